@@ -0,0 +1,474 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hamza72x/islamqa-scrapper/content"
+	"github.com/hamza72x/islamqa-scrapper/dates"
+	"github.com/hamza72x/islamqa-scrapper/log"
+	"github.com/hamza72x/islamqa-scrapper/search"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultLimit = 20
+	maxLimit     = 100
+)
+
+// Server is the read-only JSON API over the scraped contents
+type Server struct {
+	db     *gorm.DB
+	mux    *http.ServeMux
+	routes []route
+}
+
+// route is an endpoint, described for the OpenAPI spec
+type route struct {
+	Path     string
+	Summary  string
+	Params   []param
+	Response interface{}
+	handler  http.HandlerFunc
+}
+
+type param struct {
+	Name        string
+	In          string
+	Type        string
+	Description string
+	Required    bool
+}
+
+var (
+	errNotFound = errors.New("not found")
+
+	// errInternal is the error of the failures of the server, their
+	// causes are logged, not told to the clients
+	errInternal = errors.New("internal error")
+
+	languageParam = param{"language", "query", "string", "language code, e.g. en", false}
+	cursorParam   = param{"cursor", "query", "string", "next_cursor of the previous page", false}
+	limitParam    = param{"limit", "query", "integer", "page size, 20 by default, 100 at most", false}
+
+	publishedSinceParam = param{"published_since", "query", "string", "published at or after, by the date of the page, RFC 3339 or YYYY-MM-DD", false}
+	publishedUntilParam = param{"published_until", "query", "string", "published before, RFC 3339 or YYYY-MM-DD", false}
+	minViewsParam       = param{"min_views", "query", "integer", "viewed at least as many times", false}
+	authorParam         = param{"author", "query", "string", "author name", false}
+)
+
+func New(db *gorm.DB) *Server {
+	s := &Server{
+		db:  db,
+		mux: http.NewServeMux(),
+	}
+
+	s.routes = []route{
+		{
+			Path:    "/fatwas",
+			Summary: "List fatwas and articles, oldest first",
+			Params: []param{
+				{"site", "query", "string", "site adapter, e.g. islamqa", false},
+				languageParam,
+				{"kind", "query", "string", "fatwa or article", false},
+				{"since", "query", "string", "last modified at or after, RFC 3339 or YYYY-MM-DD", false},
+				{"until", "query", "string", "last modified before, RFC 3339 or YYYY-MM-DD", false},
+				publishedSinceParam,
+				publishedUntilParam,
+				minViewsParam,
+				authorParam,
+				{"category", "query", "string", "topic id or name", false},
+				cursorParam,
+				limitParam,
+			},
+			Response: FatwaList{},
+			handler:  s.list,
+		},
+		{
+			Path:    "/fatwas/{question_id}",
+			Summary: "Get a fatwa in every language it's translated to",
+			Params: []param{
+				{"question_id", "path", "integer", "question id, as in the url", true},
+				languageParam,
+			},
+			Response: FatwaList{},
+			handler:  s.get,
+		},
+		{
+			Path:    "/lookup",
+			Summary: "Get a fatwa by its url",
+			Params: []param{
+				{"url", "query", "string", "url of the fatwa", true},
+			},
+			Response: Fatwa{},
+			handler:  s.lookup,
+		},
+		{
+			Path:    "/search",
+			Summary: "Full-text search, best ranked first",
+			Params: []param{
+				{"q", "query", "string", "search terms", true},
+				languageParam,
+				publishedSinceParam,
+				publishedUntilParam,
+				minViewsParam,
+				authorParam,
+				{"sort", "query", "string", "rank (default), published (latest first) or views (most viewed first)", false},
+				cursorParam,
+				limitParam,
+			},
+			Response: SearchResults{},
+			handler:  s.search,
+		},
+		{
+			Path:     "/openapi.json",
+			Summary:  "This OpenAPI spec",
+			Response: map[string]interface{}{},
+			handler:  s.openAPI,
+		},
+	}
+
+	for _, r := range s.routes {
+		pattern := r.Path
+		if i := strings.Index(pattern, "{"); i >= 0 {
+			pattern = pattern[:i]
+		}
+		s.mux.HandleFunc(pattern, readOnly(r.handler))
+	}
+
+	s.mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, errNotFound)
+	})
+
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// readOnly allows GET and HEAD only
+func readOnly(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (s *Server) list(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	limit, err := parseLimit(q.Get("limit"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	after, err := decodeCursor(q.Get("cursor"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	tx := s.db.Where("id > ?", after)
+
+	if site := q.Get("site"); len(site) > 0 {
+		tx = tx.Where("site = ?", site)
+	}
+
+	if language := q.Get("language"); len(language) > 0 {
+		tx = tx.Where("language = ?", language)
+	}
+
+	if kind := q.Get("kind"); len(kind) > 0 {
+		tx = tx.Where("kind = ?", kind)
+	}
+
+	for name, op := range map[string]string{"since": ">=", "until": "<"} {
+		if len(q.Get(name)) == 0 {
+			continue
+		}
+		t, err := parseTime(q.Get(name))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, errors.New("invalid "+name+": "+q.Get(name)))
+			return
+		}
+		tx = tx.Where("last_modified "+op+" ?", t)
+	}
+
+	for name, op := range map[string]string{"published_since": ">=", "published_until": "<"} {
+		if len(q.Get(name)) == 0 {
+			continue
+		}
+		t, err := parseTime(q.Get(name))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, errors.New("invalid "+name+": "+q.Get(name)))
+			return
+		}
+		tx = tx.Where("published_at "+op+" ?", t)
+	}
+
+	if len(q.Get("min_views")) > 0 {
+		minViews, err := strconv.ParseInt(q.Get("min_views"), 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, errors.New("invalid min_views: "+q.Get("min_views")))
+			return
+		}
+		tx = tx.Where("views >= ?", minViews)
+	}
+
+	if author := q.Get("author"); len(author) > 0 {
+		tx = tx.Where("author = ?", author)
+	}
+
+	if category := q.Get("category"); len(category) > 0 {
+		categories := s.db.Model(&content.Category{}).Select("content_id")
+		if topicID, err := strconv.ParseUint(category, 10, 64); err == nil {
+			categories = categories.Where("topic_id = ?", topicID)
+		} else {
+			categories = categories.Where("name = ?", category)
+		}
+		tx = tx.Where("id IN (?)", categories)
+	}
+
+	tx = tx.Order("id").Limit(limit + 1)
+
+	items, err := s.find(tx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	list := FatwaList{Items: items}
+	if len(items) > limit {
+		list.Items = items[:limit]
+		list.NextCursor = encodeCursor(items[limit-1].ID)
+	}
+
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *Server) get(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	questionID, err := strconv.ParseUint(strings.TrimPrefix(r.URL.Path, "/fatwas/"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusNotFound, errNotFound)
+		return
+	}
+
+	tx := s.db.Where("question_id = ?", questionID)
+	if language := q.Get("language"); len(language) > 0 {
+		tx = tx.Where("language = ?", language)
+	}
+
+	items, err := s.find(tx.Order("language, kind"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if len(items) == 0 {
+		writeError(w, http.StatusNotFound, errNotFound)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, FatwaList{Items: items})
+}
+
+func (s *Server) lookup(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	if len(q.Get("url")) == 0 {
+		writeError(w, http.StatusBadRequest, errors.New("url is required"))
+		return
+	}
+
+	items, err := s.find(s.db.Where("url = ?", q.Get("url")).Limit(1))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if len(items) == 0 {
+		writeError(w, http.StatusNotFound, errNotFound)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, items[0])
+}
+
+func (s *Server) search(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	if len(strings.TrimSpace(q.Get("q"))) == 0 {
+		writeError(w, http.StatusBadRequest, errors.New("q is required"))
+		return
+	}
+
+	limit, err := parseLimit(q.Get("limit"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// search is ranked, its cursor is the offset
+	offset, err := decodeCursor(q.Get("cursor"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	opts := search.Options{
+		Language:       q.Get("language"),
+		Author:         q.Get("author"),
+		Sort:           q.Get("sort"),
+		Limit:          limit + 1,
+		Offset:         int(offset),
+		HighlightStart: "<mark>",
+		HighlightEnd:   "</mark>",
+	}
+
+	for name, t := range map[string]*time.Time{"published_since": &opts.PublishedSince, "published_until": &opts.PublishedUntil} {
+		if len(q.Get(name)) == 0 {
+			continue
+		}
+		if *t, err = parseTime(q.Get(name)); err != nil {
+			writeError(w, http.StatusBadRequest, errors.New("invalid "+name+": "+q.Get(name)))
+			return
+		}
+	}
+
+	if len(q.Get("min_views")) > 0 {
+		if opts.MinViews, err = strconv.ParseInt(q.Get("min_views"), 10, 64); err != nil {
+			writeError(w, http.StatusBadRequest, errors.New("invalid min_views: "+q.Get("min_views")))
+			return
+		}
+	}
+
+	switch opts.Sort {
+	case "", search.SortRank, search.SortPublished, search.SortViews:
+	default:
+		writeError(w, http.StatusBadRequest, errors.New("invalid sort: "+opts.Sort))
+		return
+	}
+
+	results, err := search.Search(s.db, q.Get("q"), opts)
+	if err != nil {
+		if errors.Is(err, search.ErrUnavailable) {
+			writeError(w, http.StatusServiceUnavailable, err)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	list := SearchResults{Items: []SearchResult{}}
+	for _, result := range results {
+		list.Items = append(list.Items, fromSearchResult(result))
+	}
+
+	if len(list.Items) > limit {
+		list.Items = list.Items[:limit]
+		list.NextCursor = encodeCursor(offset + uint(limit))
+	}
+
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *Server) openAPI(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.OpenAPI())
+}
+
+// find runs the query on the contents
+func (s *Server) find(tx *gorm.DB) ([]Fatwa, error) {
+	items := []Fatwa{}
+
+	contents := []*content.Content{}
+	if err := tx.
+		Preload("Categories").
+		Preload("References", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position")
+		}).
+		Find(&contents).
+		Error; err != nil {
+		return nil, err
+	}
+	for _, c := range contents {
+		items = append(items, fromContent(c))
+	}
+
+	return items, nil
+}
+
+func parseLimit(limit string) (int, error) {
+	if len(limit) == 0 {
+		return defaultLimit, nil
+	}
+
+	n, err := strconv.Atoi(limit)
+	if err != nil || n < 1 || n > maxLimit {
+		return 0, errors.New("limit must be between 1 and " + strconv.Itoa(maxLimit))
+	}
+
+	return n, nil
+}
+
+// parseTime parses a date of a parameter to UTC, see dates.Parse
+func parseTime(value string) (time.Time, error) {
+	return dates.Parse(value, "")
+}
+
+// cursors are opaque to the clients
+func encodeCursor(n uint) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatUint(uint64(n), 10)))
+}
+
+func decodeCursor(cursor string) (uint, error) {
+	if len(cursor) == 0 {
+		return 0, nil
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, errors.New("invalid cursor")
+	}
+
+	n, err := strconv.ParseUint(string(b), 10, 64)
+	if err != nil {
+		return 0, errors.New("invalid cursor")
+	}
+
+	return uint(n), nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+
+	if err := encoder.Encode(v); err != nil {
+		log.Err("failed to write response:", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	if status == http.StatusInternalServerError {
+		log.Err("failed to serve request:", err)
+		err = errInternal
+	}
+
+	writeJSON(w, status, Error{Error: err.Error()})
+}
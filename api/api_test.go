@@ -0,0 +1,291 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/hamza72x/islamqa-scrapper/content"
+	"github.com/hamza72x/islamqa-scrapper/migrations"
+	"github.com/hamza72x/islamqa-scrapper/search"
+	"github.com/hamza72x/islamqa-scrapper/storage/storagetest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fts is whether the test database has full-text search,
+// sqlite has it with the sqlite_fts5 build tag only
+var fts bool
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	db := storagetest.Open(t)
+
+	_, err := migrations.Up(db)
+	require.NoError(t, err)
+
+	err = search.Migrate(db)
+	if err != nil && !errors.Is(err, search.ErrUnavailable) {
+		require.NoError(t, err)
+	}
+	fts = err == nil
+
+	views := int64(100)
+	published := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	contents := []*content.Content{
+		{
+			URL: "https://islamqa.info/en/answers/1/fasting", Site: "islamqa", Language: "en", Kind: content.KindFatwa, QuestionID: 1,
+			Title: str("Fasting in Ramadan"), Text: str("The fast of Ramadan is obligatory."),
+			PublishedAt: &published, Views: &views,
+			Categories: []content.Category{{TopicID: 63, Name: "Fasting"}},
+			References: []content.Reference{{Position: 1, Marker: "1", Text: "Narrated by al-Bukhari (1891)"}},
+		},
+		{
+			URL: "https://islamqa.info/ar/answers/1/fasting", Site: "islamqa", Language: "ar", Kind: content.KindFatwa, QuestionID: 1,
+			Title: str("صيام رمضان"), Text: str("صيام رمضان واجب."),
+		},
+		{
+			URL: "https://islamqa.info/en/answers/2/prayer", Site: "islamqa", Language: "en", Kind: content.KindFatwa, QuestionID: 2,
+			Title: str("Prayer times"), Text: str("The times of the five prayers."),
+		},
+		{
+			URL: "https://islamqa.info/en/articles/3/zakat", Site: "islamqa", Language: "en", Kind: content.KindArticle, QuestionID: 3,
+			Title: str("Zakat"), Text: str("Zakat is due on wealth."),
+		},
+	}
+
+	for _, c := range contents {
+		c.Normalize()
+		require.NoError(t, db.Create(c).Error)
+	}
+
+	server := httptest.NewServer(New(db))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func str(s string) *string {
+	return &s
+}
+
+// get decodes the json response of a path into v, it returns the status
+func get(t *testing.T, server *httptest.Server, path string, v interface{}) int {
+	t.Helper()
+
+	resp, err := http.Get(server.URL + path)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, "application/json; charset=utf-8", resp.Header.Get("Content-Type"))
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(v))
+
+	return resp.StatusCode
+}
+
+func TestList(t *testing.T) {
+	server := newTestServer(t)
+
+	var list FatwaList
+	require.Equal(t, http.StatusOK, get(t, server, "/fatwas", &list))
+	assert.Len(t, list.Items, 4)
+	assert.Empty(t, list.NextCursor)
+
+	first := list.Items[0]
+	assert.Equal(t, uint(1), first.QuestionID)
+	assert.Equal(t, "en", first.Language)
+	assert.Equal(t, content.DirectionLTR, first.Direction)
+	assert.Equal(t, []Category{{TopicID: 63, Name: "Fasting"}}, first.Categories)
+	assert.Equal(t, []Reference{{Position: 1, Marker: "1", Text: "Narrated by al-Bukhari (1891)"}}, first.References)
+
+	filters := map[string][]uint{
+		"/fatwas?language=ar":                    {1},
+		"/fatwas?kind=article":                   {3},
+		"/fatwas?category=63":                    {1},
+		"/fatwas?category=Fasting":               {1},
+		"/fatwas?min_views=50":                   {1},
+		"/fatwas?published_since=2020-01-01":     {1},
+		"/fatwas?published_until=2020-01-01":     {},
+		"/fatwas?site=islamqa&language=en&kind=": {1, 2, 3},
+	}
+
+	for path, want := range filters {
+		var list FatwaList
+		require.Equal(t, http.StatusOK, get(t, server, path, &list), path)
+
+		got := []uint{}
+		for _, item := range list.Items {
+			got = append(got, item.QuestionID)
+		}
+		assert.Equal(t, want, got, path)
+	}
+}
+
+func TestListCursor(t *testing.T) {
+	server := newTestServer(t)
+
+	ids := []uint{}
+	cursor := ""
+	pages := 0
+
+	for {
+		var list FatwaList
+		require.Equal(t, http.StatusOK, get(t, server, "/fatwas?limit=3&cursor="+cursor, &list))
+		pages++
+
+		for _, item := range list.Items {
+			ids = append(ids, item.ID)
+		}
+
+		if len(list.NextCursor) == 0 {
+			break
+		}
+		cursor = list.NextCursor
+	}
+
+	assert.Equal(t, 2, pages)
+	assert.Equal(t, []uint{1, 2, 3, 4}, ids)
+}
+
+func TestGet(t *testing.T) {
+	server := newTestServer(t)
+
+	var list FatwaList
+	require.Equal(t, http.StatusOK, get(t, server, "/fatwas/1", &list))
+	if assert.Len(t, list.Items, 2) {
+		assert.Equal(t, "ar", list.Items[0].Language)
+		assert.Equal(t, content.DirectionRTL, list.Items[0].Direction)
+		assert.Equal(t, "en", list.Items[1].Language)
+	}
+
+	require.Equal(t, http.StatusOK, get(t, server, "/fatwas/1?language=en", &list))
+	assert.Len(t, list.Items, 1)
+
+	var e Error
+	assert.Equal(t, http.StatusNotFound, get(t, server, "/fatwas/99", &e))
+	assert.Equal(t, http.StatusNotFound, get(t, server, "/fatwas/abc", &e))
+}
+
+func TestLookup(t *testing.T) {
+	server := newTestServer(t)
+
+	var f Fatwa
+	loc := "https://islamqa.info/en/answers/2/prayer"
+	require.Equal(t, http.StatusOK, get(t, server, "/lookup?url="+url.QueryEscape(loc), &f))
+	assert.Equal(t, loc, f.URL)
+	assert.Equal(t, "Prayer times", *f.Title)
+
+	var e Error
+	assert.Equal(t, http.StatusNotFound, get(t, server, "/lookup?url="+url.QueryEscape("https://islamqa.info/en/answers/9"), &e))
+}
+
+func TestSearch(t *testing.T) {
+	server := newTestServer(t)
+
+	var list SearchResults
+	status := get(t, server, "/search?q=ramadan&language=en", &list)
+	if !fts {
+		assert.Equal(t, http.StatusServiceUnavailable, status)
+		t.Skip(search.ErrUnavailable)
+	}
+
+	require.Equal(t, http.StatusOK, status)
+	if assert.Len(t, list.Items, 1) {
+		assert.Equal(t, "https://islamqa.info/en/answers/1/fasting", list.Items[0].URL)
+		assert.Contains(t, list.Items[0].Snippet, "<mark>")
+	}
+
+	// the cursor of a ranked search is the offset
+	list = SearchResults{}
+	require.Equal(t, http.StatusOK, get(t, server, "/search?q=the&limit=1", &list))
+	if assert.Len(t, list.Items, 1) && assert.NotEmpty(t, list.NextCursor) {
+		first, cursor := list.Items[0].ID, list.NextCursor
+
+		list = SearchResults{}
+		require.Equal(t, http.StatusOK, get(t, server, "/search?q=the&limit=1&cursor="+cursor, &list))
+		if assert.Len(t, list.Items, 1) {
+			assert.NotEqual(t, first, list.Items[0].ID)
+		}
+	}
+}
+
+func TestOpenAPI(t *testing.T) {
+	server := newTestServer(t)
+
+	var spec map[string]interface{}
+	require.Equal(t, http.StatusOK, get(t, server, "/openapi.json", &spec))
+	assert.Equal(t, "3.0.3", spec["openapi"])
+
+	paths, ok := spec["paths"].(map[string]interface{})
+	require.True(t, ok)
+	for _, path := range []string{"/fatwas", "/fatwas/{question_id}", "/lookup", "/search", "/openapi.json"} {
+		assert.Contains(t, paths, path)
+	}
+}
+
+func TestBadRequest(t *testing.T) {
+	server := newTestServer(t)
+
+	paths := []string{
+		"/fatwas?limit=0",
+		"/fatwas?limit=101",
+		"/fatwas?limit=abc",
+		"/fatwas?cursor=!!",
+		"/fatwas?cursor=" + base64.RawURLEncoding.EncodeToString([]byte("abc")),
+		"/fatwas?since=yesterday",
+		"/fatwas?until=2020-13-01",
+		"/fatwas?published_since=abc",
+		"/fatwas?min_views=abc",
+		"/lookup",
+		"/search",
+		"/search?q=%20",
+		"/search?q=ramadan&limit=1000",
+		"/search?q=ramadan&cursor=!!",
+		"/search?q=ramadan&sort=title",
+		"/search?q=ramadan&min_views=abc",
+		"/search?q=ramadan&published_until=abc",
+	}
+
+	for _, path := range paths {
+		var e Error
+		assert.Equal(t, http.StatusBadRequest, get(t, server, path, &e), path)
+		assert.NotEmpty(t, e.Error, path)
+	}
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	server := newTestServer(t)
+
+	resp, err := http.Post(server.URL+"/fatwas", "application/json", nil)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+	assert.Equal(t, "GET, HEAD", resp.Header.Get("Allow"))
+}
+
+// TestInternalError tells the clients of no database error
+func TestInternalError(t *testing.T) {
+	db := storagetest.Open(t)
+
+	_, err := migrations.Up(db)
+	require.NoError(t, err)
+	require.NoError(t, db.Migrator().DropTable("contents"))
+
+	server := httptest.NewServer(New(db))
+	t.Cleanup(server.Close)
+
+	for _, path := range []string{"/fatwas", "/fatwas/1", "/lookup?url=https://islamqa.info/en/answers/1"} {
+		var e Error
+		assert.Equal(t, http.StatusInternalServerError, get(t, server, path, &e), path)
+		assert.Equal(t, "internal error", e.Error, path)
+	}
+}
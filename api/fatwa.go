@@ -0,0 +1,146 @@
+package api
+
+import (
+	"time"
+
+	"github.com/hamza72x/islamqa-scrapper/content"
+	"github.com/hamza72x/islamqa-scrapper/search"
+)
+
+const SourceContents = "contents"
+
+// Fatwa is a content (fatwa or article)
+type Fatwa struct {
+	ID         uint   `json:"id"`
+	Source     string `json:"source"`
+	Site       string `json:"site"`
+	QuestionID uint   `json:"question_id"`
+	URL        string `json:"url"`
+	Language   string `json:"language"`
+	Kind       string `json:"kind"`
+	Direction  string `json:"direction"`
+
+	Title *string `json:"title"`
+
+	// Question is the question body, or the seo description of an article
+	Question *string `json:"question,omitempty"`
+
+	// Summary and the answer formats are of the full crawls only
+	Summary  *string `json:"summary,omitempty"`
+	HTML     *string `json:"html,omitempty"`
+	Markdown *string `json:"markdown,omitempty"`
+	Text     *string `json:"text,omitempty"`
+
+	LastModified time.Time `json:"last_modified"`
+
+	// Published, Modified, Views, Author and Publisher are the
+	// metadata of the page, of the full crawls only
+	Published *time.Time `json:"published,omitempty"`
+	Modified  *time.Time `json:"modified,omitempty"`
+	Views     *int64     `json:"views,omitempty"`
+	Author    *string    `json:"author,omitempty"`
+	Publisher *string    `json:"publisher,omitempty"`
+
+	Categories []Category  `json:"categories,omitempty"`
+	References []Reference `json:"references,omitempty"`
+}
+
+type Category struct {
+	TopicID uint   `json:"topic_id"`
+	Name    string `json:"name"`
+}
+
+type Reference struct {
+	Position     int    `json:"position"`
+	Marker       string `json:"marker,omitempty"`
+	MarkerAnchor string `json:"marker_anchor,omitempty"`
+	Anchor       string `json:"anchor,omitempty"`
+	Text         string `json:"text"`
+}
+
+// FatwaList is a page of fatwas, NextCursor is empty on the last page
+type FatwaList struct {
+	Items      []Fatwa `json:"items"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
+
+type SearchResult struct {
+	Source    string     `json:"source"`
+	ID        uint       `json:"id"`
+	URL       string     `json:"url"`
+	Title     string     `json:"title"`
+	Language  string     `json:"language"`
+	Snippet   string     `json:"snippet"`
+	Published *time.Time `json:"published,omitempty"`
+	Views     *int64     `json:"views,omitempty"`
+	Author    string     `json:"author,omitempty"`
+	Rank      float64    `json:"rank"`
+}
+
+type SearchResults struct {
+	Items      []SearchResult `json:"items"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+type Error struct {
+	Error string `json:"error"`
+}
+
+func fromContent(c *content.Content) Fatwa {
+	f := Fatwa{
+		ID:           c.ID,
+		Source:       SourceContents,
+		Site:         c.Site,
+		QuestionID:   c.QuestionID,
+		URL:          c.URL,
+		Language:     c.Language,
+		Kind:         c.Kind,
+		Direction:    content.Direction(c.Language),
+		Title:        c.Title,
+		Question:     c.Question,
+		Summary:      c.Summary,
+		HTML:         c.HTML,
+		Markdown:     c.Markdown,
+		Text:         c.Text,
+		LastModified: c.LastModified,
+		Published:    c.PublishedAt,
+		Modified:     c.ModifiedAt,
+		Views:        c.Views,
+		Author:       c.Author,
+		Publisher:    c.Publisher,
+	}
+
+	for _, category := range c.Categories {
+		f.Categories = append(f.Categories, Category{
+			TopicID: category.TopicID,
+			Name:    category.Name,
+		})
+	}
+
+	for _, ref := range c.References {
+		f.References = append(f.References, Reference{
+			Position:     ref.Position,
+			Marker:       ref.Marker,
+			MarkerAnchor: ref.MarkerAnchor,
+			Anchor:       ref.Anchor,
+			Text:         ref.Text,
+		})
+	}
+
+	return f
+}
+
+func fromSearchResult(r search.Result) SearchResult {
+	return SearchResult{
+		Source:    r.Source,
+		ID:        r.ID,
+		URL:       r.URL,
+		Title:     r.Title,
+		Language:  r.Language,
+		Snippet:   r.Snippet,
+		Published: r.Published,
+		Views:     r.Views,
+		Author:    r.Author,
+		Rank:      r.Rank,
+	}
+}
@@ -0,0 +1,135 @@
+package api
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OpenAPI generates the OpenAPI 3 spec of the routes,
+// response schemas are reflected from the response types
+func (s *Server) OpenAPI() map[string]interface{} {
+	schemas := map[string]interface{}{}
+	paths := map[string]interface{}{}
+
+	errorRef := schemaRef(reflect.TypeOf(Error{}), schemas)
+
+	for _, r := range s.routes {
+		parameters := []interface{}{}
+		for _, p := range r.Params {
+			parameters = append(parameters, map[string]interface{}{
+				"name":        p.Name,
+				"in":          p.In,
+				"description": p.Description,
+				"required":    p.Required,
+				"schema":      map[string]interface{}{"type": p.Type},
+			})
+		}
+
+		responses := map[string]interface{}{
+			"200": response("OK", schemaRef(reflect.TypeOf(r.Response), schemas)),
+		}
+		for _, status := range []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError} {
+			responses[strconv.Itoa(status)] = response(http.StatusText(status), errorRef)
+		}
+
+		paths[r.Path] = map[string]interface{}{
+			"get": map[string]interface{}{
+				"summary":    r.Summary,
+				"parameters": parameters,
+				"responses":  responses,
+			},
+		}
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   "islamqa-scrapper",
+			"version": "1",
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": schemas,
+		},
+	}
+}
+
+func response(description string, schema interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"description": description,
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{
+				"schema": schema,
+			},
+		},
+	}
+}
+
+// schemaRef returns the schema of t, named structs are added
+// to schemas and referenced
+func schemaRef(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	if t.Kind() == reflect.Ptr {
+		schema := schemaRef(t.Elem(), schemas)
+		if _, isRef := schema["$ref"]; !isRef {
+			schema["nullable"] = true
+		}
+		return schema
+	}
+
+	if t == reflect.TypeOf(time.Time{}) {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": schemaRef(t.Elem(), schemas)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object"}
+	case reflect.Struct:
+	default:
+		return map[string]interface{}{}
+	}
+
+	ref := map[string]interface{}{"$ref": "#/components/schemas/" + t.Name()}
+	if _, ok := schemas[t.Name()]; ok {
+		return ref
+	}
+
+	properties := map[string]interface{}{}
+	required := []string{}
+	schemas[t.Name()] = map[string]interface{}{"type": "object", "properties": properties}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if len(name) == 0 {
+			name = field.Name
+		}
+
+		properties[name] = schemaRef(field.Type, schemas)
+		if !strings.Contains(opts, "omitempty") && field.Type.Kind() != reflect.Ptr {
+			required = append(required, name)
+		}
+	}
+
+	if len(required) > 0 {
+		schemas[t.Name()].(map[string]interface{})["required"] = required
+	}
+
+	return ref
+}
@@ -0,0 +1,77 @@
+package content
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Category is a topic a content is listed under
+type Category struct {
+	ID        uint `gorm:"primarykey;column:id"`
+	ContentID uint `gorm:"column:content_id;index"`
+
+	// TopicID is the id of the topic in the site, shared by languages
+	TopicID uint   `gorm:"column:topic_id;index"`
+	Name    string `gorm:"column:name"`
+}
+
+func (Category) TableName() string {
+	return "content_categories"
+}
+
+// ParseCategories parses the topics of the page from the links of
+// the link selector, the first group of topic matched in their href
+// is the topic id, the whole html page is expected
+/*
+	ParseCategories(body, "[class*='breadcrumb'] a[href]", regexp.MustCompile(`/categories/topics/(\d+)`))
+
+	<nav class="breadcrumb">
+		<ul>
+			<li><a href="https://islamqa.info/en/categories/topics/63/fasting">Fasting</a></li>
+		</ul>
+	</nav>
+*/
+func ParseCategories(body string, linkSelector string, topic *regexp.Regexp) []Category {
+	if len(linkSelector) == 0 || topic == nil {
+		return []Category{}
+	}
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(body))
+	if err != nil {
+		return []Category{}
+	}
+
+	categories := []Category{}
+	seen := map[uint]bool{}
+
+	doc.Find(linkSelector).Each(func(_ int, a *goquery.Selection) {
+		href, _ := a.Attr("href")
+
+		m := topic.FindStringSubmatch(href)
+		if len(m) < 2 {
+			return
+		}
+
+		id, err := strconv.ParseUint(m[1], 10, 64)
+		if err != nil || seen[uint(id)] {
+			return
+		}
+
+		name := strings.Join(strings.Fields(a.Text()), " ")
+		if len(name) == 0 {
+			return
+		}
+
+		seen[uint(id)] = true
+
+		categories = append(categories, Category{
+			TopicID: uint(id),
+			Name:    name,
+		})
+	})
+
+	return categories
+}
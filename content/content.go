@@ -1,18 +1,12 @@
 package content
 
 import (
-	"bytes"
-	"errors"
-	"io"
-	"net/http"
 	"strings"
 	"time"
 
-	"github.com/hamza72x/islamqa-scrapper/helper"
-	"github.com/hamza72x/islamqa-scrapper/log"
-	"github.com/hamza72x/islamqa-scrapper/sitemap"
+	"github.com/hamza72x/islamqa-scrapper/normalize"
+	"github.com/hamza72x/islamqa-scrapper/storage"
 
-	"github.com/PuerkitoBio/goquery"
 	"gorm.io/gorm"
 )
 
@@ -22,9 +16,16 @@ type Content struct {
 	// URL is the url of the content
 	URL string `gorm:"column:url;uniqueIndex"`
 
-	// Title is parsed question/title (fatwa/article)
+	// Site is the name of the site adapter of the url, e.g. "islamqa"
+	Site string `gorm:"column:site;index"`
+
+	// Title is the parsed title of the fatwa/article
 	Title *string `gorm:"column:title"`
 
+	// Question is the parsed question of a fatwa,
+	// or the seo description of an article
+	Question *string `gorm:"column:question"`
+
 	// Content is the parsed answer/content (fatwa/article)
 	Content *string `gorm:"column:content"`
 
@@ -35,93 +36,105 @@ type Content struct {
 	// Body is the whole html body
 	Body string `gorm:"column:body"`
 
+	// Language is the language code from the url, e.g. "en"
+	Language string `gorm:"column:language;index"`
+
+	// Kind is KindFatwa or KindArticle, from the url
+	Kind string `gorm:"column:kind;index"`
+
+	// QuestionID is the id from the url, shared by the translations
+	QuestionID uint `gorm:"column:question_id;index"`
+
+	// HTML, Markdown and Text are the answer (Content) converted,
+	// HTML is sanitised and carries the dir and lang of the answer
+	HTML     *string `gorm:"column:html"`
+	Markdown *string `gorm:"column:markdown"`
+	Text     *string `gorm:"column:text"`
+
+	// TitleNormalized is Title and TextNormalized is Question and Text
+	// normalised for search and duplicate detection
+	TitleNormalized string `gorm:"column:title_normalized;index"`
+	TextNormalized  string `gorm:"column:text_normalized"`
+
+	// Hash is the ContentHash, set by Normalize, for change detection
+	Hash string `gorm:"column:hash;index"`
+
+	// JSONLD, Microdata and OpenGraph are the structured data of the
+	// page as parsed, see package structured, the values of it are
+	// preferred over the css selectors by the rules
+	JSONLD    storage.JSON[[]map[string]interface{}] `gorm:"column:json_ld"`
+	Microdata storage.JSON[[]map[string]interface{}] `gorm:"column:microdata"`
+	OpenGraph storage.JSON[map[string]string]        `gorm:"column:open_graph"`
+
+	// LastModified is the lastmod of the sitemap url
 	LastModified time.Time `gorm:"column:last_modified"`
-}
 
-func New(url *sitemap.URL) (*Content, error) {
+	// PublishedAt and ModifiedAt are the dates of the page itself,
+	// in UTC, nil when it has none
+	PublishedAt *time.Time `gorm:"column:published_at;index"`
+	ModifiedAt  *time.Time `gorm:"column:modified_at;index"`
 
-	resp, err := helper.GetURLResponse(url.Loc, "")
+	// Views is the view count shown by the page
+	Views *int64 `gorm:"column:views;index"`
 
-	if err != nil {
-		return nil, err
-	}
+	// Author and Publisher are the names in the page metadata
+	Author    *string `gorm:"column:author;index"`
+	Publisher *string `gorm:"column:publisher"`
+
+	// Crawl is the name of the Config the content was parsed by,
+	// CrawlFull or CrawlLight
+	Crawl string `gorm:"column:crawl;index"`
 
-	defer resp.Body.Close()
+	// References are the parsed footnotes and reference section
+	// stored separately, load with Preload("References")
+	References []Reference `gorm:"foreignKey:ContentID"`
+
+	// Categories are the parsed topics, load with Preload("Categories")
+	Categories []Category `gorm:"foreignKey:ContentID"`
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, errors.New("status code is not ok, but: " + resp.Status)
+// Normalize populates the normalised columns from Title, Question
+// and Text, and the Hash
+func (c *Content) Normalize() {
+	c.TitleNormalized = ""
+	if c.Title != nil {
+		c.TitleNormalized = normalize.Text(*c.Title)
 	}
 
-	htmlBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+	text := []string{}
+	if c.Question != nil {
+		text = append(text, *c.Question)
 	}
+	if c.Text != nil {
+		text = append(text, *c.Text)
+	}
+	c.TextNormalized = normalize.Text(strings.Join(text, "\n"))
+
+	c.Hash = c.ContentHash()
+}
+
+// PlainText is the text of title, question, summary and answer
+// joined by new lines, answer html tags are stripped
+func (c *Content) PlainText() string {
+	parts := []string{}
 
-	c := &Content{
-		URL:          resp.Request.URL.String(),
-		Body:         string(htmlBytes),
-		LastModified: url.LastMod,
+	if c.Title != nil {
+		parts = append(parts, *c.Title)
 	}
 
-	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(htmlBytes))
-	if err != nil {
-		log.Fatal(err)
+	if c.Question != nil {
+		parts = append(parts, *c.Question)
 	}
 
-	// question
-	/*
-		<section class="single_fatwa__question text-justified">
-			<h2 class="has-text-weight-bold subtitle">প্রশ্ন</h2>
-			<div>প্রশ্ন: আমি আমেরিকাতে প্রবাসী। স্বর্ণের নিসাব আমেরিকান ডলারে কত আসবে?</div>
-		</section>
-	*/
-
-	question := doc.Find("section.single_fatwa__question").Find("div").Text()
-	question = strings.TrimSpace(question)
-	if len(question) > 0 {
-		c.Title = &question
-	} else {
-		// single-layout__title
-		/*
-			<div class="single-layout__title has-text-centered">
-				<h1 class="title is-4 is-size-5-touch" itemprop="name">
-					هكذا بشر رسول الله أصحابه بقدوم رمضان
-				</h1>
-			</div>
-		*/
-		title := doc.Find("div.single-layout__title").Find("h1").Text()
-		title = strings.TrimSpace(title)
-		c.Title = &title
+	if c.Summary != nil {
+		parts = append(parts, *c.Summary)
 	}
 
-	// answer
-	/*
-		<section class="single_fatwa__answer__body text-justified _pa--0">
-			<div class="content">
-				<p>আলহামদু লিল্লাহ।.</p>
-			</div>
-		</section>
-	*/
-
-	answer, err := doc.Find("section.single_fatwa__answer__body").Find("div.content").Html()
-	if err != nil {
-		log.Warn("failed to parse answer for", c.URL, "err", err)
-	} else {
-		answer = strings.TrimSpace(answer)
-		c.Content = &answer
+	if c.Text != nil {
+		parts = append(parts, *c.Text)
+	} else if c.Content != nil {
+		parts = append(parts, Convert(*c.Content, c.Language).Text)
 	}
 
-	// summary
-	/*
-		<div class="single_fatwa__summary__body">
-			<div>
-				Giving a name such as this
-			</div>
-		</div>
-	*/
-	summary := doc.Find("div.single_fatwa__summary__body").Find("div").Text()
-	summary = strings.TrimSpace(summary)
-	c.Summary = &summary
-
-	return c, nil
+	return strings.Join(parts, "\n")
 }
@@ -0,0 +1,599 @@
+package content
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Converted is an answer in sanitised html, markdown and plain text
+type Converted struct {
+	// HTML is the allow-listed html, wrapped in a div
+	// carrying the dir and lang of the answer
+	HTML string
+
+	// Markdown is CommonMark, tables are kept as html blocks
+	Markdown string
+
+	Text string
+
+	// Direction is "rtl" or "ltr"
+	Direction string
+}
+
+var (
+	// elements kept by the sanitiser, with their allowed attributes
+	// "dir" and "lang" are allowed on every element
+	allowedElements = map[atom.Atom][]string{
+		atom.P: nil, atom.Br: nil, atom.Hr: nil,
+		atom.H1: nil, atom.H2: nil, atom.H3: nil, atom.H4: nil, atom.H5: nil, atom.H6: nil,
+		atom.Strong: nil, atom.B: nil, atom.Em: nil, atom.I: nil, atom.U: nil, atom.S: nil,
+		atom.Sub: nil, atom.Sup: nil, atom.Q: nil, atom.Cite: nil,
+		atom.Blockquote: nil, atom.Pre: nil, atom.Code: nil,
+		atom.Ul: nil, atom.Ol: {"start"}, atom.Li: nil,
+		atom.Dl: nil, atom.Dt: nil, atom.Dd: nil,
+		atom.A:     {"href"},
+		atom.Img:   {"src", "alt"},
+		atom.Table: nil, atom.Thead: nil, atom.Tbody: nil, atom.Tfoot: nil, atom.Tr: nil,
+		atom.Th: {"colspan", "rowspan"}, atom.Td: {"colspan", "rowspan"},
+	}
+
+	// elements removed together with their content
+	droppedElements = map[atom.Atom]bool{
+		atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+		atom.Iframe: true, atom.Object: true, atom.Embed: true, atom.Svg: true, atom.Math: true,
+		atom.Form: true, atom.Input: true, atom.Button: true, atom.Select: true, atom.Textarea: true,
+		atom.Head: true, atom.Title: true, atom.Meta: true, atom.Link: true,
+	}
+
+	// inline elements which are dropped when empty
+	formattingElements = map[atom.Atom]bool{
+		atom.A: true, atom.Strong: true, atom.B: true, atom.Em: true, atom.I: true, atom.U: true,
+		atom.S: true, atom.Sub: true, atom.Sup: true, atom.Q: true, atom.Cite: true, atom.Code: true,
+		atom.Span: true,
+	}
+
+	blockElements = map[atom.Atom]bool{
+		atom.P: true, atom.Hr: true, atom.Blockquote: true, atom.Pre: true,
+		atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+		atom.Ul: true, atom.Ol: true, atom.Li: true, atom.Dl: true, atom.Dt: true, atom.Dd: true,
+		atom.Table: true, atom.Div: true, atom.Section: true, atom.Article: true,
+	}
+
+	whitespaceRegex = regexp.MustCompile(`\s+`)
+
+	// line starts that CommonMark would read as a block marker, a heading,
+	// a list item, a thematic break or setext underline, a code fence
+	// or a quote, before a hard line break too, "*", "_" and "`" are
+	// escaped anywhere
+	markdownLineStartRegex = regexp.MustCompile(`^(#{1,6}(\s|$)|[-+*](\s|$)|(-\s*)+\\?$|~{3,}|\d{1,9}[.)](\s|$)|>|=+\s*\\?$)`)
+
+	markdownEscaper = strings.NewReplacer(
+		`\`, `\\`, "`", "\\`", `*`, `\*`, `_`, `\_`,
+		`[`, `\[`, `]`, `\]`, `<`, `\<`,
+	)
+)
+
+// Convert sanitises the answer html and converts it to markdown and text
+// language is the content language, e.g. "ar", used for direction hints
+func Convert(answer string, language string) Converted {
+	direction := Direction(language)
+
+	nodes, err := html.ParseFragment(strings.NewReader(answer), &html.Node{
+		Type:     html.ElementNode,
+		Data:     "div",
+		DataAtom: atom.Div,
+	})
+	if err != nil {
+		return Converted{Direction: direction}
+	}
+
+	cleaned := []*html.Node{}
+	for _, n := range nodes {
+		cleaned = append(cleaned, sanitize(n)...)
+	}
+
+	root := &html.Node{
+		Type:     html.ElementNode,
+		Data:     "div",
+		DataAtom: atom.Div,
+		Attr:     []html.Attribute{{Key: "dir", Val: direction}},
+	}
+	if len(language) > 0 {
+		root.Attr = append(root.Attr, html.Attribute{Key: "lang", Val: language})
+	}
+	for _, n := range cleaned {
+		root.AppendChild(n)
+	}
+
+	// arabic quotes in a left-to-right answer get their own direction
+	if direction == DirectionLTR {
+		hintDirection(root)
+	}
+
+	var b strings.Builder
+	if err := html.Render(&b, root); err != nil {
+		return Converted{Direction: direction}
+	}
+
+	return Converted{
+		HTML:      b.String(),
+		Markdown:  strings.Join(renderBlocks(root, true), "\n\n"),
+		Text:      strings.Join(renderBlocks(root, false), "\n\n"),
+		Direction: direction,
+	}
+}
+
+// sanitize returns the allow-listed copy of n, unknown elements
+// are unwrapped, dropped elements are removed with their content
+func sanitize(n *html.Node) []*html.Node {
+	switch n.Type {
+	case html.TextNode:
+		return []*html.Node{{Type: html.TextNode, Data: n.Data}}
+	case html.ElementNode:
+	default:
+		return nil
+	}
+
+	if droppedElements[n.DataAtom] {
+		return nil
+	}
+
+	children := []*html.Node{}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		children = append(children, sanitize(c)...)
+	}
+
+	attrs, ok := allowedElements[n.DataAtom]
+	tag := n.DataAtom
+	sourceAttrs := n.Attr
+
+	if !ok {
+		switch {
+		// a wrapper of inline content is a paragraph
+		case blockElements[n.DataAtom] && !hasBlock(children):
+			tag = atom.P
+		// a span is kept only for its direction or language
+		case n.DataAtom == atom.Span && (hasAttr(n, "dir") || hasAttr(n, "lang")):
+			tag = atom.Span
+		// e.g. an ayah quoted in a left-to-right answer
+		case n.DataAtom == atom.Span && isArabicScript(nodeText(n)):
+			tag = atom.Span
+			sourceAttrs = []html.Attribute{{Key: "dir", Val: DirectionRTL}}
+		default:
+			return children
+		}
+	}
+
+	el := &html.Node{
+		Type:     html.ElementNode,
+		Data:     tag.String(),
+		DataAtom: tag,
+	}
+
+	for _, a := range sourceAttrs {
+		switch {
+		case a.Key == "dir" && (a.Val == DirectionRTL || a.Val == DirectionLTR || a.Val == "auto"):
+		case a.Key == "lang" && len(a.Val) > 0 && len(a.Val) <= 16:
+		case a.Key == "href" && tag == atom.A && safeURL(a.Val, true):
+		case a.Key == "src" && tag == atom.Img && safeURL(a.Val, false):
+		case ok && contains(attrs, a.Key) && a.Key != "href" && a.Key != "src":
+		default:
+			continue
+		}
+		el.Attr = append(el.Attr, html.Attribute{Key: a.Key, Val: a.Val})
+	}
+
+	// an image without source, empty paragraphs and formatting are noise
+	switch {
+	case tag == atom.Img && !hasAttr(el, "src"):
+		return nil
+	case tag == atom.A && !hasAttr(el, "href"):
+		return children
+	case tag == atom.P && isBlank(children):
+		return nil
+	case len(children) == 0 && formattingElements[tag]:
+		return nil
+	}
+
+	for _, c := range children {
+		el.AppendChild(c)
+	}
+
+	return []*html.Node{el}
+}
+
+// hintDirection sets dir="rtl" on the blocks written in arabic script
+func hintDirection(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode {
+			continue
+		}
+
+		switch c.DataAtom {
+		case atom.P, atom.Blockquote, atom.Li, atom.Span, atom.Q,
+			atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+			if !hasAttr(c, "dir") && isArabicScript(nodeText(c)) {
+				c.Attr = append(c.Attr, html.Attribute{Key: "dir", Val: DirectionRTL})
+				continue
+			}
+		}
+
+		hintDirection(c)
+	}
+}
+
+// renderBlocks renders the children of n as markdown or plain text blocks
+func renderBlocks(n *html.Node, markdown bool) []string {
+	blocks := []string{}
+	inline := []*html.Node{}
+
+	flush := func() {
+		if text := renderParagraph(inline, markdown); len(text) > 0 {
+			blocks = append(blocks, text)
+		}
+		inline = inline[:0]
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode || !blockElements[c.DataAtom] {
+			inline = append(inline, c)
+			continue
+		}
+
+		flush()
+
+		if block := renderBlock(c, markdown); len(block) > 0 {
+			blocks = append(blocks, block)
+		}
+	}
+
+	flush()
+
+	return blocks
+}
+
+func renderBlock(n *html.Node, markdown bool) string {
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		text := renderParagraph(children(n), markdown)
+		if !markdown || len(text) == 0 {
+			return text
+		}
+		level, _ := strconv.Atoi(n.Data[1:])
+		return strings.Repeat("#", level) + " " + strings.ReplaceAll(text, "\\\n", " ")
+
+	case atom.Hr:
+		if markdown {
+			return "---"
+		}
+		return ""
+
+	case atom.Blockquote:
+		text := strings.Join(renderBlocks(n, markdown), "\n\n")
+		if !markdown {
+			return text
+		}
+		return prefixLines(text, "> ", ">")
+
+	case atom.Ul, atom.Ol, atom.Dl:
+		return renderList(n, markdown)
+
+	case atom.Pre:
+		text := strings.Trim(nodeText(n), "\n")
+		if !markdown {
+			return text
+		}
+		fence := "```"
+		for strings.Contains(text, fence) {
+			fence += "`"
+		}
+		return fence + "\n" + text + "\n" + fence
+
+	case atom.Table:
+		if !markdown {
+			rows := []string{}
+			for _, tr := range findAll(n, atom.Tr) {
+				cells := []string{}
+				for _, td := range children(tr) {
+					if td.Type == html.ElementNode {
+						cells = append(cells, renderParagraph(children(td), false))
+					}
+				}
+				rows = append(rows, strings.Join(cells, "\t"))
+			}
+			return strings.Join(rows, "\n")
+		}
+		// CommonMark has no tables, an html block is kept as is
+		var b strings.Builder
+		if err := html.Render(&b, n); err != nil {
+			return ""
+		}
+		return b.String()
+
+	case atom.P, atom.Dt, atom.Dd:
+		return renderParagraph(children(n), markdown)
+	}
+
+	return strings.Join(renderBlocks(n, markdown), "\n\n")
+}
+
+func renderList(n *html.Node, markdown bool) string {
+	items := []string{}
+
+	number := 1
+	if start, err := strconv.Atoi(attr(n, "start")); err == nil {
+		number = start
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode {
+			continue
+		}
+
+		marker := "- "
+		if n.DataAtom == atom.Ol {
+			marker = strconv.Itoa(number) + ". "
+			number++
+		}
+		if n.DataAtom == atom.Dl && c.DataAtom == atom.Dt {
+			marker = ""
+		}
+
+		text := strings.Join(renderBlocks(c, markdown), "\n\n")
+		if c.DataAtom == atom.Ul || c.DataAtom == atom.Ol {
+			text = renderList(c, markdown)
+			marker = ""
+		}
+		if len(text) == 0 {
+			continue
+		}
+
+		items = append(items, marker+prefixLines(text, strings.Repeat(" ", len(marker)), "")[len(marker):])
+	}
+
+	return strings.Join(items, "\n")
+}
+
+// renderParagraph renders inline nodes as a single paragraph
+func renderParagraph(nodes []*html.Node, markdown bool) string {
+	var b strings.Builder
+	for _, n := range nodes {
+		b.WriteString(renderInline(n, markdown))
+	}
+
+	lines := strings.Split(b.String(), "\n")
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if markdown && markdownLineStartRegex.MatchString(line) {
+			line = escapeLineStart(line)
+		}
+		lines[i] = line
+	}
+
+	text := strings.Join(lines, "\n")
+	if markdown {
+		text = strings.TrimSuffix(text, "\\")
+	}
+
+	return strings.TrimSpace(text)
+}
+
+func renderInline(n *html.Node, markdown bool) string {
+	if n.Type == html.TextNode {
+		text := whitespaceRegex.ReplaceAllString(n.Data, " ")
+		if markdown {
+			text = markdownEscaper.Replace(text)
+		}
+		return text
+	}
+
+	if n.Type != html.ElementNode {
+		return ""
+	}
+
+	inner := ""
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		inner += renderInline(c, markdown)
+	}
+
+	if n.DataAtom == atom.Br {
+		if markdown {
+			return "\\\n"
+		}
+		return "\n"
+	}
+
+	if n.DataAtom == atom.Img {
+		if markdown {
+			return "![" + markdownEscaper.Replace(attr(n, "alt")) + "](" + markdownURL(attr(n, "src")) + ")"
+		}
+		return attr(n, "alt")
+	}
+
+	if blockElements[n.DataAtom] {
+		return " " + inner + " "
+	}
+
+	if !markdown {
+		return inner
+	}
+
+	switch n.DataAtom {
+	case atom.Strong, atom.B:
+		return wrapInline(inner, "**")
+	case atom.Em, atom.I, atom.Cite:
+		return wrapInline(inner, "*")
+	case atom.Code:
+		return "`" + nodeText(n) + "`"
+	case atom.Q:
+		return "\"" + inner + "\""
+	case atom.A:
+		href := attr(n, "href")
+		if len(href) == 0 || len(strings.TrimSpace(inner)) == 0 {
+			return inner
+		}
+		return "[" + inner + "](" + markdownURL(href) + ")"
+	}
+
+	return inner
+}
+
+// wrapInline wraps the text with a delimiter, keeping the
+// surrounding spaces outside so that CommonMark reads it as emphasis
+func wrapInline(text string, delimiter string) string {
+	trimmed := strings.TrimSpace(text)
+	if len(trimmed) == 0 {
+		return text
+	}
+
+	start := text[:strings.Index(text, trimmed)]
+	end := text[len(start)+len(trimmed):]
+
+	return start + delimiter + trimmed + delimiter + end
+}
+
+func escapeLineStart(line string) string {
+	for i, r := range line {
+		if r == '.' || r == ')' {
+			return line[:i] + "\\" + line[i:]
+		}
+		if !unicode.IsDigit(r) {
+			return "\\" + line
+		}
+	}
+	return line
+}
+
+func markdownURL(u string) string {
+	return strings.NewReplacer(" ", "%20", "(", "%28", ")", "%29").Replace(u)
+}
+
+func prefixLines(text string, prefix string, emptyPrefix string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if len(line) == 0 {
+			lines[i] = emptyPrefix
+		} else {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// safeURL allows web and in-page links, and no "javascript:" etc.
+// a protocol-relative "//host" link may be of any host, it is refused
+func safeURL(u string, allowMail bool) bool {
+	u = strings.TrimSpace(strings.ToLower(u))
+
+	switch {
+	case strings.HasPrefix(u, "http://"), strings.HasPrefix(u, "https://"):
+		return true
+	case strings.HasPrefix(u, "//"), strings.HasPrefix(u, "/\\"):
+		return false
+	case strings.HasPrefix(u, "/"), strings.HasPrefix(u, "#"):
+		return true
+	case allowMail && strings.HasPrefix(u, "mailto:"):
+		return true
+	}
+
+	return false
+}
+
+func isArabicScript(text string) bool {
+	arabic, letters := 0, 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		if unicode.Is(unicode.Arabic, r) {
+			arabic++
+		}
+	}
+	return letters > 0 && arabic*2 > letters
+}
+
+// isBlank reports if nodes are whitespace only, without an image or a break
+func isBlank(nodes []*html.Node) bool {
+	for _, n := range nodes {
+		if n.Type == html.TextNode && len(strings.TrimSpace(n.Data)) > 0 {
+			return false
+		}
+		if n.Type == html.ElementNode && (n.DataAtom == atom.Img || !isBlank(children(n))) {
+			return false
+		}
+	}
+	return true
+}
+
+func hasBlock(nodes []*html.Node) bool {
+	for _, n := range nodes {
+		if n.Type == html.ElementNode && blockElements[n.DataAtom] {
+			return true
+		}
+	}
+	return false
+}
+
+func children(n *html.Node) []*html.Node {
+	nodes := []*html.Node{}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		nodes = append(nodes, c)
+	}
+	return nodes
+}
+
+func findAll(n *html.Node, a atom.Atom) []*html.Node {
+	nodes := []*html.Node{}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && c.DataAtom == a {
+			nodes = append(nodes, c)
+			continue
+		}
+		nodes = append(nodes, findAll(c, a)...)
+	}
+	return nodes
+}
+
+func nodeText(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	text := ""
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		text += nodeText(c)
+	}
+	return text
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func hasAttr(n *html.Node, key string) bool {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package content
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertSanitize(t *testing.T) {
+	tests := []struct {
+		name   string
+		answer string
+		want   string
+	}{
+		{
+			name:   "script and style",
+			answer: `<p>Praise be to Allah.<script>alert(1)</script></p><style>p { color: red }</style>`,
+			want:   `<div dir="ltr" lang="en"><p>Praise be to Allah.</p></div>`,
+		},
+		{
+			name:   "event handlers and styles",
+			answer: `<p onclick="alert(1)" style="color: red" class="x">Text <img src="/a.png" onerror="alert(1)" alt="a"></p>`,
+			want:   `<div dir="ltr" lang="en"><p>Text <img src="/a.png" alt="a"/></p></div>`,
+		},
+		{
+			name:   "javascript links",
+			answer: `<p><a href="javascript:alert(1)">click</a> <a href=" JavaScript:alert(1)">here</a></p>`,
+			want:   `<div dir="ltr" lang="en"><p>click here</p></div>`,
+		},
+		{
+			name:   "protocol-relative links",
+			answer: `<p><a href="//evil.example/a">a</a> <a href="/\evil.example/b">b</a> <img src="//evil.example/c.png"></p>`,
+			want:   `<div dir="ltr" lang="en"><p>a b </p></div>`,
+		},
+		{
+			name:   "allowed links",
+			answer: `<p><a href="https://islamqa.info/en/answers/1">1</a> <a href="/en/answers/2">2</a> <a href="#fn1">3</a> <a href="mailto:a@example.com">4</a></p>`,
+			want:   `<div dir="ltr" lang="en"><p><a href="https://islamqa.info/en/answers/1">1</a> <a href="/en/answers/2">2</a> <a href="#fn1">3</a> <a href="mailto:a@example.com">4</a></p></div>`,
+		},
+		{
+			name:   "iframes and forms",
+			answer: `<p>Text</p><iframe src="https://example.com"></iframe><form><input name="q"></form>`,
+			want:   `<div dir="ltr" lang="en"><p>Text</p></div>`,
+		},
+		{
+			name:   "unknown elements are unwrapped",
+			answer: `<div class="content"><font color="red">Text</font></div>`,
+			want:   `<div dir="ltr" lang="en"><p>Text</p></div>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Convert(tt.answer, "en").HTML)
+		})
+	}
+}
+
+func TestConvertMarkdown(t *testing.T) {
+	tests := []struct {
+		name   string
+		answer string
+		want   string
+		text   string
+	}{
+		{
+			name:   "inline",
+			answer: `<p><strong>Fasting</strong> is <em>obligatory</em>, see <a href="/en/answers/1">this (answer)</a>.</p>`,
+			want:   `**Fasting** is *obligatory*, see [this (answer)](/en/answers/1).`,
+			text:   `Fasting is obligatory, see this (answer).`,
+		},
+		{
+			name:   "blocks",
+			answer: `<h2>Title</h2><ul><li>One</li><li>Two</li></ul><ol start="3"><li>Three</li></ol><blockquote><p>Quote</p></blockquote>`,
+			want:   "## Title\n\n- One\n- Two\n\n3. Three\n\n> Quote",
+			text:   "Title\n\n- One\n- Two\n\n3. Three\n\nQuote",
+		},
+		{
+			name:   "markdown characters",
+			answer: `<p>2 * 3 = 6, a_b, [1] and <code>x</code></p>`,
+			want:   "2 \\* 3 = 6, a\\_b, \\[1\\] and `x`",
+			text:   "2 * 3 = 6, a_b, [1] and x",
+		},
+		{
+			name:   "thematic breaks and setext underlines",
+			answer: `<p>Line<br>---<br>***<br>___<br>- - -<br>--</p>`,
+			want:   "Line\\\n\\---\\\n\\*\\*\\*\\\n\\_\\_\\_\\\n\\- - -\\\n\\--",
+			text:   "Line\n---\n***\n___\n- - -\n--",
+		},
+		{
+			name:   "code fences",
+			answer: "<p>~~~<br>```</p>",
+			want:   "\\~~~\\\n\\`\\`\\`",
+			text:   "~~~\n```",
+		},
+		{
+			name:   "block markers",
+			answer: `<p># not a heading</p><p>- not a list</p><p>1. not a list</p><p>&gt; not a quote</p>`,
+			want:   "\\# not a heading\n\n\\- not a list\n\n1\\. not a list\n\n\\> not a quote",
+			text:   "# not a heading\n\n- not a list\n\n1. not a list\n\n> not a quote",
+		},
+		{
+			name:   "pre",
+			answer: "<pre>a\n```\nb</pre>",
+			want:   "````\na\n```\nb\n````",
+			text:   "a\n```\nb",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			converted := Convert(tt.answer, "en")
+			assert.Equal(t, tt.want, converted.Markdown)
+			assert.Equal(t, tt.text, converted.Text)
+		})
+	}
+}
+
+func TestConvertDirection(t *testing.T) {
+	converted := Convert(`<p>الحمد لله</p>`, "ar")
+	assert.Equal(t, DirectionRTL, converted.Direction)
+	assert.Equal(t, `<div dir="rtl" lang="ar"><p>الحمد لله</p></div>`, converted.HTML)
+
+	converted = Convert(`<p>Allah says:</p><p>كتب عليكم الصيام</p>`, "en")
+	assert.Equal(t, `<div dir="ltr" lang="en"><p>Allah says:</p><p dir="rtl">كتب عليكم الصيام</p></div>`, converted.HTML)
+}
@@ -0,0 +1,150 @@
+package content
+
+import "strings"
+
+const (
+	DiffEqual  = "equal"
+	DiffInsert = "insert"
+	DiffDelete = "delete"
+)
+
+// DiffOp is a run of words of a word diff
+type DiffOp struct {
+	Kind string
+	Text string
+}
+
+// WordDiff is the word-level diff from a to b, by the longest
+// common subsequence of their words
+// the runs of the same kind are joined, the words by single spaces
+func WordDiff(a string, b string) []DiffOp {
+	x := strings.Fields(a)
+	y := strings.Fields(b)
+
+	// the common prefix and suffix are left out of the table,
+	// revisions mostly differ by a few words
+	prefix := 0
+	for prefix < len(x) && prefix < len(y) && x[prefix] == y[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(x)-prefix && suffix < len(y)-prefix && x[len(x)-1-suffix] == y[len(y)-1-suffix] {
+		suffix++
+	}
+
+	ops := []DiffOp{}
+
+	add := func(kind string, word string) {
+		if n := len(ops); n > 0 && ops[n-1].Kind == kind {
+			ops[n-1].Text += " " + word
+			return
+		}
+		ops = append(ops, DiffOp{Kind: kind, Text: word})
+	}
+
+	for _, word := range x[:prefix] {
+		add(DiffEqual, word)
+	}
+
+	mx := x[prefix : len(x)-suffix]
+	my := y[prefix : len(y)-suffix]
+
+	// lcs[i][j] is the length of the lcs of mx[i:] and my[j:]
+	lcs := make([][]int32, len(mx)+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, len(my)+1)
+	}
+
+	for i := len(mx) - 1; i >= 0; i-- {
+		for j := len(my) - 1; j >= 0; j-- {
+			if mx[i] == my[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(mx) && j < len(my) {
+		switch {
+		case mx[i] == my[j]:
+			add(DiffEqual, mx[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			add(DiffDelete, mx[i])
+			i++
+		default:
+			add(DiffInsert, my[j])
+			j++
+		}
+	}
+
+	for ; i < len(mx); i++ {
+		add(DiffDelete, mx[i])
+	}
+
+	for ; j < len(my); j++ {
+		add(DiffInsert, my[j])
+	}
+
+	for _, word := range x[len(x)-suffix:] {
+		add(DiffEqual, word)
+	}
+
+	return ops
+}
+
+// FormatDiff renders a word diff, the deleted words as [-words-]
+// and the inserted words as {+words+}
+func FormatDiff(ops []DiffOp) string {
+	words := []string{}
+
+	for _, op := range ops {
+		switch op.Kind {
+		case DiffDelete:
+			words = append(words, "[-"+op.Text+"-]")
+		case DiffInsert:
+			words = append(words, "{+"+op.Text+"+}")
+		default:
+			words = append(words, op.Text)
+		}
+	}
+
+	return strings.Join(words, " ")
+}
+
+// DiffRevisions renders the word diffs of the fields of r changed
+// since previous, a field per line after its name, e.g. "title:\n..."
+func DiffRevisions(previous *Revision, r *Revision) string {
+	fields := []struct {
+		name     string
+		old, new *string
+	}{
+		{"title", previous.Title, r.Title},
+		{"question", previous.Question, r.Question},
+		{"summary", previous.Summary, r.Summary},
+		{"text", previous.Text, r.Text},
+	}
+
+	var b strings.Builder
+	for _, f := range fields {
+		if value(f.old) == value(f.new) {
+			continue
+		}
+		b.WriteString(f.name + ":\n" + FormatDiff(WordDiff(value(f.old), value(f.new))) + "\n")
+	}
+
+	return b.String()
+}
+
+func value(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,38 @@
+package content
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWordDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{name: "equal", a: "Fasting is obligatory", b: "Fasting  is\nobligatory", want: "Fasting is obligatory"},
+		{name: "changed word", a: "Fasting is obligatory", b: "Fasting is recommended", want: "Fasting is [-obligatory-] {+recommended+}"},
+		{name: "inserted words", a: "Praise be to Allah", b: "Praise be to Allah, the Lord", want: "Praise be to [-Allah-] {+Allah, the Lord+}"},
+		{name: "deleted words", a: "It is said that it is not", b: "It is not", want: "It is [-said that it is-] not"},
+		{name: "empty", a: "", b: "New answer", want: "{+New answer+}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, FormatDiff(WordDiff(tt.a, tt.b)))
+		})
+	}
+}
+
+func TestDiffRevisions(t *testing.T) {
+	title, question := "Title", "Question?"
+	oldText, newText := "The answer is yes", "The answer is no"
+
+	previous := &Revision{Title: &title, Question: &question, Text: &oldText}
+	r := &Revision{Title: &title, Text: &newText}
+
+	assert.Equal(t, "question:\n[-Question?-]\ntext:\nThe answer is [-yes-] {+no+}\n", DiffRevisions(previous, r))
+	assert.Empty(t, DiffRevisions(previous, previous))
+}
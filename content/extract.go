@@ -0,0 +1,218 @@
+package content
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/hamza72x/islamqa-scrapper/helper"
+	"github.com/hamza72x/islamqa-scrapper/sitemap"
+	"github.com/hamza72x/islamqa-scrapper/storage"
+	"github.com/hamza72x/islamqa-scrapper/structured"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const (
+	ExtractTitle    = "title"
+	ExtractQuestion = "question"
+	ExtractAnswer   = "answer"
+	ExtractSummary  = "summary"
+	ExtractBody     = "body"
+
+	// the metadata of the page, not part of the content hash
+	ExtractPublished = "published"
+	ExtractModified  = "modified"
+	ExtractViews     = "views"
+	ExtractAuthor    = "author"
+	ExtractPublisher = "publisher"
+
+	// ExtractStructured stores the structured data of the page,
+	// see StructuredExtractor
+	ExtractStructured = "structured"
+)
+
+const (
+	CrawlFull  = "full"
+	CrawlLight = "light"
+)
+
+// AnyKind registers an extractor for the kinds without their own
+const AnyKind = ""
+
+// Page is a fetched page of a sitemap url
+type Page struct {
+	URL  *sitemap.URL
+	Body string
+	Doc  *goquery.Document
+
+	structured *structured.Data
+}
+
+// Structured is the structured data of the page, parsed once
+func (p *Page) Structured() *structured.Data {
+	if p.structured == nil {
+		data := structured.Parse(p.Doc)
+		p.structured = &data
+	}
+	return p.structured
+}
+
+// StructuredExtractor sets the JSON-LD, Microdata and OpenGraph of c,
+// the same for every site
+func StructuredExtractor(p *Page, c *Content) error {
+	data := p.Structured()
+
+	c.JSONLD = storage.NewJSON(data.JSONLD)
+	c.Microdata = storage.NewJSON(data.Microdata)
+	c.OpenGraph = storage.NewJSON(data.OpenGraph)
+
+	return nil
+}
+
+// Extractor sets the fields of a content parsed from its page
+type Extractor func(p *Page, c *Content) error
+
+// Extractors are the extractors of a site by page kind, then by name
+type Extractors map[string]map[string]Extractor
+
+// Register adds the extractor name of a page kind, KindFatwa, KindArticle
+// or AnyKind, replacing the registered one
+func (extractors Extractors) Register(kind string, name string, e Extractor) {
+	if _, ok := extractors[kind]; !ok {
+		extractors[kind] = map[string]Extractor{}
+	}
+	extractors[kind][name] = e
+}
+
+// Get is the extractor name of kind, or of AnyKind
+func (extractors Extractors) Get(kind string, name string) (Extractor, bool) {
+	if e, ok := extractors[kind][name]; ok {
+		return e, true
+	}
+	e, ok := extractors[AnyKind][name]
+	return e, ok
+}
+
+// Config is a crawl, the extractors run on every page
+type Config struct {
+	Name       string
+	Extractors []string
+}
+
+var (
+	// Full parses and stores the whole page and its metadata
+	Full = Config{
+		Name: CrawlFull,
+		Extractors: []string{
+			ExtractStructured, ExtractTitle, ExtractQuestion, ExtractAnswer, ExtractSummary, ExtractBody,
+			ExtractPublished, ExtractModified, ExtractViews, ExtractAuthor, ExtractPublisher,
+		},
+	}
+
+	// Light parses the title and the question, or the seo
+	// description of an article
+	Light = Config{
+		Name:       CrawlLight,
+		Extractors: []string{ExtractTitle, ExtractQuestion},
+	}
+
+	// Configs are the crawls by name
+	Configs = map[string]Config{
+		CrawlFull:  Full,
+		CrawlLight: Light,
+	}
+)
+
+// Has is whether the config runs the extractor name
+func (cfg Config) Has(name string) bool {
+	for _, e := range cfg.Extractors {
+		if e == name {
+			return true
+		}
+	}
+	return false
+}
+
+// CoveredBy are the names of the configs running every extractor of cfg,
+// a content crawled by any of them isn't fetched again by cfg until it changes
+func (cfg Config) CoveredBy() []string {
+	names := []string{cfg.Name}
+
+	for name, other := range Configs {
+		if name == cfg.Name {
+			continue
+		}
+		covers := true
+		for _, e := range cfg.Extractors {
+			if !other.Has(e) {
+				covers = false
+				break
+			}
+		}
+		if covers {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// Extract runs the extractors of the config on the page, of the kind
+// of c, the fields of the other extractors are kept as they are
+func (cfg Config) Extract(extractors Extractors, p *Page, c *Content) error {
+	for _, name := range cfg.Extractors {
+		e, ok := extractors.Get(c.Kind, name)
+		if !ok {
+			return errors.New("unknown extractor: " + name)
+		}
+		if err := e(p, c); err != nil {
+			return err
+		}
+	}
+
+	c.Normalize()
+
+	return nil
+}
+
+// ErrGone is returned by Fetch for the pages not found or gone,
+// their contents are deleted by the sync
+var ErrGone = errors.New("page is gone")
+
+// Fetch gets and parses the page of url
+func Fetch(url *sitemap.URL) (*Page, error) {
+	resp, err := helper.GetURLResponse(url.Loc, "")
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone {
+		return nil, fmt.Errorf("%w: %s %s", ErrGone, url.Loc, resp.Status)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("status code is not ok, but: " + resp.Status)
+	}
+
+	htmlBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParsePage(url, string(htmlBytes))
+}
+
+// ParsePage is the page of url with the html body
+func ParsePage(url *sitemap.URL, body string) (*Page, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Page{URL: url, Body: body, Doc: doc}, nil
+}
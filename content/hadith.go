@@ -0,0 +1,249 @@
+package content
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/hamza72x/islamqa-scrapper/normalize"
+
+	"gorm.io/gorm"
+)
+
+// HadithCitation is a reference to a hadith collection found in a content
+// e.g. "Narrated by al-Bukhari (1234)" or "رواه مسلم (567)"
+type HadithCitation struct {
+	ID        uint `gorm:"primarykey;column:id"`
+	ContentID uint `gorm:"column:content_id;index"`
+
+	// Collection is the key of the collection, e.g. "bukhari"
+	Collection string `gorm:"column:collection;index:idx_hadith_citations_collection_number"`
+	Number     int    `gorm:"column:number;index:idx_hadith_citations_collection_number"`
+
+	// Grading is the grading text following the citation, if any
+	// e.g. "classed as saheeh by al-Albaani"
+	Grading string `gorm:"column:grading"`
+
+	// Raw is the matched text, as it was found
+	Raw string `gorm:"column:raw"`
+}
+
+func (HadithCitation) TableName() string {
+	return "hadith_citations"
+}
+
+// hadithCollection is a known hadith book and the ways it's written
+type hadithCollection struct {
+	Key   string
+	Names []string
+
+	// Ambiguous names are words too, e.g. "a Muslim", "Imam Ahmad", they
+	// are cited after "narrated by" or with a bracketed or "no." number
+	Ambiguous bool
+}
+
+var hadithCollections = []hadithCollection{
+	{"bukhari", []string{`bukh[aā]a?r[iīe]e?`, `البخاري`}, false},
+	{"muslim", []string{`muslim`, `مسلم`}, true},
+	{"abu-dawud", []string{`ab[uiī] d[aā]a?w[uūo]o?d`, `أبو داود`, `أبي داود`, `ابو داود`, `ابي داود`}, false},
+	{"tirmidhi", []string{`tirmidh[iīe]e?`, `الترمذي`}, false},
+	{"nasai", []string{`nas[aā]a?[’'‘ʼ]?[iīe]e?`, `النسائي`}, false},
+	{"ibn-majah", []string{`ibn m[aā]a?jah?`, `ابن ماجه`, `ابن ماجة`}, false},
+	{"ahmad", []string{`ahmad`, `أحمد`, `احمد`}, true},
+	{"malik", []string{`m[aā]a?lik`, `muwatt?a[’'‘]?`, `مالك`, `الموطأ`}, true},
+	{"darimi", []string{`d[aā]a?rim[iīe]e?`, `الدارمي`}, false},
+	{"ibn-hibban", []string{`ibn hibb[aā]a?n`, `ابن حبان`}, false},
+	{"ibn-khuzaymah", []string{`ibn khuza[iy]mah?`, `ابن خزيمة`}, false},
+	{"hakim", []string{`h[aā]a?kim`, `الحاكم`}, true},
+	{"bayhaqi", []string{`bayh[aā]q[iīe]e?`, `البيهقي`}, false},
+	{"tabarani", []string{`tabar[aā]a?n[iīe]e?`, `الطبراني`}, false},
+	{"daraqutni", []string{`d[aā]a?raqutn[iīe]e?`, `الدارقطني`}, false},
+}
+
+var (
+	hadithCollectionRegexes = map[string]*regexp.Regexp{}
+
+	// ... (1234), no. 1234, رقم 1234
+	// but not volume/page as in "(2/123)"
+	// the groups are the opening bracket, the "no." and the number
+	hadithNumberRegex = regexp.MustCompile(`^[\s,:،]*([\(\[])?\s*((?i:no\.?|number|hadith|#)|رقم|برقم)?\s*(\d{1,5})(\s*[\)\]]|[\s,.;،؛]|$)`)
+
+	// "narrated by", "رواه" and the like, in the sentence before a citation
+	hadithContextRegex = regexp.MustCompile(`(?i)(?:narrated|reported|recorded|transmitted|related|compiled|collected)\s+by|(?:رواه|أخرجه|اخرجه|خرجه|روى)`)
+
+	hadithGradingRegex = regexp.MustCompile(`(?i)(?:(?:classed|classified|graded|declared|deemed|ruled)\s+(?:as\s+|it\s+as\s+|it\s+)?(?:saheeh|sahih|hasan|da[‘'’]?eef|da[‘'’]?if|weak|sound|good)|(?:و)?(?:صححه|حسنه|ضعفه))[^.\n;؛]*`)
+)
+
+func init() {
+	for _, c := range hadithCollections {
+		// arabic "and" is attached to the name, as in "ومسلم"
+		hadithCollectionRegexes[c.Key] = regexp.MustCompile(`(?i)و?(?:` + strings.Join(c.Names, "|") + `)`)
+	}
+}
+
+// HadithCollection resolves a collection name, e.g. "al-Bukhaari"
+// or "البخاري" to its key, e.g. "bukhari"
+func HadithCollection(name string) (string, bool) {
+	name = strings.TrimSpace(name)
+
+	for _, c := range hadithCollections {
+		if c.Key == strings.ToLower(name) {
+			return c.Key, true
+		}
+	}
+
+	for _, c := range hadithCollections {
+		loc := hadithCollectionRegexes[c.Key].FindStringIndex(name)
+		if loc != nil && isWordBoundary(name, loc[0], loc[1]) {
+			return c.Key, true
+		}
+	}
+
+	return "", false
+}
+
+// ExtractHadithCitations finds the "collection (number)" references in text
+// the grading is the grading phrase following a citation in the same sentence
+func ExtractHadithCitations(text string) []HadithCitation {
+	text = normalize.Digits(text)
+
+	type match struct {
+		start, end int
+		citation   HadithCitation
+	}
+
+	matches := []match{}
+
+	for _, c := range hadithCollections {
+		for _, loc := range hadithCollectionRegexes[c.Key].FindAllStringIndex(text, -1) {
+			if !isWordBoundary(text, loc[0], loc[1]) {
+				continue
+			}
+
+			m := hadithNumberRegex.FindStringSubmatchIndex(text[loc[1]:])
+			if m == nil {
+				continue
+			}
+
+			number, err := strconv.Atoi(text[loc[1]+m[6] : loc[1]+m[7]])
+			if err != nil || number == 0 {
+				continue
+			}
+
+			bracketed := m[2] >= 0 && strings.ContainsAny(text[loc[1]+m[8]:loc[1]+m[9]], ")]")
+			if c.Ambiguous && !bracketed && m[4] < 0 && !hadithContextRegex.MatchString(sentence(text, loc[0])) {
+				continue
+			}
+
+			end := loc[1] + m[1]
+
+			matches = append(matches, match{
+				start: loc[0],
+				end:   end,
+				citation: HadithCitation{
+					Collection: c.Key,
+					Number:     number,
+					Raw:        strings.TrimRight(text[loc[0]:end], " \t\n,.;،؛"),
+				},
+			})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].start < matches[j].start
+	})
+
+	citations := []HadithCitation{}
+	seen := map[string]int{}
+
+	for i, m := range matches {
+		// grading is searched until the next citation
+		window := text[m.end:]
+		if i+1 < len(matches) && matches[i+1].start > m.end {
+			window = text[m.end:matches[i+1].start]
+		}
+		if end := strings.IndexAny(window, ".\n؛"); end >= 0 {
+			window = window[:end]
+		}
+
+		m.citation.Grading = strings.TrimSpace(hadithGradingRegex.FindString(window))
+
+		key := m.citation.Collection + "/" + strconv.Itoa(m.citation.Number)
+		if j, ok := seen[key]; ok {
+			if len(citations[j].Grading) == 0 {
+				citations[j].Grading = m.citation.Grading
+			}
+			continue
+		}
+		seen[key] = len(citations)
+
+		citations = append(citations, m.citation)
+	}
+
+	return citations
+}
+
+// FindByHadith returns the contents citing the given hadith
+func FindByHadith(db *gorm.DB, collection string, number int) ([]*Content, error) {
+	contents := []*Content{}
+
+	if err := db.
+		Model(&Content{}).
+		Where("id IN (?)", db.
+			Model(&HadithCitation{}).
+			Select("content_id").
+			Where("collection = ? AND number = ?", collection, number),
+		).
+		Order("id").
+		Find(&contents).
+		Error; err != nil {
+		return nil, err
+	}
+
+	return contents, nil
+}
+
+// sentence is the text from the start of the sentence to end,
+// the period of "No." or "no." before a number doesn't end a sentence
+func sentence(text string, end int) string {
+	start := end
+	for {
+		start = strings.LastIndexAny(text[:start], ".!?\n؛")
+		if !isNumberAbbreviation(text, start) {
+			return text[start+1 : end]
+		}
+	}
+}
+
+// isNumberAbbreviation reports if the period at i is of a "No."
+// or "no." word followed by a digit
+func isNumberAbbreviation(text string, i int) bool {
+	if i < 2 || text[i] != '.' || (text[i-2:i] != "No" && text[i-2:i] != "no") || !isWordBoundary(text, i-2, i) {
+		return false
+	}
+
+	r, _ := utf8.DecodeRuneInString(strings.TrimLeft(text[i+1:], " "))
+	return unicode.IsDigit(r)
+}
+
+// isWordBoundary reports if s[start:end] is not a part of a longer word
+func isWordBoundary(s string, start int, end int) bool {
+	if start > 0 {
+		r, _ := utf8.DecodeLastRuneInString(s[:start])
+		if unicode.IsLetter(r) {
+			return false
+		}
+	}
+
+	if end < len(s) {
+		r, _ := utf8.DecodeRuneInString(s[end:])
+		if unicode.IsLetter(r) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,114 @@
+package content
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractHadithCitations(t *testing.T) {
+	type citation struct {
+		collection string
+		number     int
+	}
+
+	tests := []struct {
+		name string
+		text string
+		want []citation
+	}{
+		{
+			name: "bracketed",
+			text: "Narrated by al-Bukhaari (1234) and Muslim (567).",
+			want: []citation{{"bukhari", 1234}, {"muslim", 567}},
+		},
+		{
+			name: "narrated by",
+			text: "It was narrated by Ahmad 1995 and others.",
+			want: []citation{{"ahmad", 1995}},
+		},
+		{
+			name: "number",
+			text: "See Muslim no. 20 and Maalik, hadith 12.",
+			want: []citation{{"muslim", 20}, {"malik", 12}},
+		},
+		{
+			name: "narrated by after no.",
+			text: "Narrated by al-Bukhaari, no. 12, and Muslim 34.",
+			want: []citation{{"bukhari", 12}, {"muslim", 34}},
+		},
+		{
+			name: "arabic",
+			text: "رواه البخاري ومسلم ٥٦٧",
+			want: []citation{{"muslim", 567}},
+		},
+		{
+			name: "arabic number",
+			text: "صحيح مسلم رقم 99",
+			want: []citation{{"muslim", 99}},
+		},
+		{
+			name: "unambiguous without context",
+			text: "al-Tirmidhi 2516",
+			want: []citation{{"tirmidhi", 2516}},
+		},
+		{
+			name: "a muslim, times a day",
+			text: "Every Muslim, 5 times a day, has to pray.",
+		},
+		{
+			name: "a muslim, years old",
+			text: "He is a Muslim 20 years old.",
+		},
+		{
+			name: "imam ahmad, a year",
+			text: "Imam Ahmad 1995 said it.",
+		},
+		{
+			name: "unclosed bracket",
+			text: "a Muslim (20 years old) asked",
+		},
+		{
+			name: "context of another sentence",
+			text: "Narrated by al-Bukhaari (1). Every Muslim 5 times a day.",
+			want: []citation{{"bukhari", 1}},
+		},
+		{
+			name: "no of another word",
+			text: "It was narrated by Bruno. Every Muslim 5 times a day.",
+		},
+		{
+			name: "no without a number",
+			text: "Narrated by al-Bukhaari, he said no. Every Muslim 5 times a day.",
+		},
+		{
+			name: "arabic without context",
+			text: "كل مسلم 5 مرات",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []citation{}
+			for _, c := range ExtractHadithCitations(tt.text) {
+				got = append(got, citation{c.Collection, c.Number})
+			}
+
+			want := tt.want
+			if want == nil {
+				want = []citation{}
+			}
+
+			assert.Equal(t, want, got)
+		})
+	}
+}
+
+func TestExtractHadithCitationsGrading(t *testing.T) {
+	citations := ExtractHadithCitations("Narrated by Abu Dawood (4607) and classed as saheeh by al-Albaani.")
+
+	if assert.Len(t, citations, 1) {
+		assert.Equal(t, "abu-dawud", citations[0].Collection)
+		assert.Equal(t, "classed as saheeh by al-Albaani", citations[0].Grading)
+	}
+}
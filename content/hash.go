@@ -0,0 +1,37 @@
+package content
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// ContentHash is the SHA-256 of the whitespace-trimmed title, question,
+// summary and answer text, the page chrome (Body) is left out, an equal
+// hash is the same content
+// the text isn't normalised, a changed diacritic or letter is a change
+func (c *Content) ContentHash() string {
+	return hashFields(c.Title, c.Question, c.Summary, c.Text)
+}
+
+// ContentHash is the ContentHash of the content of the revision
+func (r *Revision) ContentHash() string {
+	return hashFields(r.Title, r.Question, r.Summary, r.Text)
+}
+
+func hashFields(fields ...*string) string {
+	h := sha256.New()
+
+	for _, field := range fields {
+		// a missing field differs from an empty one
+		if field == nil {
+			h.Write([]byte{0})
+			continue
+		}
+		h.Write([]byte{1})
+		h.Write([]byte(strings.TrimSpace(*field)))
+		h.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
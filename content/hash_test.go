@@ -0,0 +1,50 @@
+package content
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContentHash(t *testing.T) {
+	s := func(s string) *string { return &s }
+
+	base := &Content{Title: s("Fasting"), Text: s("Praise be to Allah.")}
+
+	tests := []struct {
+		name    string
+		content *Content
+		changed bool
+	}{
+		{
+			name:    "surrounding whitespace",
+			content: &Content{Title: s(" Fasting\n"), Text: s("\tPraise be to Allah. ")},
+		},
+		{
+			name:    "case",
+			content: &Content{Title: s("fasting"), Text: s("Praise be to Allah.")},
+			changed: true,
+		},
+		{
+			name:    "punctuation",
+			content: &Content{Title: s("Fasting"), Text: s("Praise be to Allah")},
+			changed: true,
+		},
+		{
+			name:    "diacritics",
+			content: &Content{Title: s("Fasting"), Text: s("Praise be to Allāh.")},
+			changed: true,
+		},
+		{
+			name:    "empty summary",
+			content: &Content{Title: s("Fasting"), Summary: s(""), Text: s("Praise be to Allah.")},
+			changed: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.changed, tt.content.ContentHash() != base.ContentHash())
+		})
+	}
+}
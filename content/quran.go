@@ -0,0 +1,151 @@
+package content
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/hamza72x/islamqa-scrapper/normalize"
+)
+
+// QuranCitation is a surah:ayah reference found in a content
+// e.g. "[al-Baqarah 2:183]" or "[البقرة/183-185]"
+type QuranCitation struct {
+	ID        uint `gorm:"primarykey;column:id"`
+	ContentID uint `gorm:"column:content_id;index"`
+
+	Surah    int `gorm:"column:surah;index"`
+	AyahFrom int `gorm:"column:ayah_from"`
+	AyahTo   int `gorm:"column:ayah_to"`
+
+	// Raw is the matched text, as it was found
+	Raw string `gorm:"column:raw"`
+}
+
+func (QuranCitation) TableName() string {
+	return "quran_citations"
+}
+
+var (
+	// [name] [surah] (:|/) ayah [- ayah], wrapped in [], () or ﴿﴾
+	quranCitationRegex = regexp.MustCompile(`([\[\(﴿])\s*([^\[\]\(\)﴾﴿\d:/]{0,40}?)\s*(\d{1,3})?\s*[:/：]\s*(\d{1,3})(?:\s*[-–—]\s*(\d{1,3}))?\s*[\]\)﴾]`)
+
+	surahArticleRegex = regexp.MustCompile(`^(al|an|ash|at|ad|adh|ar|az|as|ath|el|ul)[-\s]+`)
+	surahPrefixRegex  = regexp.MustCompile(`^(surah|surat|sura|soorah|soorat|soora|سوره)\s+`)
+
+	surahLatinReplacer = strings.NewReplacer("oo", "u", "ee", "i")
+
+	surahsByKey = map[string]*surah{}
+)
+
+func init() {
+	for i := range surahs {
+		s := &surahs[i]
+		for _, name := range append([]string{s.Arabic, s.Name}, s.Aliases...) {
+			surahsByKey[surahKey(name)] = s
+		}
+	}
+}
+
+// ExtractQuranCitations finds the surah:ayah references in text
+// and normalises them to (surah, ayah range), duplicates are dropped
+func ExtractQuranCitations(text string) []QuranCitation {
+	text = normalize.Digits(text)
+
+	citations := []QuranCitation{}
+	seen := map[[3]int]bool{}
+
+	for _, m := range quranCitationRegex.FindAllStringSubmatch(text, -1) {
+		s := resolveSurah(m[2], m[3])
+		if s == nil {
+			continue
+		}
+
+		// "(at 10:30)" is rather a time than a citation,
+		// parentheses need a known surah name
+		if m[1] == "(" && surahsByKey[surahKey(m[2])] == nil {
+			continue
+		}
+
+		from, _ := strconv.Atoi(m[4])
+		to := from
+		if len(m[5]) > 0 {
+			to, _ = strconv.Atoi(m[5])
+		}
+
+		if from < 1 || to < from || to > s.Ayahs {
+			continue
+		}
+
+		key := [3]int{s.Number, from, to}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		citations = append(citations, QuranCitation{
+			Surah:    s.Number,
+			AyahFrom: from,
+			AyahTo:   to,
+			Raw:      strings.TrimSpace(m[0]),
+		})
+	}
+
+	return citations
+}
+
+// resolveSurah prefers the surah number, and falls back to the name
+// a known name contradicting the number is rejected
+func resolveSurah(name string, number string) *surah {
+	byName := surahsByKey[surahKey(name)]
+
+	if len(number) == 0 {
+		return byName
+	}
+
+	n, err := strconv.Atoi(number)
+	if err != nil || n < 1 || n > len(surahs) {
+		return nil
+	}
+
+	if byName != nil && byName.Number != n {
+		return nil
+	}
+
+	return &surahs[n-1]
+}
+
+// surahKey reduces the spelling variants of a surah name
+// e.g. "al-Baqarah", "Al Baqara" and "البقرة" have a single key each
+func surahKey(name string) string {
+	name = normalize.Text(name)
+	name = strings.Trim(name, ",،-–— ")
+	name = surahPrefixRegex.ReplaceAllString(name, "")
+	name = strings.Map(func(r rune) rune {
+		switch r {
+		case '\'', '’', '‘', 'ʿ', 'ʾ', '`', '´':
+			return -1
+		}
+		return r
+	}, name)
+	name = surahArticleRegex.ReplaceAllString(name, "")
+	name = surahLatinReplacer.Replace(name)
+
+	var b strings.Builder
+	var last rune
+	for _, r := range name {
+		if !unicode.IsLetter(r) || r == last {
+			continue
+		}
+		b.WriteRune(r)
+		last = r
+	}
+
+	key := b.String()
+	if len(key) > 3 {
+		key = strings.TrimSuffix(key, "h")
+	}
+
+	return key
+}
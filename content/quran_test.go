@@ -0,0 +1,93 @@
+package content
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractQuranCitations(t *testing.T) {
+	type citation struct {
+		surah, from, to int
+	}
+
+	tests := []struct {
+		name string
+		text string
+		want []citation
+	}{
+		{
+			name: "name and number",
+			text: "Allah says (interpretation of the meaning): [al-Baqarah 2:183]",
+			want: []citation{{2, 183, 183}},
+		},
+		{
+			name: "range",
+			text: "[al-Baqarah 2:183-185] and [Aal ‘Imraan 3:102–103]",
+			want: []citation{{2, 183, 185}, {3, 102, 103}},
+		},
+		{
+			name: "name only",
+			text: "[al-Baqarah:255] [Soorat al-Ikhlaas:1-4]",
+			want: []citation{{2, 255, 255}, {112, 1, 4}},
+		},
+		{
+			name: "number only",
+			text: "as in [2:255]",
+			want: []citation{{2, 255, 255}},
+		},
+		{
+			name: "arabic name",
+			text: "﴿يا أيها الذين آمنوا كتب عليكم الصيام﴾ [البقرة/183-185]",
+			want: []citation{{2, 183, 185}},
+		},
+		{
+			name: "arabic-indic digits",
+			text: "[البقرة: ١٨٣] [يونس ١٠:٣٠]",
+			want: []citation{{2, 183, 183}, {10, 30, 30}},
+		},
+		{
+			name: "parentheses with a surah name",
+			text: "(al-Baqarah 2:186)",
+			want: []citation{{2, 186, 186}},
+		},
+		{
+			name: "duplicates",
+			text: "[2:255] and again [al-Baqarah 2:255]",
+			want: []citation{{2, 255, 255}},
+		},
+		{
+			name: "time in parentheses",
+			text: "The lesson starts (at 10:30) after Fajr.",
+		},
+		{
+			name: "plain time",
+			text: "The ayah was recited at 2:255 in the night.",
+		},
+		{
+			name: "name contradicting the number",
+			text: "[al-Baqarah 3:10]",
+		},
+		{
+			name: "ayah out of the surah",
+			text: "[al-Ikhlaas 112:5] [115:1]",
+		},
+		{
+			name: "reversed range",
+			text: "[al-Baqarah 2:185-183]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []citation{}
+			for _, c := range ExtractQuranCitations(tt.text) {
+				got = append(got, citation{c.Surah, c.AyahFrom, c.AyahTo})
+			}
+			if tt.want == nil {
+				tt.want = []citation{}
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package content
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Reference is a footnote or an entry of the reference section
+// at the end of an answer, in the order they appear
+type Reference struct {
+	ID        uint `gorm:"primarykey;column:id"`
+	ContentID uint `gorm:"column:content_id;index"`
+
+	// Position is the 1 based order in the reference list
+	Position int `gorm:"column:position"`
+
+	// Marker is the in-text marker, e.g. "1" for "[1]"
+	// empty for reference section entries without one
+	Marker string `gorm:"column:marker"`
+
+	// MarkerAnchor is the html id of the in-text marker, if any
+	MarkerAnchor string `gorm:"column:marker_anchor"`
+
+	// Anchor is the html id of the reference, if any
+	Anchor string `gorm:"column:anchor"`
+
+	Text string `gorm:"column:text"`
+}
+
+func (Reference) TableName() string {
+	return "content_references"
+}
+
+// "[1] ...", "(1) ...", "1- ..." at the beginning of a footnote
+var referenceNumberRegex = regexp.MustCompile(`^\s*[\[\(]?\s*(\d{1,3})\s*[\]\)]?\s*[-.:)]?\s*`)
+
+// ParseReferences parses the footnotes and the reference section
+// of an answer body, the whole html page is expected, the answer
+// is the element of the answer selector, the body without one
+// heading matches the heading of the reference section, e.g.
+// "References", without it only the trailing numbered paragraphs
+// are a reference section
+func ParseReferences(body string, answerSelector string, heading *regexp.Regexp) []Reference {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(body))
+	if err != nil {
+		return []Reference{}
+	}
+
+	answer := doc.Find("body")
+	if len(answerSelector) > 0 {
+		if s := doc.Find(answerSelector); s.Length() > 0 {
+			answer = s
+		}
+	}
+
+	if refs := parseAnchoredReferences(doc, answer); len(refs) > 0 {
+		return refs
+	}
+
+	return parseReferenceSection(answer, heading)
+}
+
+// parseAnchoredReferences follows in-text links to in-page targets
+/*
+	<p>... as it is narrated<sup><a id="fnref1" href="#fn1">[1]</a></sup></p>
+	...
+	<p id="fn1"><a href="#fnref1">[1]</a> Narrated by al-Bukhari (1234)</p>
+*/
+func parseAnchoredReferences(doc *goquery.Document, answer *goquery.Selection) []Reference {
+	refs := []Reference{}
+	seen := map[string]bool{}
+	markers := map[string]bool{}
+
+	answer.Find("a[href^='#']").Each(func(_ int, a *goquery.Selection) {
+		href, _ := a.Attr("href")
+		anchor := strings.TrimPrefix(href, "#")
+		// seen, or a back link from the reference to its marker
+		if len(anchor) == 0 || seen[anchor] || markers[anchor] {
+			return
+		}
+
+		target := doc.Find("[id='" + strings.ReplaceAll(anchor, "'", "\\'") + "']").First()
+		if target.Length() == 0 {
+			return
+		}
+
+		// "<a id="fn1"></a> text" has the text in the parent
+		text := refTextOf(target)
+		if len(text) == 0 {
+			text = refTextOf(target.Parent())
+		}
+		text = referenceNumberRegex.ReplaceAllString(text, "")
+		if len(text) == 0 {
+			return
+		}
+
+		seen[anchor] = true
+
+		markerAnchor, _ := a.Attr("id")
+		if len(markerAnchor) == 0 {
+			markerAnchor, _ = a.Parent().Attr("id")
+		}
+
+		markers[markerAnchor] = true
+
+		refs = append(refs, Reference{
+			Position:     len(refs) + 1,
+			Marker:       strings.Trim(refTextOf(a), "[]() "),
+			MarkerAnchor: markerAnchor,
+			Anchor:       anchor,
+			Text:         text,
+		})
+	})
+
+	return refs
+}
+
+// parseReferenceSection reads the blocks after a heading,
+// or else the trailing numbered paragraphs whose markers appear in the text
+func parseReferenceSection(answer *goquery.Selection, heading *regexp.Regexp) []Reference {
+	blocks := []*goquery.Selection{}
+	answer.Find("p, li, h2, h3, h4, h5, h6, div:not(:has(p, li, div))").Each(func(_ int, s *goquery.Selection) {
+		blocks = append(blocks, s)
+	})
+
+	start := -1
+	for i, b := range blocks {
+		if heading != nil && heading.MatchString(refTextOf(b)) {
+			start = i + 1
+		}
+	}
+
+	if start < 0 {
+		// trailing numbered paragraphs are footnotes
+		for i := len(blocks) - 1; i >= 0; i-- {
+			text := refTextOf(blocks[i])
+			if !referenceNumberRegex.MatchString(text) ||
+				!(strings.HasPrefix(text, "[") || strings.HasPrefix(text, "(")) {
+				break
+			}
+			start = i
+		}
+	}
+
+	if start < 0 {
+		return []Reference{}
+	}
+
+	before := ""
+	for _, b := range blocks[:start] {
+		before += refTextOf(b) + "\n"
+	}
+
+	refs := []Reference{}
+	for _, b := range blocks[start:] {
+		text := refTextOf(b)
+		if len(text) == 0 {
+			continue
+		}
+
+		ref := Reference{
+			Position: len(refs) + 1,
+			Text:     text,
+		}
+
+		if m := referenceNumberRegex.FindStringSubmatch(text); m != nil {
+			n, _ := strconv.Atoi(m[1])
+			marker := strconv.Itoa(n)
+			if strings.Contains(before, "["+marker+"]") || strings.Contains(before, "("+marker+")") {
+				ref.Marker = marker
+				ref.Text = strings.TrimSpace(text[len(m[0]):])
+			}
+		}
+
+		ref.Anchor, _ = b.Attr("id")
+
+		refs = append(refs, ref)
+	}
+
+	return refs
+}
+
+func refTextOf(s *goquery.Selection) string {
+	return strings.Join(strings.Fields(s.Text()), " ")
+}
@@ -0,0 +1,57 @@
+package content
+
+import "time"
+
+const RevisionSourceContents = "contents"
+
+// Revision is a version of a content, a revision is stored
+// whenever a sync changes the content
+type Revision struct {
+	ID uint `gorm:"primarykey;column:id"`
+
+	// Source is the table of the content, RevisionSourceContents
+	Source    string `gorm:"column:source;index:idx_content_revisions_content"`
+	ContentID uint   `gorm:"column:content_id;index:idx_content_revisions_content"`
+
+	QuestionID uint   `gorm:"column:question_id;index"`
+	Language   string `gorm:"column:language"`
+	URL        string `gorm:"column:url"`
+
+	// Question is the question or seo description, Text is the answer text
+	Title    *string `gorm:"column:title"`
+	Question *string `gorm:"column:question"`
+	Summary  *string `gorm:"column:summary"`
+	Text     *string `gorm:"column:text"`
+
+	// Hash is the ContentHash of the content, equal for equal revisions
+	Hash string `gorm:"column:hash"`
+
+	FetchedAt time.Time `gorm:"column:fetched_at"`
+
+	// LastModified is the sitemap lastmod of the fetched content
+	LastModified time.Time `gorm:"column:last_modified"`
+}
+
+func (Revision) TableName() string {
+	return "content_revisions"
+}
+
+// NewRevision is the revision of a content fetched at fetchedAt
+func NewRevision(c *Content, fetchedAt time.Time) *Revision {
+	r := &Revision{
+		Source:       RevisionSourceContents,
+		ContentID:    c.ID,
+		QuestionID:   c.QuestionID,
+		Language:     c.Language,
+		URL:          c.URL,
+		Title:        c.Title,
+		Question:     c.Question,
+		Summary:      c.Summary,
+		Text:         c.Text,
+		Hash:         c.ContentHash(),
+		FetchedAt:    fetchedAt,
+		LastModified: c.LastModified,
+	}
+
+	return r
+}
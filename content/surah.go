@@ -0,0 +1,129 @@
+package content
+
+// surah is a chapter of the Quran
+type surah struct {
+	Number int
+	Arabic string
+	Name   string
+	Ayahs  int
+
+	// Aliases are other common spellings of the name
+	Aliases []string
+}
+
+var surahs = []surah{
+	{1, "الفاتحة", "Al-Fatihah", 7, []string{"Fatiha", "Opening"}},
+	{2, "البقرة", "Al-Baqarah", 286, nil},
+	{3, "آل عمران", "Aal Imran", 200, []string{"Imran", "Ali Imran"}},
+	{4, "النساء", "An-Nisa", 176, nil},
+	{5, "المائدة", "Al-Ma'idah", 120, []string{"Maidah"}},
+	{6, "الأنعام", "Al-An'am", 165, nil},
+	{7, "الأعراف", "Al-A'raf", 206, nil},
+	{8, "الأنفال", "Al-Anfal", 75, nil},
+	{9, "التوبة", "At-Tawbah", 129, []string{"Taubah", "Bara'ah"}},
+	{10, "يونس", "Yunus", 109, nil},
+	{11, "هود", "Hud", 123, nil},
+	{12, "يوسف", "Yusuf", 111, nil},
+	{13, "الرعد", "Ar-Ra'd", 43, nil},
+	{14, "إبراهيم", "Ibrahim", 52, nil},
+	{15, "الحجر", "Al-Hijr", 99, nil},
+	{16, "النحل", "An-Nahl", 128, nil},
+	{17, "الإسراء", "Al-Isra", 111, []string{"Bani Isra'il"}},
+	{18, "الكهف", "Al-Kahf", 110, nil},
+	{19, "مريم", "Maryam", 98, nil},
+	{20, "طه", "Ta-Ha", 135, nil},
+	{21, "الأنبياء", "Al-Anbiya", 112, nil},
+	{22, "الحج", "Al-Hajj", 78, nil},
+	{23, "المؤمنون", "Al-Mu'minun", 118, nil},
+	{24, "النور", "An-Nur", 64, nil},
+	{25, "الفرقان", "Al-Furqan", 77, nil},
+	{26, "الشعراء", "Ash-Shu'ara", 227, nil},
+	{27, "النمل", "An-Naml", 93, nil},
+	{28, "القصص", "Al-Qasas", 88, nil},
+	{29, "العنكبوت", "Al-Ankabut", 69, nil},
+	{30, "الروم", "Ar-Rum", 60, nil},
+	{31, "لقمان", "Luqman", 34, nil},
+	{32, "السجدة", "As-Sajdah", 30, nil},
+	{33, "الأحزاب", "Al-Ahzab", 73, nil},
+	{34, "سبأ", "Saba", 54, nil},
+	{35, "فاطر", "Fatir", 45, []string{"Al-Mala'ikah"}},
+	{36, "يس", "Ya-Sin", 83, nil},
+	{37, "الصافات", "As-Saffat", 182, nil},
+	{38, "ص", "Sad", 88, nil},
+	{39, "الزمر", "Az-Zumar", 75, nil},
+	{40, "غافر", "Ghafir", 85, []string{"Al-Mu'min"}},
+	{41, "فصلت", "Fussilat", 54, []string{"Ha-Mim As-Sajdah"}},
+	{42, "الشورى", "Ash-Shura", 53, nil},
+	{43, "الزخرف", "Az-Zukhruf", 89, nil},
+	{44, "الدخان", "Ad-Dukhan", 59, nil},
+	{45, "الجاثية", "Al-Jathiyah", 37, nil},
+	{46, "الأحقاف", "Al-Ahqaf", 35, nil},
+	{47, "محمد", "Muhammad", 38, nil},
+	{48, "الفتح", "Al-Fath", 29, nil},
+	{49, "الحجرات", "Al-Hujurat", 18, nil},
+	{50, "ق", "Qaf", 45, nil},
+	{51, "الذاريات", "Adh-Dhariyat", 60, nil},
+	{52, "الطور", "At-Tur", 49, nil},
+	{53, "النجم", "An-Najm", 62, nil},
+	{54, "القمر", "Al-Qamar", 55, nil},
+	{55, "الرحمن", "Ar-Rahman", 78, nil},
+	{56, "الواقعة", "Al-Waqi'ah", 96, nil},
+	{57, "الحديد", "Al-Hadid", 29, nil},
+	{58, "المجادلة", "Al-Mujadilah", 22, []string{"Al-Mujadalah"}},
+	{59, "الحشر", "Al-Hashr", 24, nil},
+	{60, "الممتحنة", "Al-Mumtahanah", 13, nil},
+	{61, "الصف", "As-Saff", 14, nil},
+	{62, "الجمعة", "Al-Jumu'ah", 11, nil},
+	{63, "المنافقون", "Al-Munafiqun", 11, nil},
+	{64, "التغابن", "At-Taghabun", 18, nil},
+	{65, "الطلاق", "At-Talaq", 12, nil},
+	{66, "التحريم", "At-Tahrim", 12, nil},
+	{67, "الملك", "Al-Mulk", 30, nil},
+	{68, "القلم", "Al-Qalam", 52, []string{"Nun"}},
+	{69, "الحاقة", "Al-Haqqah", 52, nil},
+	{70, "المعارج", "Al-Ma'arij", 44, nil},
+	{71, "نوح", "Nuh", 28, nil},
+	{72, "الجن", "Al-Jinn", 28, nil},
+	{73, "المزمل", "Al-Muzzammil", 20, nil},
+	{74, "المدثر", "Al-Muddaththir", 56, []string{"Al-Muddathir"}},
+	{75, "القيامة", "Al-Qiyamah", 40, nil},
+	{76, "الإنسان", "Al-Insan", 31, []string{"Ad-Dahr"}},
+	{77, "المرسلات", "Al-Mursalat", 50, nil},
+	{78, "النبأ", "An-Naba", 40, nil},
+	{79, "النازعات", "An-Nazi'at", 46, nil},
+	{80, "عبس", "Abasa", 42, nil},
+	{81, "التكوير", "At-Takwir", 29, nil},
+	{82, "الانفطار", "Al-Infitar", 19, nil},
+	{83, "المطففين", "Al-Mutaffifin", 36, nil},
+	{84, "الانشقاق", "Al-Inshiqaq", 25, nil},
+	{85, "البروج", "Al-Buruj", 22, nil},
+	{86, "الطارق", "At-Tariq", 17, nil},
+	{87, "الأعلى", "Al-A'la", 19, nil},
+	{88, "الغاشية", "Al-Ghashiyah", 26, nil},
+	{89, "الفجر", "Al-Fajr", 30, nil},
+	{90, "البلد", "Al-Balad", 20, nil},
+	{91, "الشمس", "Ash-Shams", 15, nil},
+	{92, "الليل", "Al-Layl", 21, []string{"Al-Lail"}},
+	{93, "الضحى", "Ad-Duha", 11, nil},
+	{94, "الشرح", "Ash-Sharh", 8, []string{"Al-Inshirah"}},
+	{95, "التين", "At-Tin", 8, nil},
+	{96, "العلق", "Al-Alaq", 19, nil},
+	{97, "القدر", "Al-Qadr", 5, nil},
+	{98, "البينة", "Al-Bayyinah", 8, nil},
+	{99, "الزلزلة", "Az-Zalzalah", 8, []string{"Az-Zilzal"}},
+	{100, "العاديات", "Al-Adiyat", 11, nil},
+	{101, "القارعة", "Al-Qari'ah", 11, nil},
+	{102, "التكاثر", "At-Takathur", 8, nil},
+	{103, "العصر", "Al-Asr", 3, nil},
+	{104, "الهمزة", "Al-Humazah", 9, nil},
+	{105, "الفيل", "Al-Fil", 5, nil},
+	{106, "قريش", "Quraysh", 4, []string{"Quraish"}},
+	{107, "الماعون", "Al-Ma'un", 7, nil},
+	{108, "الكوثر", "Al-Kawthar", 3, []string{"Al-Kauthar"}},
+	{109, "الكافرون", "Al-Kafirun", 6, nil},
+	{110, "النصر", "An-Nasr", 3, nil},
+	{111, "المسد", "Al-Masad", 5, []string{"Al-Lahab"}},
+	{112, "الإخلاص", "Al-Ikhlas", 4, nil},
+	{113, "الفلق", "Al-Falaq", 5, nil},
+	{114, "الناس", "An-Nas", 6, nil},
+}
@@ -0,0 +1,85 @@
+package content
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	DirectionLTR = "ltr"
+	DirectionRTL = "rtl"
+
+	KindFatwa   = "fatwa"
+	KindArticle = "article"
+)
+
+// rtlLanguages are the site languages written in arabic script
+var rtlLanguages = map[string]bool{
+	"ar": true,
+	"fa": true,
+	"ur": true,
+	"ug": true,
+}
+
+// kinds are the url path segments of the page kinds
+var kinds = map[string]string{
+	"answers":  KindFatwa,
+	"articles": KindArticle,
+}
+
+// Language is the language code of a content url
+// e.g. "en" for https://islamqa.info/en/answers/1/...
+func Language(loc string) string {
+	segments := pathSegments(loc)
+	if len(segments[0]) != 2 {
+		return ""
+	}
+
+	return strings.ToLower(segments[0])
+}
+
+// Kind is the page kind of a content url, KindFatwa or KindArticle
+// e.g. "fatwa" for https://islamqa.info/en/answers/1/...
+func Kind(loc string) string {
+	segments := pathSegments(loc)
+	if len(segments) < 2 {
+		return ""
+	}
+
+	return kinds[segments[1]]
+}
+
+// QuestionID is the id of a content url, shared by its translations
+// e.g. 1 for https://islamqa.info/en/answers/1/...
+func QuestionID(loc string) uint {
+	segments := pathSegments(loc)
+	if len(segments) < 3 {
+		return 0
+	}
+
+	id, err := strconv.ParseUint(segments[2], 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	return uint(id)
+}
+
+// Direction is the text direction of a language code
+func Direction(language string) string {
+	if rtlLanguages[language] {
+		return DirectionRTL
+	}
+
+	return DirectionLTR
+}
+
+func pathSegments(loc string) []string {
+	u, err := url.Parse(loc)
+	if err != nil {
+		return []string{""}
+	}
+
+	return strings.Split(strings.Trim(u.Path, "/"), "/")
+}
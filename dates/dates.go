@@ -0,0 +1,138 @@
+package dates
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/hamza72x/islamqa-scrapper/normalize"
+)
+
+// layouts are the machine readable dates, tried first
+var layouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	time.RFC1123Z,
+	time.RFC1123,
+}
+
+// Parse parses a date written in language to UTC: RFC 3339 or another
+// machine readable date, a numeric date (day, month, year or year,
+// month, day, e.g. 2000年3月21日), or a day, month name and year,
+// a hijri one is converted by the Umm al-Qura calendar, the years
+// before 1600 are hijri, the language may be empty for english only
+func Parse(value string, language string) (time.Time, error) {
+	value = strings.TrimSpace(digits(value))
+	if len(value) == 0 {
+		return time.Time{}, errors.New("empty date")
+	}
+
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC(), nil
+		}
+	}
+
+	s := " " + canonical(value) + " "
+
+	monthNumber, hijri := 0, false
+	for _, m := range months(language) {
+		if strings.Contains(s, " "+m.name+" ") {
+			s = strings.Replace(s, " "+m.name+" ", " ", 1)
+			monthNumber, hijri = m.number, m.hijri
+			break
+		}
+	}
+
+	markers := hijriMarkers(language)
+
+	numbers := []int{}
+	for _, field := range strings.Fields(s) {
+		if n, err := strconv.Atoi(field); err == nil {
+			numbers = append(numbers, n)
+			continue
+		}
+		if markers[field] {
+			hijri = true
+		}
+	}
+
+	var year, monthOfYear, day int
+
+	switch {
+	case monthNumber > 0 && len(numbers) >= 2:
+		// 21 March 2000, March 21, 2000 or 2000 March 21
+		monthOfYear = monthNumber
+		if numbers[0] > 31 {
+			year, day = numbers[0], numbers[1]
+		} else {
+			day, year = numbers[0], numbers[1]
+		}
+	case monthNumber == 0 && len(numbers) >= 3:
+		// 21-03-2000 or 2000/03/21
+		if numbers[0] > 31 {
+			year, monthOfYear, day = numbers[0], numbers[1], numbers[2]
+		} else {
+			day, monthOfYear, year = numbers[0], numbers[1], numbers[2]
+		}
+	default:
+		return time.Time{}, errors.New("invalid date: " + value)
+	}
+
+	if year < 1600 {
+		hijri = true
+	}
+
+	if hijri {
+		return FromHijri(year, monthOfYear, day)
+	}
+
+	t := time.Date(year, time.Month(monthOfYear), day, 0, 0, 0, 0, time.UTC)
+	if t.Day() != day || int(t.Month()) != monthOfYear {
+		return time.Time{}, errors.New("invalid date: " + value)
+	}
+
+	return t, nil
+}
+
+// digits are the ascii digits of the digits of normalize.Digits and of
+// the bengali and devanagari ones
+func digits(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= '০' && r <= '৯':
+			return '0' + (r - '০')
+		case r >= '०' && r <= '९':
+			return '0' + (r - '०')
+		}
+		return r
+	}, normalize.Digits(s))
+}
+
+// canonical is the normalised text of a date, words and numbers
+// separated by single spaces, punctuation is a space
+func canonical(s string) string {
+	s = normalize.Text(strings.NewReplacer("'", "", "’", "", "ʼ", "", "ʿ", "", "`", "").Replace(s))
+
+	b := strings.Builder{}
+	var previous rune
+	for _, r := range s {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.Is(unicode.M, r):
+			r = ' '
+		case previous != 0 && previous != ' ' && unicode.IsDigit(r) != unicode.IsDigit(previous):
+			b.WriteRune(' ')
+		}
+		b.WriteRune(r)
+		previous = r
+	}
+
+	return strings.Join(strings.Fields(b.String()), " ")
+}
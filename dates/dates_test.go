@@ -0,0 +1,89 @@
+package dates
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		language string
+		value    string
+		want     time.Time
+		hijri    *HijriDate
+	}{
+		{language: "en", value: "2000-03-21T00:00:00Z", want: time.Date(2000, 3, 21, 0, 0, 0, 0, time.UTC)},
+		{language: "en", value: "March 21, 2000", want: time.Date(2000, 3, 21, 0, 0, 0, 0, time.UTC)},
+		{language: "en", value: "10 Ramadan 1444", hijri: &HijriDate{1444, 9, 10}},
+		{language: "en", value: "15 Dhul-Hijjah 1420 AH", hijri: &HijriDate{1420, 12, 15}},
+		{language: "ar", value: "١٠ رمضان ١٤٤٤ هـ", hijri: &HijriDate{1444, 9, 10}},
+		{language: "ur", value: "10 رمضان 1444", hijri: &HijriDate{1444, 9, 10}},
+		{language: "fa", value: "۱۰ رمضان ۱۴۴۴", hijri: &HijriDate{1444, 9, 10}},
+		{language: "tr", value: "10 Ramazan 1444", hijri: &HijriDate{1444, 9, 10}},
+		{language: "id", value: "10 Ramadhan 1444 H", hijri: &HijriDate{1444, 9, 10}},
+		{language: "fr", value: "10 ramadan 1444", hijri: &HijriDate{1444, 9, 10}},
+		{language: "ru", value: "10 рамадана 1444", hijri: &HijriDate{1444, 9, 10}},
+		{language: "bn", value: "১০ রমজান ১৪৪৪", hijri: &HijriDate{1444, 9, 10}},
+		{language: "bn", value: "10 রমজান 1444", hijri: &HijriDate{1444, 9, 10}},
+		{language: "bn", value: "১৫ জিলহজ্জ ১৪২০ হিজরি", hijri: &HijriDate{1420, 12, 15}},
+		{language: "bn", value: "২১ মার্চ ২০০০", want: time.Date(2000, 3, 21, 0, 0, 0, 0, time.UTC)},
+		{language: "hi", value: "१० रमज़ान १४४४", hijri: &HijriDate{1444, 9, 10}},
+		{language: "hi", value: "10 रमजान 1444", hijri: &HijriDate{1444, 9, 10}},
+		{language: "hi", value: "15 ज़िलहिज्जा 1420 हिजरी", hijri: &HijriDate{1420, 12, 15}},
+		{language: "ug", value: "10 رامىزان 1444", hijri: &HijriDate{1444, 9, 10}},
+		{language: "ug", value: "15 زۇلھەججە 1420 ھ", hijri: &HijriDate{1420, 12, 15}},
+		{language: "es", value: "10 de ramadán de 1444", hijri: &HijriDate{1444, 9, 10}},
+		{language: "es", value: "15 dhu al-hiyya 1420 hégira", hijri: &HijriDate{1420, 12, 15}},
+		{language: "pt", value: "10 de ramadã de 1444", hijri: &HijriDate{1444, 9, 10}},
+		{language: "pt", value: "15 dhu al-hijja 1420", hijri: &HijriDate{1420, 12, 15}},
+		{language: "ge", value: "10. Ramadan 1444", hijri: &HijriDate{1444, 9, 10}},
+		{language: "ge", value: "15. Dhu l-Hiddscha 1420 Hidschra", hijri: &HijriDate{1420, 12, 15}},
+		{language: "tg", value: "10 рамазон 1444", hijri: &HijriDate{1444, 9, 10}},
+		{language: "tg", value: "15 зулҳиҷҷа 1420 ҳ", hijri: &HijriDate{1420, 12, 15}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.language+"/"+tt.value, func(t *testing.T) {
+			want := tt.want
+			if tt.hijri != nil {
+				var err error
+				want, err = FromHijri(tt.hijri.Year, tt.hijri.Month, tt.hijri.Day)
+				require.NoError(t, err)
+			}
+
+			got, err := Parse(tt.value, tt.language)
+			require.NoError(t, err)
+			assert.Equal(t, want, got)
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, value := range []string{"", "Ramadan", "10 রমজান", "31 February 2000"} {
+		_, err := Parse(value, "bn")
+		assert.Error(t, err, value)
+	}
+}
+
+func TestFromHijri(t *testing.T) {
+	got, err := FromHijri(1444, 9, 10)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC), got)
+
+	_, err = FromHijri(1300, 1, 1)
+	assert.Error(t, err)
+}
+
+// TestMonths checks that every language with hijri markers has its
+// twelve hijri months
+func TestMonths(t *testing.T) {
+	for language := range markers {
+		require.Len(t, hijriMonths[language], 12, language)
+		for i, names := range hijriMonths[language] {
+			assert.NotEmpty(t, names, "%s month %d", language, i+1)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package dates
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/hablullah/go-hijri"
+)
+
+// the hijri years the Umm al-Qura tables have
+const (
+	minHijriYear = 1356
+	maxHijriYear = 1500
+)
+
+// HijriDate is a date of the Umm al-Qura calendar
+type HijriDate struct {
+	Year  int
+	Month int
+	Day   int
+}
+
+// String is the date as year-month-day, e.g. 1420-12-15
+func (d HijriDate) String() string {
+	return strconv.Itoa(d.Year) + "-" + pad(d.Month) + "-" + pad(d.Day)
+}
+
+// FromHijri is the gregorian date, in UTC, of an Umm al-Qura date
+func FromHijri(year int, month int, day int) (time.Time, error) {
+	d := HijriDate{Year: year, Month: month, Day: day}
+
+	if year < minHijriYear || year > maxHijriYear || month < 1 || month > 12 || day < 1 || day > 30 {
+		return time.Time{}, errors.New("hijri date out of range: " + d.String())
+	}
+
+	t := hijri.UmmAlQuraDate{Year: int64(year), Month: int64(month), Day: int64(day)}.ToGregorian()
+	t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+
+	// the 30th of a 29 days month is the next month
+	if back, err := ToHijri(t); err != nil || back != d {
+		return time.Time{}, errors.New("invalid hijri date: " + d.String())
+	}
+
+	return t, nil
+}
+
+// ToHijri is the Umm al-Qura date of the day of t in UTC
+func ToHijri(t time.Time) (HijriDate, error) {
+	d, err := hijri.CreateUmmAlQuraDate(t.UTC())
+	if err != nil {
+		return HijriDate{}, err
+	}
+
+	return HijriDate{Year: int(d.Year), Month: int(d.Month), Day: int(d.Day)}, nil
+}
+
+func pad(n int) string {
+	if n < 10 {
+		return "0" + strconv.Itoa(n)
+	}
+	return strconv.Itoa(n)
+}
@@ -0,0 +1,357 @@
+package dates
+
+import (
+	"sort"
+)
+
+// gregorianMonths and hijriMonths are the month names by language,
+// english is tried for every language, the chinese dates are numeric
+var (
+	gregorianMonths = map[string][][]string{
+		"en": {
+			{"january", "jan"}, {"february", "feb"}, {"march", "mar"}, {"april", "apr"},
+			{"may"}, {"june", "jun"}, {"july", "jul"}, {"august", "aug"},
+			{"september", "sep", "sept"}, {"october", "oct"}, {"november", "nov"}, {"december", "dec"},
+		},
+		"ar": {
+			{"يناير", "كانون الثاني"}, {"فبراير", "شباط"}, {"مارس", "آذار"}, {"أبريل", "إبريل", "نيسان"},
+			{"مايو", "أيار"}, {"يونيو", "حزيران"}, {"يوليو", "تموز"}, {"أغسطس", "آب"},
+			{"سبتمبر", "أيلول"}, {"أكتوبر", "تشرين الأول"}, {"نوفمبر", "تشرين الثاني"}, {"ديسمبر", "كانون الأول"},
+		},
+		"ur": {
+			{"جنوری"}, {"فروری"}, {"مارچ"}, {"اپریل"}, {"مئی"}, {"جون"},
+			{"جولائی"}, {"اگست"}, {"ستمبر"}, {"اکتوبر"}, {"نومبر"}, {"دسمبر"},
+		},
+		"fa": {
+			{"ژانویه"}, {"فوریه"}, {"مارس"}, {"آوریل"}, {"مه"}, {"ژوئن"},
+			{"ژوئیه", "جولای"}, {"اوت", "آگوست"}, {"سپتامبر"}, {"اکتبر"}, {"نوامبر"}, {"دسامبر"},
+		},
+		"ug": {
+			{"يانۋار"}, {"فېۋرال"}, {"مارت"}, {"ئاپرېل"}, {"ماي"}, {"ئىيۇن"},
+			{"ئىيۇل"}, {"ئاۋغۇست"}, {"سېنتەبىر"}, {"ئۆكتەبىر"}, {"نويابىر"}, {"دېكابىر"},
+		},
+		"fr": {
+			{"janvier"}, {"février"}, {"mars"}, {"avril"}, {"mai"}, {"juin"},
+			{"juillet"}, {"août"}, {"septembre"}, {"octobre"}, {"novembre"}, {"décembre"},
+		},
+		"es": {
+			{"enero"}, {"febrero"}, {"marzo"}, {"abril"}, {"mayo"}, {"junio"},
+			{"julio"}, {"agosto"}, {"septiembre", "setiembre"}, {"octubre"}, {"noviembre"}, {"diciembre"},
+		},
+		"pt": {
+			{"janeiro"}, {"fevereiro"}, {"março"}, {"abril"}, {"maio"}, {"junho"},
+			{"julho"}, {"agosto"}, {"setembro"}, {"outubro"}, {"novembro"}, {"dezembro"},
+		},
+		"id": {
+			{"januari"}, {"februari"}, {"maret"}, {"april"}, {"mei"}, {"juni"},
+			{"juli"}, {"agustus"}, {"september"}, {"oktober"}, {"november"}, {"desember"},
+		},
+		"ge": {
+			{"januar"}, {"februar"}, {"märz"}, {"april"}, {"mai"}, {"juni"},
+			{"juli"}, {"august"}, {"september"}, {"oktober"}, {"november"}, {"dezember"},
+		},
+		"tr": {
+			{"ocak"}, {"şubat"}, {"mart"}, {"nisan"}, {"mayıs"}, {"haziran"},
+			{"temmuz"}, {"ağustos"}, {"eylül"}, {"ekim"}, {"kasım"}, {"aralık"},
+		},
+		"ru": {
+			{"января", "январь"}, {"февраля", "февраль"}, {"марта", "март"}, {"апреля", "апрель"},
+			{"мая", "май"}, {"июня", "июнь"}, {"июля", "июль"}, {"августа", "август"},
+			{"сентября", "сентябрь"}, {"октября", "октябрь"}, {"ноября", "ноябрь"}, {"декабря", "декабрь"},
+		},
+		"tg": {
+			{"январ"}, {"феврал"}, {"март"}, {"апрел"}, {"май"}, {"июн"},
+			{"июл"}, {"август"}, {"сентябр"}, {"октябр"}, {"ноябр"}, {"декабр"},
+		},
+		"bn": {
+			{"জানুয়ারি", "জানুয়ারী"}, {"ফেব্রুয়ারি", "ফেব্রুয়ারী"}, {"মার্চ"}, {"এপ্রিল"}, {"মে"}, {"জুন"},
+			{"জুলাই"}, {"আগস্ট", "আগষ্ট"}, {"সেপ্টেম্বর"}, {"অক্টোবর"}, {"নভেম্বর"}, {"ডিসেম্বর"},
+		},
+		"hi": {
+			{"जनवरी"}, {"फ़रवरी", "फरवरी"}, {"मार्च"}, {"अप्रैल"}, {"मई"}, {"जून"},
+			{"जुलाई"}, {"अगस्त"}, {"सितंबर", "सितम्बर"}, {"अक्टूबर"}, {"नवंबर", "नवम्बर"}, {"दिसंबर", "दिसम्बर"},
+		},
+	}
+
+	hijriMonths = map[string][][]string{
+		"en": {
+			{"muharram"},
+			{"safar"},
+			{"rabi al-awwal", "rabi ul-awwal", "rabi al-awal", "rabi i"},
+			{"rabi al-thani", "rabi al-akhir", "rabi ul-akhir", "rabi ul-thani", "rabi ii"},
+			{"jumada al-ula", "jumada al-awwal", "jumada al-oula", "jumada i"},
+			{"jumada al-akhirah", "jumada al-thani", "jumada al-akhira", "jumada ii"},
+			{"rajab"},
+			{"shaban", "sha'ban"},
+			{"ramadan", "ramadhan"},
+			{"shawwal"},
+			{"dhu al-qadah", "dhul-qadah", "dhu al-qidah", "dhul-qidah", "dhu'l-qa'dah"},
+			{"dhu al-hijjah", "dhul-hijjah", "dhu'l-hijjah"},
+		},
+		"ar": {
+			{"محرم"},
+			{"صفر"},
+			{"ربيع الأول", "ربيع أول"},
+			{"ربيع الآخر", "ربيع الثاني", "ربيع آخر", "ربيع ثاني"},
+			{"جمادى الأولى", "جمادى الأول", "جمادى أولى"},
+			{"جمادى الآخرة", "جمادى الثانية", "جمادى الآخر", "جمادى ثانية"},
+			{"رجب"},
+			{"شعبان"},
+			{"رمضان"},
+			{"شوال"},
+			{"ذو القعدة", "ذي القعدة"},
+			{"ذو الحجة", "ذي الحجة"},
+		},
+		"ur": {
+			{"محرم"},
+			{"صفر"},
+			{"ربیع الاول", "ربیع الأول"},
+			{"ربیع الثانی", "ربیع الآخر"},
+			{"جمادی الاول", "جمادی الاولی"},
+			{"جمادی الثانی", "جمادی الآخر", "جمادی الثانیہ"},
+			{"رجب"},
+			{"شعبان"},
+			{"رمضان"},
+			{"شوال"},
+			{"ذوالقعدہ", "ذو القعدہ", "ذی قعدہ"},
+			{"ذوالحجہ", "ذو الحجہ", "ذی الحجہ"},
+		},
+		"fa": {
+			{"محرم"},
+			{"صفر"},
+			{"ربیع‌الاول", "ربیع الاول"},
+			{"ربیع‌الثانی", "ربیع الثانی"},
+			{"جمادی‌الاول", "جمادی الاول", "جمادی‌الاولی"},
+			{"جمادی‌الثانی", "جمادی الثانی", "جمادی‌الآخر"},
+			{"رجب"},
+			{"شعبان"},
+			{"رمضان"},
+			{"شوال"},
+			{"ذی‌القعده", "ذیقعده"},
+			{"ذی‌الحجه", "ذیحجه"},
+		},
+		"tr": {
+			{"muharrem"},
+			{"safer"},
+			{"rebiülevvel"},
+			{"rebiülahir"},
+			{"cemaziyelevvel"},
+			{"cemaziyelahir"},
+			{"recep", "receb"},
+			{"şaban"},
+			{"ramazan"},
+			{"şevval"},
+			{"zilkade"},
+			{"zilhicce"},
+		},
+		"id": {
+			{"muharram"},
+			{"safar"},
+			{"rabiul awal", "rabiul awwal"},
+			{"rabiul akhir", "rabiul tsani"},
+			{"jumadil awal", "jumadil ula"},
+			{"jumadil akhir", "jumadil tsani"},
+			{"rajab"},
+			{"syaban", "sya'ban"},
+			{"ramadhan"},
+			{"syawal"},
+			{"dzulqaidah", "dzulqa'dah"},
+			{"dzulhijjah"},
+		},
+		"fr": {
+			{"mouharram"},
+			{"safar"},
+			{"rabi al awal", "rabi al-awwal"},
+			{"rabi ath-thani", "rabi al-akhir"},
+			{"joumada al oula", "joumada al-oula"},
+			{"joumada ath-thania", "joumada al-akhira"},
+			{"rajab"},
+			{"chaabane", "chaaban"},
+			{"ramadan", "ramadhan"},
+			{"chawwal"},
+			{"dhou al qi'da", "dhoul qi'da"},
+			{"dhou al hijja", "dhoul hijja"},
+		},
+		"ru": {
+			{"мухаррам", "мухаррама"},
+			{"сафар", "сафара"},
+			{"раби аль-авваль", "раби аль-авваля"},
+			{"раби ас-сани", "раби аль-ахир"},
+			{"джумада аль-уля", "джумада аль-авваль"},
+			{"джумада ас-сани", "джумада ас-сания", "джумада аль-ахира"},
+			{"раджаб", "раджаба"},
+			{"шаабан", "шаабана"},
+			{"рамадан", "рамадана"},
+			{"шавваль", "шавваля"},
+			{"зуль-када", "зуль-каада"},
+			{"зуль-хиджа", "зуль-хиджжа"},
+		},
+		"ug": {
+			{"مۇھەررەم"},
+			{"سەپەر"},
+			{"رەبىئۇلئەۋۋەل", "رەبىئۇل ئەۋۋەل"},
+			{"رەبىئۇلئاخىر", "رەبىئۇل ئاخىر"},
+			{"جامادىيەلئەۋۋەل", "جامادىيەل ئەۋۋەل"},
+			{"جامادىيەلئاخىر", "جامادىيەل ئاخىر"},
+			{"رەجەب"},
+			{"شەئبان", "شەبان"},
+			{"رامىزان", "رامازان"},
+			{"شەۋۋال"},
+			{"زۇلقەئدە", "زۇلقەدە"},
+			{"زۇلھەججە"},
+		},
+		"es": {
+			{"muharram"},
+			{"safar"},
+			{"rabi al-awwal", "rabi al-awal"},
+			{"rabi al-thani", "rabi al-ajir", "rabi al-akhir"},
+			{"yumada al-ula", "jumada al-ula"},
+			{"yumada al-ajira", "yumada al-thani", "jumada al-akhira"},
+			{"rayab", "rajab"},
+			{"shaabán", "sha'ban"},
+			{"ramadán", "ramadhán"},
+			{"shawwal", "chawwal"},
+			{"dhu al-qa'da", "dul qa'da"},
+			{"dhu al-hiyya", "dhu al-hijja", "dul hiyya"},
+		},
+		"pt": {
+			{"muharram"},
+			{"safar"},
+			{"rabi al-awwal", "rabi al-awal"},
+			{"rabi al-thani", "rabi al-akhir"},
+			{"jumada al-ula", "jumada al-awwal"},
+			{"jumada al-akhira", "jumada al-thani"},
+			{"rajab"},
+			{"shaaban", "sha'ban", "xaban"},
+			{"ramadã", "ramadão", "ramadhan"},
+			{"shawwal", "chawwal"},
+			{"dhu al-qa'da", "dhul-qada"},
+			{"dhu al-hijja", "dhul-hijja"},
+		},
+		"ge": {
+			{"muharram"},
+			{"safar"},
+			{"rabi al-awwal", "rabi' al-awwal"},
+			{"rabi al-thani", "rabi al-achir"},
+			{"dschumada al-ula", "dschumada l-ula"},
+			{"dschumada al-achira", "dschumada th-thaniya"},
+			{"radschab"},
+			{"schaban", "scha'ban"},
+			{"ramadan"},
+			{"schawwal"},
+			{"dhu l-qa'da", "dhul-qada"},
+			{"dhu l-hiddscha", "dhul-hiddscha", "dhu l-hidschdscha"},
+		},
+		"tg": {
+			{"муҳаррам"},
+			{"сафар"},
+			{"рабеъулаввал", "рабиулаввал"},
+			{"рабеъуссонӣ", "рабиуссонӣ", "рабеъулохир"},
+			{"ҷумодиулаввал", "ҷамодиулаввал"},
+			{"ҷумодиуссонӣ", "ҷамодиуссонӣ", "ҷумодиулохир"},
+			{"раҷаб"},
+			{"шаъбон"},
+			{"рамазон"},
+			{"шаввол"},
+			{"зулқаъда", "зулқаъд"},
+			{"зулҳиҷҷа", "зулҳиҷа"},
+		},
+		"bn": {
+			{"মহররম", "মুহাররম"},
+			{"সফর"},
+			{"রবিউল আউয়াল", "রবিউল আওয়াল"},
+			{"রবিউস সানি", "রবিউস সানী", "রবিউল আখির"},
+			{"জমাদিউল আউয়াল", "জুমাদাল উলা", "জমাদিউল আওয়াল"},
+			{"জমাদিউস সানি", "জমাদিউস সানী", "জুমাদাল আখিরাহ"},
+			{"রজব"},
+			{"শাবান", "শা'বান"},
+			{"রমজান", "রমযান", "রামাদান", "রামাযান"},
+			{"শাওয়াল"},
+			{"জিলকদ", "যিলকদ", "জিলকদ্দ", "যুলকাদাহ"},
+			{"জিলহজ", "জিলহজ্জ", "যিলহজ্জ", "যুলহিজ্জাহ"},
+		},
+		"hi": {
+			{"मुहर्रम"},
+			{"सफ़र", "सफर"},
+			{"रबी उल अव्वल", "रबीउल अव्वल"},
+			{"रबी उल आख़िर", "रबी उस सानी", "रबीउल आखिर"},
+			{"जमादी उल अव्वल", "जुमादा अल ऊला", "जमादिल अव्वल"},
+			{"जमादी उल आख़िर", "जमादी उस सानी", "जमादिल आखिर"},
+			{"रजब"},
+			{"शाबान", "शअबान"},
+			{"रमज़ान", "रमजान", "रमदान"},
+			{"शव्वाल"},
+			{"ज़ुल क़ादा", "ज़िलक़ाद", "जिलकाद"},
+			{"ज़ुल हिज्जा", "ज़िलहिज्जा", "जिलहिज्जा"},
+		},
+	}
+
+	// markers tell a hijri date, e.g. "1440 هـ" or "1440 AH"
+	markers = map[string][]string{
+		"en": {"ah", "h", "hijri"},
+		"ar": {"هـ", "ه", "هجري", "هجرية"},
+		"ur": {"ھ", "ہ", "ہجری"},
+		"fa": {"ق", "ه", "هـ", "هجری", "قمری"},
+		"ug": {"ھ", "ھىجرىيە"},
+		"tr": {"hicri"},
+		"id": {"hijriyah", "hijriah"},
+		"fr": {"hégire"},
+		"es": {"hégira"},
+		"pt": {"hégira"},
+		"ge": {"hidschra"},
+		"ru": {"х", "хиджры"},
+		"tg": {"ҳ", "ҳиҷрӣ"},
+		"bn": {"হিজরি", "হিজরী"},
+		"hi": {"हिजरी"},
+	}
+)
+
+// month is a month name, canonical as the date it's matched in
+type month struct {
+	name   string
+	number int
+	hijri  bool
+}
+
+// months are the month names of a language and of english,
+// longest first so that a name isn't matched by its prefix
+func months(language string) []month {
+	names := []month{}
+	for _, lang := range languages(language) {
+		names = appendMonths(names, gregorianMonths[lang], false)
+		names = appendMonths(names, hijriMonths[lang], true)
+	}
+
+	sort.SliceStable(names, func(i, j int) bool {
+		return len(names[i].name) > len(names[j].name)
+	})
+
+	return names
+}
+
+func appendMonths(names []month, variants [][]string, hijri bool) []month {
+	for i, monthNames := range variants {
+		for _, name := range monthNames {
+			names = append(names, month{name: canonical(name), number: i + 1, hijri: hijri})
+		}
+	}
+	return names
+}
+
+// hijriMarkers are the canonical markers of a language and of english
+func hijriMarkers(language string) map[string]bool {
+	found := map[string]bool{}
+	for _, lang := range languages(language) {
+		for _, marker := range markers[lang] {
+			found[canonical(marker)] = true
+		}
+	}
+	return found
+}
+
+func languages(language string) []string {
+	if len(language) == 0 || language == "en" {
+		return []string{"en"}
+	}
+	return []string{"en", language}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/hamza72x/islamqa-scrapper/content"
+	"github.com/hamza72x/islamqa-scrapper/log"
+
+	"gorm.io/gorm"
+)
+
+// diffCommand prints the word diffs between the revisions of a question,
+// deleted words as [-words-] and inserted words as {+words+}
+// usage: diff [-lang en] <question-id>
+func diffCommand(db *gorm.DB, args []string) {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	language := flags.String("lang", "", "language code, e.g. en, every language by default")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		log.Fatal("usage: diff [-lang en] <question-id>")
+	}
+
+	questionID, err := strconv.ParseUint(flags.Arg(0), 10, 64)
+	if err != nil {
+		log.Fatal("invalid question id: " + flags.Arg(0))
+	}
+
+	tx := db.Where("question_id = ? AND source = ?", questionID, content.RevisionSourceContents)
+	if len(*language) > 0 {
+		tx = tx.Where("language = ?", *language)
+	}
+
+	revisions := []*content.Revision{}
+	if err := tx.Order("language, content_id, id").Find(&revisions).Error; err != nil {
+		log.Fatal("failed to find revisions: " + err.Error())
+	}
+
+	if len(revisions) == 0 {
+		log.Fatal("no revisions of question " + flags.Arg(0))
+	}
+
+	for i, r := range revisions {
+		if i == 0 || revisions[i-1].ContentID != r.ContentID {
+			fmt.Printf("%s\t%s\n", r.Language, r.URL)
+			fmt.Printf("revision %d\tfetched %s\tlastmod %s\n\n", r.ID, formatTime(r.FetchedAt), formatTime(r.LastModified))
+			continue
+		}
+
+		previous := revisions[i-1]
+
+		fmt.Printf("revision %d -> %d\tfetched %s\tlastmod %s\n", previous.ID, r.ID, formatTime(r.FetchedAt), formatTime(r.LastModified))
+		fmt.Print(content.DiffRevisions(previous, r))
+		fmt.Println()
+	}
+}
+
+func formatTime(t time.Time) string {
+	return t.Format("2006-01-02 15:04")
+}
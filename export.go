@@ -0,0 +1,155 @@
+package main
+
+import (
+	"flag"
+	"strings"
+	"time"
+
+	"github.com/hamza72x/islamqa-scrapper/dates"
+	"github.com/hamza72x/islamqa-scrapper/export"
+	"github.com/hamza72x/islamqa-scrapper/log"
+
+	"gorm.io/gorm"
+)
+
+// exportCommand exports the contents to files
+// usage: export <format> [flags], e.g. export jsonl -out export
+func exportCommand(db *gorm.DB, args []string) {
+	if len(args) == 0 {
+		log.Fatal("usage: export jsonl|parquet|csv|epub|markdown [flags]")
+	}
+
+	format := args[0]
+
+	flags := flag.NewFlagSet("export "+format, flag.ExitOnError)
+	site := flags.String("site", "", "site adapter, e.g. islamqa, every site by default")
+	language := flags.String("lang", "", "language code, e.g. en, every language by default")
+	kind := flags.String("kind", "", "fatwa or article, both by default")
+	modifiedSince := flags.String("modified-since", "", "last modified since, RFC 3339 or YYYY-MM-DD")
+	publishedSince := flags.String("published-since", "", "published at or after, by the date of the page, RFC 3339 or YYYY-MM-DD")
+	publishedUntil := flags.String("published-until", "", "published before, RFC 3339 or YYYY-MM-DD")
+	minViews := flags.Int64("min-views", 0, "viewed at least as many times")
+	author := flags.String("author", "", "author name, every author by default")
+	sort := flags.String("sort", export.SortID, "id, published (latest first) or views (most viewed first)")
+	since := flags.String("since", "", "checkpoint id or \"last\", only the rows created, updated or deleted since")
+	out := flags.String("out", "export", "output directory")
+	rowGroupSize := flags.Int64("row-group-size", export.DefaultRowGroupSize, "parquet row group size in bytes")
+	columns := flags.String("columns", strings.Join(export.DefaultColumns, ","), "csv columns, comma separated")
+	tsv := flags.Bool("tsv", false, "csv separated by tabs")
+	bom := flags.Bool("bom", false, "csv starting with a UTF-8 BOM, for Excel")
+	byCategory := flags.Bool("by-category", false, "an epub per language and category")
+	flags.Parse(args[1:])
+
+	q := export.Query{
+		Source:   export.SourceContents,
+		Site:     *site,
+		Language: *language,
+		Kind:     *kind,
+		MinViews: *minViews,
+		Author:   *author,
+		Sort:     *sort,
+	}
+
+	if len(*modifiedSince) > 0 {
+		t, err := parseTime(*modifiedSince)
+		if err != nil {
+			log.Fatal("invalid modified-since: " + *modifiedSince)
+		}
+		q.ModifiedSince = t
+	}
+
+	if len(*publishedSince) > 0 {
+		t, err := parseTime(*publishedSince)
+		if err != nil {
+			log.Fatal("invalid published-since: " + *publishedSince)
+		}
+		q.PublishedSince = t
+	}
+
+	if len(*publishedUntil) > 0 {
+		t, err := parseTime(*publishedUntil)
+		if err != nil {
+			log.Fatal("invalid published-until: " + *publishedUntil)
+		}
+		q.PublishedUntil = t
+	}
+
+	// the options changing the files of a format, a delta
+	// applies to an export of the same options
+	options := []string{}
+	switch format {
+	case "csv":
+		options = append(options, "-columns", *columns)
+		if *tsv {
+			options = append(options, "-tsv")
+		}
+		if *bom {
+			options = append(options, "-bom")
+		}
+	case "epub":
+		if *byCategory {
+			options = append(options, "-by-category")
+		}
+	}
+
+	var sinceCheckpoint *export.Checkpoint
+	if len(*since) > 0 {
+		var err error
+		if sinceCheckpoint, err = export.FindCheckpoint(db, *since, format, q, options...); err != nil {
+			log.Fatal("failed to find checkpoint: " + err.Error())
+		}
+		q.UpdatedSince = sinceCheckpoint.StartedAt
+	}
+
+	checkpoint := export.NewCheckpoint(format, q, sinceCheckpoint, options...)
+
+	switch format {
+	case "jsonl":
+		manifest, err := export.JSONL(db, q, *out)
+		if err != nil {
+			log.Fatal("failed to export jsonl: " + err.Error())
+		}
+		log.Ok("exported", len(manifest.Shards), "shards to", *out)
+	case "parquet":
+		count, err := export.Parquet(db, q, *out, *rowGroupSize)
+		if err != nil {
+			log.Fatal("failed to export parquet: " + err.Error())
+		}
+		log.Ok("exported", count, "rows to", *out)
+	case "csv":
+		count, err := export.CSV(db, q, *out, export.CSVOptions{
+			Columns: strings.Split(*columns, ","),
+			TSV:     *tsv,
+			BOM:     *bom,
+		})
+		if err != nil {
+			log.Fatal("failed to export csv: " + err.Error())
+		}
+		log.Ok("exported", count, "rows to", *out)
+	case "epub":
+		books, err := export.EPUB(db, q, *out, *byCategory)
+		if err != nil {
+			log.Fatal("failed to export epub: " + err.Error())
+		}
+		log.Ok("exported", len(books), "books to", *out)
+	case "markdown":
+		stats, err := export.Markdown(db, q, *out)
+		if err != nil {
+			log.Fatal("failed to export markdown: " + err.Error())
+		}
+		log.Ok("exported", stats.Written, "notes to", *out+",", stats.Unchanged, "unchanged,", stats.Deleted, "deleted")
+	default:
+		log.Fatal("unknown export format: " + format)
+	}
+
+	if err := checkpoint.Save(db); err != nil {
+		log.Fatal("failed to save checkpoint: " + err.Error())
+	}
+
+	log.Info("checkpoint", checkpoint.ID)
+}
+
+// parseTime parses a date of a flag to UTC, see dates.Parse
+func parseTime(value string) (time.Time, error) {
+	return dates.Parse(value, "")
+}
@@ -0,0 +1,182 @@
+package export
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CheckpointLast refers to the latest checkpoint of an export
+const CheckpointLast = "last"
+
+// Checkpoint records an export, the next export since it has only
+// the rows created, updated or deleted after it started
+type Checkpoint struct {
+	ID     uint   `gorm:"primarykey;column:id"`
+	Source string `gorm:"column:source;index"`
+	Format string `gorm:"column:format;index"`
+
+	// Site, Language and Kind are the filters of the export
+	Site     string `gorm:"column:site"`
+	Language string `gorm:"column:language"`
+	Kind     string `gorm:"column:kind"`
+
+	// Filters are the other filters of the export and the options
+	// of its format, as flags, e.g. "-min-views 1000 -tsv"
+	Filters string `gorm:"column:filters"`
+
+	// SinceID is the checkpoint the export was a delta of, nil for full exports
+	SinceID *uint `gorm:"column:since_id"`
+
+	// StartedAt is taken before reading the rows, a row updated
+	// during the export is in the next delta too
+	StartedAt time.Time `gorm:"column:started_at"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+}
+
+func (Checkpoint) TableName() string {
+	return "export_checkpoints"
+}
+
+// FindCheckpoint finds a checkpoint by its id, or the latest checkpoint
+// of the same export for CheckpointLast, a checkpoint of another format,
+// source, filters or options is an error, options are the flags of the
+// format changing its files, e.g. the csv columns
+func FindCheckpoint(db *gorm.DB, ref string, format string, q Query, options ...string) (*Checkpoint, error) {
+	if len(q.Source) == 0 {
+		q.Source = SourceContents
+	}
+
+	filters := checkpointFilters(q, options)
+	checkpoint := &Checkpoint{}
+
+	if ref == CheckpointLast {
+		err := db.
+			Where(
+				"source = ? AND format = ? AND site = ? AND language = ? AND kind = ? AND filters = ?",
+				q.Source, format, q.Site, q.Language, q.Kind, filters,
+			).
+			Order("id DESC").
+			Limit(1).
+			Find(checkpoint).
+			Error
+		if err != nil {
+			return nil, err
+		}
+		if checkpoint.ID == 0 {
+			return nil, errors.New("no checkpoint of " + format + " " + q.Source)
+		}
+		return checkpoint, nil
+	}
+
+	id, err := strconv.ParseUint(ref, 10, 64)
+	if err != nil {
+		return nil, errors.New("invalid checkpoint: " + ref)
+	}
+
+	if err := db.Where("id = ?", id).Limit(1).Find(checkpoint).Error; err != nil {
+		return nil, err
+	}
+	if checkpoint.ID == 0 {
+		return nil, errors.New("checkpoint not found: " + ref)
+	}
+
+	// a delta applies to the export of the checkpoint only
+	if !checkpoint.of(format, q, filters) {
+		return nil, errors.New("checkpoint " + ref + " is of another export: " + checkpoint.describe())
+	}
+
+	return checkpoint, nil
+}
+
+// of is whether the checkpoint is of the export of format, q and filters
+func (c *Checkpoint) of(format string, q Query, filters string) bool {
+	return c.Source == q.Source &&
+		c.Format == format &&
+		c.Site == q.Site &&
+		c.Language == q.Language &&
+		c.Kind == q.Kind &&
+		c.Filters == filters
+}
+
+// checkpointFilters are the filters of q other than its source, site,
+// language and kind, and the options, as flags, the sort and the
+// updated since time don't change the exported rows
+func checkpointFilters(q Query, options []string) string {
+	flags := []string{}
+
+	times := []struct {
+		name string
+		t    time.Time
+	}{
+		{"-modified-since", q.ModifiedSince},
+		{"-published-since", q.PublishedSince},
+		{"-published-until", q.PublishedUntil},
+	}
+	for _, f := range times {
+		if !f.t.IsZero() {
+			flags = append(flags, f.name, f.t.UTC().Format(time.RFC3339))
+		}
+	}
+
+	if q.MinViews > 0 {
+		flags = append(flags, "-min-views", strconv.FormatInt(q.MinViews, 10))
+	}
+
+	if len(q.Author) > 0 {
+		flags = append(flags, "-author", strconv.Quote(q.Author))
+	}
+
+	return strings.Join(append(flags, options...), " ")
+}
+
+// describe is the format, source and filters of the checkpoint,
+// e.g. "jsonl contents -site islamqa -lang en"
+func (c *Checkpoint) describe() string {
+	s := c.Format + " " + c.Source
+	if len(c.Site) > 0 {
+		s += " -site " + c.Site
+	}
+	if len(c.Language) > 0 {
+		s += " -lang " + c.Language
+	}
+	if len(c.Kind) > 0 {
+		s += " -kind " + c.Kind
+	}
+	if len(c.Filters) > 0 {
+		s += " " + c.Filters
+	}
+	return s
+}
+
+// NewCheckpoint starts a checkpoint of an export, since is nil for
+// full exports, it's saved by Save once the export is done,
+// options are the flags of the format as in FindCheckpoint
+func NewCheckpoint(format string, q Query, since *Checkpoint, options ...string) *Checkpoint {
+	if len(q.Source) == 0 {
+		q.Source = SourceContents
+	}
+
+	c := &Checkpoint{
+		Source:    q.Source,
+		Format:    format,
+		Site:      q.Site,
+		Language:  q.Language,
+		Kind:      q.Kind,
+		Filters:   checkpointFilters(q, options),
+		StartedAt: time.Now().UTC(),
+	}
+
+	if since != nil {
+		c.SinceID = &since.ID
+	}
+
+	return c
+}
+
+func (c *Checkpoint) Save(db *gorm.DB) error {
+	return db.Create(c).Error
+}
@@ -0,0 +1,166 @@
+package export
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hamza72x/islamqa-scrapper/content"
+	"github.com/hamza72x/islamqa-scrapper/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCheckpointTombstones exports a deleted content as a tombstone
+// in the delta since a checkpoint, its note is removed from the vault,
+// whatever the timezone of the exporter
+func TestCheckpointTombstones(t *testing.T) {
+	local := time.Local
+	t.Cleanup(func() { time.Local = local })
+
+	for _, name := range []string{"UTC", "America/New_York", "Asia/Riyadh"} {
+		zone, err := time.LoadLocation(name)
+		require.NoError(t, err)
+
+		t.Run(name, func(t *testing.T) {
+			time.Local = zone
+			testCheckpointTombstones(t)
+		})
+	}
+}
+
+func testCheckpointTombstones(t *testing.T) {
+	db := newTestDB(t, 2)
+	dir := t.TempDir()
+
+	q := Query{}
+	stats, err := Markdown(db, q, dir)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.Written)
+
+	checkpoint := NewCheckpoint("markdown", q, nil)
+	require.NoError(t, checkpoint.Save(db))
+
+	// the rows changed after the checkpoint started
+	time.Sleep(10 * time.Millisecond)
+	require.NoError(t, db.Where("question_id = ?", 1).Delete(&content.Content{}).Error)
+
+	since, err := FindCheckpoint(db, CheckpointLast, "markdown", q)
+	require.NoError(t, err)
+	assert.Equal(t, checkpoint.ID, since.ID)
+
+	q.UpdatedSince = since.StartedAt
+
+	records := []*Record{}
+	require.NoError(t, Each(db, q, func(r *Record) error {
+		records = append(records, r)
+		return nil
+	}))
+	if assert.Len(t, records, 1) {
+		assert.True(t, records[0].Deleted)
+		assert.Equal(t, uint(1), records[0].QuestionID)
+		assert.NotNil(t, records[0].DeletedAt)
+	}
+
+	stats, err = Markdown(db, q, dir)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Deleted)
+
+	_, err = os.Stat(filepath.Join(dir, "islamqa", "en", "fatwa", "1.md"))
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(dir, "islamqa", "en", "fatwa", "2.md"))
+	assert.NoError(t, err)
+}
+
+// TestCheckpointTouched exports a content whose metadata changed
+// without its text in the delta since a checkpoint
+func TestCheckpointTouched(t *testing.T) {
+	db := newTestDB(t, 2)
+
+	q := Query{}
+	checkpoint := NewCheckpoint("jsonl", q, nil)
+	require.NoError(t, checkpoint.Save(db))
+
+	time.Sleep(10 * time.Millisecond)
+
+	contents := repository.NewGorm(db)
+	c, err := contents.FindByURL("https://islamqa.info/en/answers/2")
+	require.NoError(t, err)
+
+	views := int64(100)
+	c.Views = &views
+	require.NoError(t, contents.Touch(c))
+
+	q.UpdatedSince = checkpoint.StartedAt
+
+	records := []*Record{}
+	require.NoError(t, Each(db, q, func(r *Record) error {
+		records = append(records, r)
+		return nil
+	}))
+	if assert.Len(t, records, 1) {
+		assert.Equal(t, uint(2), records[0].QuestionID)
+		assert.Equal(t, &views, records[0].Views)
+	}
+}
+
+func TestFindCheckpoint(t *testing.T) {
+	db := newTestDB(t, 0)
+
+	q := Query{Site: "islamqa", Language: "en"}
+	checkpoint := NewCheckpoint("jsonl", q, nil)
+	require.NoError(t, checkpoint.Save(db))
+
+	ref := "1"
+	found, err := FindCheckpoint(db, ref, "jsonl", q)
+	require.NoError(t, err)
+	assert.Equal(t, checkpoint.ID, found.ID)
+
+	// the checkpoints of the other exports
+	others := []struct {
+		format  string
+		q       Query
+		options []string
+	}{
+		{format: "csv", q: q},
+		{format: "jsonl", q: Query{Site: "islamqa"}},
+		{format: "jsonl", q: Query{Site: "islamqa", Language: "en", MinViews: 10}},
+		{format: "jsonl", q: Query{Site: "islamqa", Language: "en", Author: "Author"}},
+		{format: "jsonl", q: Query{Site: "islamqa", Language: "en", ModifiedSince: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}},
+		{format: "jsonl", q: q, options: []string{"-columns", "id,title"}},
+	}
+	for _, other := range others {
+		name := other.format + " " + checkpointFilters(other.q, other.options)
+
+		_, err := FindCheckpoint(db, ref, other.format, other.q, other.options...)
+		assert.Error(t, err, name)
+
+		_, err = FindCheckpoint(db, CheckpointLast, other.format, other.q, other.options...)
+		assert.Error(t, err, name)
+	}
+
+	// the sort doesn't change the rows
+	sorted := q
+	sorted.Sort = SortViews
+	_, err = FindCheckpoint(db, ref, "jsonl", sorted)
+	assert.NoError(t, err)
+
+	// the filters and options are of the checkpoint
+	filtered := Query{Site: "islamqa", MinViews: 10, Author: "Author"}
+	options := []string{"-columns", "id,title", "-tsv"}
+	checkpoint = NewCheckpoint("csv", filtered, nil, options...)
+	require.NoError(t, checkpoint.Save(db))
+	assert.Equal(t, `-min-views 10 -author "Author" -columns id,title -tsv`, checkpoint.Filters)
+
+	found, err = FindCheckpoint(db, CheckpointLast, "csv", filtered, options...)
+	require.NoError(t, err)
+	assert.Equal(t, checkpoint.ID, found.ID)
+
+	_, err = FindCheckpoint(db, "2", "jsonl", q)
+	assert.Error(t, err)
+
+	_, err = FindCheckpoint(db, "abc", "jsonl", q)
+	assert.Error(t, err)
+}
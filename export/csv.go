@@ -0,0 +1,163 @@
+package export
+
+import (
+	"encoding/csv"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DefaultColumns are the CSV columns when none are chosen
+var DefaultColumns = []string{
+	"question_id", "url", "language", "kind", "title", "question", "text", "last_modified", "hash",
+}
+
+// columns are the values of a record by CSV column name
+var columns = map[string]func(r *Record) string{
+	"source":      func(r *Record) string { return r.Source },
+	"site":        func(r *Record) string { return r.Site },
+	"id":          func(r *Record) string { return strconv.FormatUint(uint64(r.ID), 10) },
+	"question_id": func(r *Record) string { return strconv.FormatUint(uint64(r.QuestionID), 10) },
+	"url":         func(r *Record) string { return r.URL },
+	"language":    func(r *Record) string { return r.Language },
+	"kind":        func(r *Record) string { return r.Kind },
+	"direction":   func(r *Record) string { return r.Direction },
+	"title":       func(r *Record) string { return r.Title },
+	"question":    func(r *Record) string { return r.Question },
+	"summary":     func(r *Record) string { return r.Summary },
+	"text":        func(r *Record) string { return r.Text },
+	"markdown":    func(r *Record) string { return r.Markdown },
+	"html":        func(r *Record) string { return r.HTML },
+	"last_modified": func(r *Record) string {
+		return r.LastModified.UTC().Format(time.RFC3339)
+	},
+	"published": func(r *Record) string { return formatTime(r.Published) },
+	"modified":  func(r *Record) string { return formatTime(r.Modified) },
+	"views": func(r *Record) string {
+		if r.Views == nil {
+			return ""
+		}
+		return strconv.FormatInt(*r.Views, 10)
+	},
+	"author":      func(r *Record) string { return r.Author },
+	"publisher":   func(r *Record) string { return r.Publisher },
+	"sitemap_url": func(r *Record) string { return r.SitemapURL },
+	"deleted":     func(r *Record) string { return strconv.FormatBool(r.Deleted) },
+	"hash":        func(r *Record) string { return r.Hash },
+	"categories": func(r *Record) string {
+		names := []string{}
+		for _, c := range r.Categories {
+			names = append(names, c.Name)
+		}
+		return strings.Join(names, "; ")
+	},
+	"references": func(r *Record) string {
+		texts := []string{}
+		for _, ref := range r.References {
+			texts = append(texts, ref.Text)
+		}
+		return strings.Join(texts, "\n")
+	},
+}
+
+// formatTime is RFC 3339 in UTC, empty for nil
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// CSVOptions of CSV, zero values are the defaults
+type CSVOptions struct {
+	// Columns are DefaultColumns by default
+	Columns []string
+
+	// TSV separates the fields by tabs instead of commas
+	TSV bool
+
+	// BOM starts the file with a UTF-8 byte order mark,
+	// so that Excel reads it as UTF-8
+	BOM bool
+}
+
+// CSV writes the records of q to dir as <source>.csv or <source>.tsv
+// fields with separators, quotes or new lines are quoted as in RFC 4180
+// returns the number of rows written, the header excluded
+func CSV(db *gorm.DB, q Query, dir string, opts CSVOptions) (int, error) {
+	if len(q.Source) == 0 {
+		q.Source = SourceContents
+	}
+
+	if len(opts.Columns) == 0 {
+		opts.Columns = DefaultColumns
+	}
+
+	values := []func(r *Record) string{}
+	for _, name := range opts.Columns {
+		value, ok := columns[name]
+		if !ok {
+			return 0, errors.New("unknown column: " + name)
+		}
+		values = append(values, value)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return 0, err
+	}
+
+	ext := ".csv"
+	if opts.TSV {
+		ext = ".tsv"
+	}
+
+	file, err := os.Create(filepath.Join(dir, q.Source+ext))
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	if opts.BOM {
+		if _, err := file.WriteString("\ufeff"); err != nil {
+			return 0, err
+		}
+	}
+
+	w := csv.NewWriter(file)
+	w.UseCRLF = true
+	if opts.TSV {
+		w.Comma = '\t'
+	}
+
+	if err := w.Write(opts.Columns); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	row := make([]string, len(values))
+
+	err = Each(db, q, func(r *Record) error {
+		for i, value := range values {
+			row[i] = value(r)
+		}
+
+		count++
+
+		return w.Write(row)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return 0, err
+	}
+
+	return count, file.Close()
+}
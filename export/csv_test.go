@@ -0,0 +1,96 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hamza72x/islamqa-scrapper/content"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readCSV parses an exported file by encoding/csv, returns whether
+// it starts with a UTF-8 BOM
+func readCSV(t *testing.T, file string, comma rune) ([][]string, bool) {
+	t.Helper()
+
+	b, err := os.ReadFile(file)
+	require.NoError(t, err)
+
+	bom := []byte{0xEF, 0xBB, 0xBF}
+	hasBOM := bytes.HasPrefix(b, bom)
+
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(b, bom)))
+	r.Comma = comma
+	records, err := r.ReadAll()
+	require.NoError(t, err)
+
+	return records, hasBOM
+}
+
+func TestCSV(t *testing.T) {
+	db := newTestDB(t, 1)
+
+	title := `The "fast", of Ramadan`
+	text := "First line\nSecond, \"quoted\" line"
+	arabic, arabicText := "الصيام في رمضان", "قال الله تعالى: ﴿كتب عليكم الصيام﴾"
+	require.NoError(t, db.Create(&content.Content{
+		URL: "https://islamqa.info/en/answers/2", Site: "islamqa", Language: "en", Kind: content.KindFatwa, QuestionID: 2,
+		Title: &title, Text: &text,
+	}).Error)
+	require.NoError(t, db.Create(&content.Content{
+		URL: "https://islamqa.info/ar/answers/3", Site: "islamqa", Language: "ar", Kind: content.KindFatwa, QuestionID: 3,
+		Title: &arabic, Text: &arabicText,
+	}).Error)
+
+	columns := []string{"question_id", "language", "direction", "title", "text", "references"}
+
+	dir := t.TempDir()
+	count, err := CSV(db, Query{}, dir, CSVOptions{Columns: columns})
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	b, err := os.ReadFile(filepath.Join(dir, "contents.csv"))
+	require.NoError(t, err)
+	assert.Contains(t, string(b), "\"The \"\"fast\"\", of Ramadan\"")
+	assert.Contains(t, string(b), "\r\n")
+
+	records, hasBOM := readCSV(t, filepath.Join(dir, "contents.csv"), ',')
+	assert.False(t, hasBOM)
+	require.Len(t, records, 4)
+	assert.Equal(t, columns, records[0])
+	assert.Equal(t, []string{"1", "en", "ltr", "Question 1", "", "Narrated by Muslim (1)"}, records[1])
+	assert.Equal(t, []string{"2", "en", "ltr", title, text, ""}, records[2])
+	assert.Equal(t, []string{"3", "ar", "rtl", arabic, arabicText, ""}, records[3])
+
+	// tsv with a bom, for Excel
+	dir = t.TempDir()
+	_, err = CSV(db, Query{Language: "ar"}, dir, CSVOptions{Columns: columns, TSV: true, BOM: true})
+	require.NoError(t, err)
+
+	records, hasBOM = readCSV(t, filepath.Join(dir, "contents.tsv"), '\t')
+	assert.True(t, hasBOM)
+	require.Len(t, records, 2)
+	assert.Equal(t, columns, records[0])
+	assert.Equal(t, []string{"3", "ar", "rtl", arabic, arabicText, ""}, records[1])
+
+	_, err = CSV(db, Query{}, t.TempDir(), CSVOptions{Columns: []string{"title", "unknown"}})
+	assert.EqualError(t, err, "unknown column: unknown")
+}
+
+func TestCSVDefaultColumns(t *testing.T) {
+	db := newTestDB(t, 2)
+	dir := t.TempDir()
+
+	count, err := CSV(db, Query{}, dir, CSVOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	records, _ := readCSV(t, filepath.Join(dir, "contents.csv"), ',')
+	require.Len(t, records, 3)
+	assert.Equal(t, DefaultColumns, records[0])
+}
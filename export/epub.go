@@ -0,0 +1,489 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"html"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/hamza72x/islamqa-scrapper/content"
+	"github.com/hamza72x/islamqa-scrapper/sites"
+
+	"gorm.io/gorm"
+)
+
+// maxOpenBooks caps the books written at once, the records are
+// read again for every batch of books
+const maxOpenBooks = 16
+
+// Book is an EPUB file of a site and a language, and of a category
+// when the books are split by category
+type Book struct {
+	File     string
+	Site     string
+	Language string
+
+	// Category is empty for the language books
+	Category string
+	TopicID  uint
+
+	Chapters int
+}
+
+type chapter struct {
+	File  string
+	Title string
+}
+
+type bookWriter struct {
+	book     Book
+	source   string
+	file     *os.File
+	zip      *zip.Writer
+	chapters []chapter
+}
+
+// EPUB writes the records of q to dir as EPUB 3 books, one per site and
+// language as <source>-<site>-<language>.epub, or one per site, language
+// and category as <source>-<site>-<language>-<topic id>.epub when
+// byCategory is set
+// at most maxOpenBooks books are open at once
+func EPUB(db *gorm.DB, q Query, dir string, byCategory bool) ([]Book, error) {
+	if len(q.Source) == 0 {
+		q.Source = SourceContents
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	// the books are listed first, then written by batches
+	listed := map[string]Book{}
+
+	err := Each(db, q, func(r *Record) error {
+		if r.Deleted {
+			return nil
+		}
+		for _, book := range books(q.Source, r, byCategory) {
+			listed[book.File] = book
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	batch := []Book{}
+	for _, book := range listed {
+		batch = append(batch, book)
+	}
+	sort.Slice(batch, func(i, j int) bool {
+		return batch[i].File < batch[j].File
+	})
+
+	result := []Book{}
+
+	for len(batch) > 0 {
+		n := len(batch)
+		if n > maxOpenBooks {
+			n = maxOpenBooks
+		}
+
+		written, err := writeBooks(db, q, dir, byCategory, batch[:n])
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, written...)
+
+		batch = batch[n:]
+	}
+
+	return result, nil
+}
+
+// books are the books of a record, a book per category of it when
+// byCategory is set, the records without a category are in topic 0
+func books(source string, r *Record, byCategory bool) []Book {
+	base := Book{Site: shardName(r.Site), Language: shardName(r.Language)}
+
+	groups := []Book{base}
+
+	if byCategory && len(r.Categories) > 0 {
+		groups = []Book{}
+		for _, c := range r.Categories {
+			book := base
+			book.Category, book.TopicID = c.Name, c.TopicID
+			groups = append(groups, book)
+		}
+	}
+
+	for i := range groups {
+		name := source + "-" + groups[i].Site + "-" + groups[i].Language
+		if byCategory {
+			name += "-" + strconv.FormatUint(uint64(groups[i].TopicID), 10)
+		}
+		groups[i].File = name + ".epub"
+	}
+
+	return groups
+}
+
+// writeBooks writes the chapters of the books of batch, the chapters
+// of the other books are skipped
+func writeBooks(db *gorm.DB, q Query, dir string, byCategory bool, batch []Book) ([]Book, error) {
+	writers := map[string]*bookWriter{}
+	result := []Book{}
+
+	closeAll := func() {
+		for _, w := range writers {
+			w.file.Close()
+		}
+	}
+
+	for _, book := range batch {
+		w, err := newBookWriter(dir, q.Source, book)
+		if err != nil {
+			closeAll()
+			return nil, err
+		}
+		writers[book.File] = w
+	}
+
+	err := Each(db, q, func(r *Record) error {
+		if r.Deleted {
+			return nil
+		}
+
+		for _, book := range books(q.Source, r, byCategory) {
+			w, ok := writers[book.File]
+			if !ok {
+				continue
+			}
+			if err := w.writeChapter(r); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		closeAll()
+		return nil, err
+	}
+
+	for _, book := range batch {
+		w := writers[book.File]
+		if err := w.close(); err != nil {
+			closeAll()
+			return nil, err
+		}
+		result = append(result, w.book)
+	}
+
+	return result, nil
+}
+
+func newBookWriter(dir string, source string, book Book) (*bookWriter, error) {
+	file, err := os.Create(filepath.Join(dir, book.File))
+	if err != nil {
+		return nil, err
+	}
+
+	w := &bookWriter{
+		book:   book,
+		source: source,
+		file:   file,
+		zip:    zip.NewWriter(file),
+	}
+
+	// the mimetype is the first entry, uncompressed
+	mimetype, err := w.zip.CreateHeader(&zip.FileHeader{
+		Name:   "mimetype",
+		Method: zip.Store,
+	})
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	if _, err := mimetype.Write([]byte(epubMimetype)); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return w, nil
+}
+
+func (w *bookWriter) writeChapter(r *Record) error {
+	c := chapter{
+		File:  "chapters/" + strconv.Itoa(len(w.chapters)+1) + ".xhtml",
+		Title: r.Title,
+	}
+	if len(c.Title) == 0 {
+		c.Title = r.URL
+	}
+
+	// the sanitised answer is kept when it's well-formed xml,
+	// the plain text is used otherwise
+	body := r.HTML
+	if len(body) == 0 || wellFormed(body) != nil {
+		body = paragraphs(r.Text)
+	}
+
+	// site relative links don't resolve inside the book
+	if base := sites.BaseURL(r.Site); len(base) > 0 {
+		body = strings.ReplaceAll(body, `href="/`, `href="`+base+`/`)
+	}
+
+	err := w.writeTemplate(c.File, chapterTemplate, map[string]interface{}{
+		"Book":       w.book,
+		"Dir":        content.Direction(w.book.Language),
+		"Title":      c.Title,
+		"Question":   paragraphs(r.Question),
+		"Summary":    paragraphs(r.Summary),
+		"Body":       body,
+		"References": r.References,
+		"URL":        r.URL,
+	})
+	if err != nil {
+		return err
+	}
+
+	w.chapters = append(w.chapters, c)
+	w.book.Chapters++
+
+	return nil
+}
+
+// close writes the cover, the navigation, the package document
+// and the container, then closes the book
+func (w *bookWriter) close() error {
+	// the host of the site names the book, the site name
+	// when it has no adapter
+	host := w.book.Site
+	if s, ok := sites.Get(w.book.Site); ok {
+		host = s.Host()
+	}
+
+	title := host + " (" + w.book.Language + ")"
+	if len(w.book.Category) > 0 {
+		title = w.book.Category + " - " + title
+	}
+
+	identifier := "urn:" + w.book.Site + ":" + w.source + ":" + w.book.Language
+	if w.book.TopicID > 0 {
+		identifier += ":" + strconv.FormatUint(uint64(w.book.TopicID), 10)
+	}
+
+	data := map[string]interface{}{
+		"Book":       w.book,
+		"Title":      title,
+		"Host":       host,
+		"BaseURL":    sites.BaseURL(w.book.Site),
+		"Identifier": identifier,
+		"Dir":        content.Direction(w.book.Language),
+		"Modified":   time.Now().UTC().Format("2006-01-02T15:04:05Z"),
+		"Chapters":   w.chapters,
+	}
+
+	files := []struct {
+		name     string
+		template *template.Template
+	}{
+		{"cover.xhtml", coverTemplate},
+		{"nav.xhtml", navTemplate},
+		{"content.opf", packageTemplate},
+		{"META-INF/container.xml", containerTemplate},
+	}
+
+	for _, f := range files {
+		if err := w.writeTemplate(f.name, f.template, data); err != nil {
+			return err
+		}
+	}
+
+	if err := w.zip.Close(); err != nil {
+		return err
+	}
+
+	return w.file.Close()
+}
+
+func (w *bookWriter) writeTemplate(name string, t *template.Template, data interface{}) error {
+	var b bytes.Buffer
+	if err := t.Execute(&b, data); err != nil {
+		return err
+	}
+
+	f, err := w.zip.Create(name)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(b.Bytes())
+	return err
+}
+
+// wellFormed checks that s is well-formed xml, an xhtml fragment
+// or document
+func wellFormed(s string) error {
+	d := xml.NewDecoder(strings.NewReader("<root>" + s + "</root>"))
+	d.Strict = true
+
+	for {
+		_, err := d.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// paragraphs escapes a plain text into xhtml paragraphs, a paragraph per line
+func paragraphs(text string) string {
+	var b strings.Builder
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		b.WriteString("<p>" + html.EscapeString(line) + "</p>\n")
+	}
+	return b.String()
+}
+
+const epubMimetype = "application/epub+zip"
+
+var epubFuncs = template.FuncMap{
+	"escape": html.EscapeString,
+	"inc":    func(i int) int { return i + 1 },
+	"rtl":    func(dir string) bool { return dir == content.DirectionRTL },
+}
+
+var containerTemplate = template.Must(template.New("container").Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>
+`))
+
+var packageTemplate = template.Must(template.New("package").Funcs(epubFuncs).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="id" xml:lang="{{.Book.Language}}" dir="{{.Dir}}">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="id">{{escape .Identifier}}</dc:identifier>
+    <dc:title>{{escape .Title}}</dc:title>
+    <dc:language>{{escape .Book.Language}}</dc:language>
+    <dc:publisher>{{escape .Host}}</dc:publisher>
+    {{- if .BaseURL}}
+    <dc:source>{{escape .BaseURL}}/{{escape .Book.Language}}</dc:source>
+    {{- end}}
+    {{- if .Book.Category}}
+    <dc:subject>{{escape .Book.Category}}</dc:subject>
+    {{- end}}
+    <meta property="dcterms:modified">{{.Modified}}</meta>
+  </metadata>
+  <manifest>
+    <item id="cover" href="cover.xhtml" media-type="application/xhtml+xml"/>
+    <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+    {{- range $i, $c := .Chapters}}
+    <item id="c{{inc $i}}" href="{{$c.File}}" media-type="application/xhtml+xml"/>
+    {{- end}}
+  </manifest>
+  <spine{{if rtl .Dir}} page-progression-direction="rtl"{{end}}>
+    <itemref idref="cover"/>
+    <itemref idref="nav"/>
+    {{- range $i, $c := .Chapters}}
+    <itemref idref="c{{inc $i}}"/>
+    {{- end}}
+  </spine>
+</package>
+`))
+
+var coverTemplate = template.Must(template.New("cover").Funcs(epubFuncs).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" xml:lang="{{.Book.Language}}" lang="{{.Book.Language}}" dir="{{.Dir}}">
+<head>
+  <meta charset="UTF-8"/>
+  <title>{{escape .Title}}</title>
+</head>
+<body epub:type="cover">
+  <section>
+    <h1>{{escape .Title}}</h1>
+    <p>{{escape .Host}}</p>
+  </section>
+</body>
+</html>
+`))
+
+var navTemplate = template.Must(template.New("nav").Funcs(epubFuncs).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" xml:lang="{{.Book.Language}}" lang="{{.Book.Language}}" dir="{{.Dir}}">
+<head>
+  <meta charset="UTF-8"/>
+  <title>{{escape .Title}}</title>
+</head>
+<body>
+  <nav epub:type="toc" id="toc">
+    <h1>{{escape .Title}}</h1>
+    <ol>
+      {{- range .Chapters}}
+      <li><a href="{{.File}}">{{escape .Title}}</a></li>
+      {{- end}}
+    </ol>
+  </nav>
+  <nav epub:type="landmarks" hidden="hidden">
+    <ol>
+      <li><a epub:type="cover" href="cover.xhtml">{{escape .Title}}</a></li>
+      <li><a epub:type="toc" href="#toc">{{escape .Title}}</a></li>
+    </ol>
+  </nav>
+</body>
+</html>
+`))
+
+var chapterTemplate = template.Must(template.New("chapter").Funcs(epubFuncs).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" xml:lang="{{.Book.Language}}" lang="{{.Book.Language}}" dir="{{.Dir}}">
+<head>
+  <meta charset="UTF-8"/>
+  <title>{{escape .Title}}</title>
+</head>
+<body>
+  <section>
+    <h1>{{escape .Title}}</h1>
+    {{- if .Summary}}
+    <section>
+{{.Summary}}    </section>
+    {{- end}}
+    {{- if .Question}}
+    <section>
+{{.Question}}    </section>
+    {{- end}}
+    <section>
+{{.Body}}
+    </section>
+    {{- if .References}}
+    <section epub:type="endnotes" role="doc-endnotes">
+      <ol>
+        {{- range .References}}
+        <li>{{if .Marker}}[{{escape .Marker}}] {{end}}{{escape .Text}}</li>
+        {{- end}}
+      </ol>
+    </section>
+    {{- end}}
+    <p><a href="{{escape .URL}}">{{escape .URL}}</a></p>
+  </section>
+</body>
+</html>
+`))
@@ -0,0 +1,107 @@
+package export
+
+import (
+	"archive/zip"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/hamza72x/islamqa-scrapper/content"
+	"github.com/hamza72x/islamqa-scrapper/migrations"
+	_ "github.com/hamza72x/islamqa-scrapper/sites/islamqa"
+	"github.com/hamza72x/islamqa-scrapper/storage/storagetest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// newTestDB is a database of n contents of islamqa, each of its
+// own category, linking to itself
+func newTestDB(t *testing.T, n int) *gorm.DB {
+	t.Helper()
+
+	db := storagetest.Open(t)
+	_, err := migrations.Up(db)
+	require.NoError(t, err)
+
+	for i := 1; i <= n; i++ {
+		id := strconv.Itoa(i)
+		title, html := "Question "+id, `<p>See <a href="/en/answers/`+id+`">the answer</a>.</p>`
+
+		c := &content.Content{
+			URL: "https://islamqa.info/en/answers/" + id, Site: "islamqa", Language: "en", Kind: content.KindFatwa, QuestionID: uint(i),
+			Title: &title, HTML: &html,
+			Categories: []content.Category{{TopicID: uint(i), Name: "Topic " + id}},
+			References: []content.Reference{{Position: 1, Marker: "1", Text: "Narrated by Muslim (1)"}},
+		}
+		require.NoError(t, db.Create(c).Error)
+	}
+
+	return db
+}
+
+func TestEPUB(t *testing.T) {
+	db := newTestDB(t, 2)
+	dir := t.TempDir()
+
+	books, err := EPUB(db, Query{}, dir, false)
+	require.NoError(t, err)
+	require.Len(t, books, 1)
+
+	book := books[0]
+	assert.Equal(t, Book{File: "contents-islamqa-en.epub", Site: "islamqa", Language: "en", Chapters: 2}, book)
+	require.NoError(t, validateEPUB(filepath.Join(dir, book.File)))
+
+	opf := readEPUBFile(t, filepath.Join(dir, book.File), "content.opf")
+	assert.Contains(t, opf, "<dc:title>islamqa.info (en)</dc:title>")
+	assert.Contains(t, opf, "<dc:publisher>islamqa.info</dc:publisher>")
+	assert.Contains(t, opf, "<dc:source>https://islamqa.info/en</dc:source>")
+	assert.Contains(t, opf, ">urn:islamqa:contents:en<")
+
+	chapter := readEPUBFile(t, filepath.Join(dir, book.File), "chapters/1.xhtml")
+	assert.Contains(t, chapter, `href="https://islamqa.info/en/answers/1"`)
+	assert.Contains(t, chapter, "<li>[1] Narrated by Muslim (1)</li>")
+}
+
+// TestEPUBByCategory writes more books than maxOpenBooks
+func TestEPUBByCategory(t *testing.T) {
+	db := newTestDB(t, maxOpenBooks+2)
+	dir := t.TempDir()
+
+	books, err := EPUB(db, Query{}, dir, true)
+	require.NoError(t, err)
+	require.Len(t, books, maxOpenBooks+2)
+
+	for _, book := range books {
+		assert.Equal(t, 1, book.Chapters, book.File)
+		assert.Equal(t, "contents-islamqa-en-"+strconv.FormatUint(uint64(book.TopicID), 10)+".epub", book.File)
+		assert.NoError(t, validateEPUB(filepath.Join(dir, book.File)), book.File)
+	}
+}
+
+func readEPUBFile(t *testing.T, file string, name string) string {
+	t.Helper()
+
+	r, err := zip.OpenReader(file)
+	require.NoError(t, err)
+	defer r.Close()
+
+	for _, f := range r.File {
+		if f.Name == name {
+			b, err := readZipFile(f)
+			require.NoError(t, err)
+			return string(b)
+		}
+	}
+
+	t.Fatal("missing " + name)
+	return ""
+}
+
+func TestWellFormed(t *testing.T) {
+	assert.NoError(t, wellFormed("<p>a</p><p>b<br/></p>"))
+	assert.Error(t, wellFormed("<p>a<br></p>"))
+	assert.Error(t, wellFormed(strings.Repeat("<p>", 2)))
+}
@@ -0,0 +1,185 @@
+package export
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"io"
+	"path"
+	"strings"
+)
+
+// validateEPUB checks the structure of an EPUB 3 file: the mimetype entry,
+// the container, the package metadata, manifest and spine, the navigation
+// document, and that every xhtml document is well-formed
+func validateEPUB(file string) error {
+	r, err := zip.OpenReader(file)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	if len(r.File) == 0 || r.File[0].Name != "mimetype" {
+		return errors.New("mimetype is not the first entry")
+	}
+	if r.File[0].Method != zip.Store {
+		return errors.New("mimetype is compressed")
+	}
+
+	files := map[string]*zip.File{}
+	for _, f := range r.File {
+		files[f.Name] = f
+	}
+
+	mimetype, err := readZipFile(r.File[0])
+	if err != nil {
+		return err
+	}
+	if string(mimetype) != epubMimetype {
+		return errors.New("invalid mimetype: " + string(mimetype))
+	}
+
+	var container struct {
+		Rootfiles []struct {
+			FullPath  string `xml:"full-path,attr"`
+			MediaType string `xml:"media-type,attr"`
+		} `xml:"rootfiles>rootfile"`
+	}
+	if err := unmarshalZipFile(files, "META-INF/container.xml", &container); err != nil {
+		return err
+	}
+	if len(container.Rootfiles) == 0 {
+		return errors.New("container has no rootfile")
+	}
+
+	opf := container.Rootfiles[0].FullPath
+
+	var pkg struct {
+		Version          string `xml:"version,attr"`
+		UniqueIdentifier string `xml:"unique-identifier,attr"`
+		Metadata         struct {
+			Identifiers []struct {
+				ID    string `xml:"id,attr"`
+				Value string `xml:",chardata"`
+			} `xml:"identifier"`
+			Titles    []string `xml:"title"`
+			Languages []string `xml:"language"`
+			Metas     []struct {
+				Property string `xml:"property,attr"`
+				Value    string `xml:",chardata"`
+			} `xml:"meta"`
+		} `xml:"metadata"`
+		Items []struct {
+			ID         string `xml:"id,attr"`
+			Href       string `xml:"href,attr"`
+			MediaType  string `xml:"media-type,attr"`
+			Properties string `xml:"properties,attr"`
+		} `xml:"manifest>item"`
+		Itemrefs []struct {
+			IDRef string `xml:"idref,attr"`
+		} `xml:"spine>itemref"`
+	}
+	if err := unmarshalZipFile(files, opf, &pkg); err != nil {
+		return err
+	}
+
+	if pkg.Version != "3.0" {
+		return errors.New("package version is not 3.0")
+	}
+
+	identified := false
+	for _, id := range pkg.Metadata.Identifiers {
+		if id.ID == pkg.UniqueIdentifier && len(strings.TrimSpace(id.Value)) > 0 {
+			identified = true
+		}
+	}
+	if !identified {
+		return errors.New("package has no unique identifier")
+	}
+
+	if len(pkg.Metadata.Titles) == 0 || len(pkg.Metadata.Languages) == 0 {
+		return errors.New("package has no title or language")
+	}
+
+	modified := false
+	for _, meta := range pkg.Metadata.Metas {
+		if meta.Property == "dcterms:modified" {
+			modified = true
+		}
+	}
+	if !modified {
+		return errors.New("package has no dcterms:modified")
+	}
+
+	// manifest hrefs are relative to the package document
+	dir := path.Dir(opf)
+	items := map[string]bool{}
+	navs := 0
+
+	for _, item := range pkg.Items {
+		if items[item.ID] {
+			return errors.New("duplicate manifest item: " + item.ID)
+		}
+		items[item.ID] = true
+
+		name := path.Join(dir, item.Href)
+		if _, ok := files[name]; !ok {
+			return errors.New("missing manifest item: " + name)
+		}
+
+		if strings.Contains(" "+item.Properties+" ", " nav ") {
+			navs++
+		}
+
+		if item.MediaType != "application/xhtml+xml" {
+			continue
+		}
+
+		b, err := readZipFile(files[name])
+		if err != nil {
+			return err
+		}
+		if err := wellFormed(string(b)); err != nil {
+			return errors.New(name + ": " + err.Error())
+		}
+	}
+
+	if navs != 1 {
+		return errors.New("package needs exactly one nav document")
+	}
+
+	if len(pkg.Itemrefs) == 0 {
+		return errors.New("spine is empty")
+	}
+	for _, ref := range pkg.Itemrefs {
+		if !items[ref.IDRef] {
+			return errors.New("spine item not in manifest: " + ref.IDRef)
+		}
+	}
+
+	return nil
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	return io.ReadAll(rc)
+}
+
+func unmarshalZipFile(files map[string]*zip.File, name string, v interface{}) error {
+	f, ok := files[name]
+	if !ok {
+		return errors.New("missing " + name)
+	}
+
+	b, err := readZipFile(f)
+	if err != nil {
+		return err
+	}
+
+	return xml.Unmarshal(b, v)
+}
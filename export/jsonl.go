@@ -0,0 +1,177 @@
+package export
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Manifest lists the shards of an export
+type Manifest struct {
+	CreatedAt time.Time `json:"created_at"`
+	Source    string    `json:"source"`
+
+	// UpdatedSince is set for delta exports, the shards have the rows
+	// created, updated or deleted since
+	UpdatedSince *time.Time `json:"updated_since,omitempty"`
+
+	Shards []Shard `json:"shards"`
+}
+
+// Shard is a gzip compressed JSON Lines file of a language and kind
+type Shard struct {
+	File     string `json:"file"`
+	Language string `json:"language"`
+	Kind     string `json:"kind"`
+	Count    int    `json:"count"`
+	Bytes    int64  `json:"bytes"`
+
+	// SHA256 is the hex checksum of the compressed file
+	SHA256 string `json:"sha256"`
+}
+
+type shardWriter struct {
+	shard   Shard
+	file    *os.File
+	hash    hash.Hash
+	counter *countingWriter
+	gzip    *gzip.Writer
+	encoder *json.Encoder
+}
+
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
+
+// JSONL writes the records of q to dir as <source>-<language>-<kind>.jsonl.gz
+// shards, and a manifest.json with their counts and checksums
+func JSONL(db *gorm.DB, q Query, dir string) (*Manifest, error) {
+	if len(q.Source) == 0 {
+		q.Source = SourceContents
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	shards := map[string]*shardWriter{}
+
+	closeAll := func() {
+		for _, w := range shards {
+			w.gzip.Close()
+			w.file.Close()
+		}
+	}
+
+	err := Each(db, q, func(r *Record) error {
+		key := shardName(r.Language) + "-" + shardName(r.Kind)
+
+		w, ok := shards[key]
+		if !ok {
+			var err error
+			if w, err = newShardWriter(dir, q.Source+"-"+key+".jsonl.gz", r); err != nil {
+				return err
+			}
+			shards[key] = w
+		}
+
+		w.shard.Count++
+
+		return w.encoder.Encode(r)
+	})
+	if err != nil {
+		closeAll()
+		return nil, err
+	}
+
+	manifest := &Manifest{
+		CreatedAt: time.Now().UTC(),
+		Source:    q.Source,
+		Shards:    []Shard{},
+	}
+
+	if !q.UpdatedSince.IsZero() {
+		manifest.UpdatedSince = &q.UpdatedSince
+	}
+
+	for _, w := range shards {
+		if err := w.gzip.Close(); err != nil {
+			closeAll()
+			return nil, err
+		}
+		if err := w.file.Close(); err != nil {
+			closeAll()
+			return nil, err
+		}
+
+		w.shard.SHA256 = hex.EncodeToString(w.hash.Sum(nil))
+		w.shard.Bytes = w.counter.n
+
+		manifest.Shards = append(manifest.Shards, w.shard)
+	}
+
+	sort.Slice(manifest.Shards, func(i, j int) bool {
+		return manifest.Shards[i].File < manifest.Shards[j].File
+	})
+
+	if err := writeManifest(filepath.Join(dir, "manifest.json"), manifest); err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+func newShardWriter(dir string, name string, r *Record) (*shardWriter, error) {
+	file, err := os.Create(filepath.Join(dir, name))
+	if err != nil {
+		return nil, err
+	}
+
+	w := &shardWriter{
+		shard: Shard{
+			File:     name,
+			Language: r.Language,
+			Kind:     r.Kind,
+		},
+		file:    file,
+		hash:    sha256.New(),
+		counter: &countingWriter{},
+	}
+
+	w.gzip = gzip.NewWriter(io.MultiWriter(file, w.hash, w.counter))
+	w.encoder = json.NewEncoder(w.gzip)
+	w.encoder.SetEscapeHTML(false)
+
+	return w, nil
+}
+
+func writeManifest(path string, manifest *Manifest) error {
+	b, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(b, '\n'), 0644)
+}
+
+// shardName is "unknown" for a missing language or kind
+func shardName(s string) string {
+	if len(s) == 0 {
+		return "unknown"
+	}
+	return s
+}
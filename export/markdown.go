@@ -0,0 +1,233 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hamza72x/islamqa-scrapper/sites"
+
+	"gorm.io/gorm"
+)
+
+// markdownLinkRegex matches the markdown links, the ones to the contents
+// of the sites are wiki-links, see sites.Link, the text may have escaped
+// brackets and the url balanced parentheses
+var markdownLinkRegex = regexp.MustCompile(`\[((?:[^\[\]\\]|\\.)*)\]\(((?:[^()\s]|\([^()\s]*\))+)\)`)
+
+// wikiLinkTextReplacer drops the brackets and pipes, escaped or not,
+// that would end the wiki-links
+var wikiLinkTextReplacer = strings.NewReplacer(`\[`, "", `\]`, "", `\|`, "", "[", "", "]", "", "|", "")
+
+// VaultStats counts the files of a markdown export
+type VaultStats struct {
+	Written   int
+	Unchanged int
+	Deleted   int
+}
+
+// Markdown writes the records of q to dir as a vault of markdown files,
+// <site>/<language>/<kind>/<question id>.md with YAML front matter, the
+// links to other answers are wiki-links
+// a file is written only when its content changed, re-exports are incremental,
+// the files of tombstones are removed
+func Markdown(db *gorm.DB, q Query, dir string) (*VaultStats, error) {
+	if len(q.Source) == 0 {
+		q.Source = SourceContents
+	}
+
+	stats := &VaultStats{}
+
+	err := Each(db, q, func(r *Record) error {
+		file := filepath.Join(dir, filepath.FromSlash(notePath(r.Site, r.Language, r.Kind, r.QuestionID, r.ID)+".md"))
+
+		if r.Deleted {
+			if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			stats.Deleted++
+			return nil
+		}
+
+		b, err := note(r)
+		if err != nil {
+			return err
+		}
+
+		if existing, err := os.ReadFile(file); err == nil && bytes.Equal(existing, b) {
+			stats.Unchanged++
+			return nil
+		}
+
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			return err
+		}
+
+		if err := os.WriteFile(file, b, 0644); err != nil {
+			return err
+		}
+
+		stats.Written++
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// note is the markdown file of a record
+/*
+	---
+	id: 1
+	url: "https://islamqa.info/en/answers/1/..."
+	title: "..."
+	language: "en"
+	kind: "fatwa"
+	direction: "ltr"
+	categories: ["Fasting"]
+	hash: "..."
+	author: "..."
+	last_modified: 2023-01-01T00:00:00Z
+	published: 2000-03-21T00:00:00Z
+	---
+*/
+func note(r *Record) ([]byte, error) {
+	categories := []string{}
+	for _, c := range r.Categories {
+		categories = append(categories, c.Name)
+	}
+
+	type field struct {
+		name  string
+		value interface{}
+	}
+
+	// json strings and arrays are valid yaml flow scalars and sequences
+	fields := []field{
+		{"url", r.URL},
+		{"title", r.Title},
+		{"language", r.Language},
+		{"kind", r.Kind},
+		{"direction", r.Direction},
+		{"categories", categories},
+		{"hash", r.Hash},
+	}
+
+	// the metadata the page has, the views are left out,
+	// they'd change the notes on every export
+	if len(r.Author) > 0 {
+		fields = append(fields, field{"author", r.Author})
+	}
+
+	var b bytes.Buffer
+
+	b.WriteString("---\n")
+	b.WriteString("id: " + strconv.FormatUint(uint64(r.QuestionID), 10) + "\n")
+
+	for _, f := range fields {
+		value, err := marshalYAML(f.value)
+		if err != nil {
+			return nil, err
+		}
+		b.WriteString(f.name + ": " + value + "\n")
+	}
+
+	b.WriteString("last_modified: " + r.LastModified.UTC().Format(time.RFC3339) + "\n")
+	if r.Published != nil {
+		b.WriteString("published: " + r.Published.UTC().Format(time.RFC3339) + "\n")
+	}
+	if r.Modified != nil {
+		b.WriteString("modified: " + r.Modified.UTC().Format(time.RFC3339) + "\n")
+	}
+	b.WriteString("---\n\n")
+
+	if len(r.Title) > 0 {
+		b.WriteString("# " + r.Title + "\n\n")
+	}
+
+	body := r.Markdown
+	if len(body) == 0 {
+		body = r.Text
+	}
+
+	// the question is quoted above the answer, it's the body of the light crawls
+	if len(body) == 0 {
+		body = r.Question
+	} else if len(r.Question) > 0 {
+		b.WriteString(quote(r.Question) + "\n\n")
+	}
+
+	body = markdownLinkRegex.ReplaceAllStringFunc(body, func(link string) string {
+		m := markdownLinkRegex.FindStringSubmatch(link)
+
+		site, u, ok := sites.Link(r.Site, m[2])
+		if !ok {
+			return link
+		}
+		target := notePath(site.Name(), u.Language, u.Kind, u.QuestionID, 0)
+
+		text := wikiLinkTextReplacer.Replace(m[1])
+		if len(text) == 0 {
+			return "[[" + target + "]]"
+		}
+
+		return "[[" + target + "|" + text + "]]"
+	})
+
+	b.WriteString(strings.TrimSpace(body) + "\n")
+
+	// the references are a list after the answer, by their markers
+	if len(r.References) > 0 {
+		b.WriteString("\n## References\n\n")
+		for _, ref := range r.References {
+			b.WriteString(strconv.Itoa(ref.Position) + ". ")
+			if len(ref.Marker) > 0 {
+				b.WriteString("[" + ref.Marker + "] ")
+			}
+			b.WriteString(ref.Text + "\n")
+		}
+	}
+
+	return b.Bytes(), nil
+}
+
+// notePath is <site>/<language>/<kind>/<question id>, the row id
+// is used when the url has no question id
+func notePath(site string, language string, kind string, questionID uint, id uint) string {
+	name := strconv.FormatUint(uint64(questionID), 10)
+	if questionID == 0 {
+		name = "c" + strconv.FormatUint(uint64(id), 10)
+	}
+
+	return shardName(site) + "/" + shardName(language) + "/" + shardName(kind) + "/" + name
+}
+
+func marshalYAML(v interface{}) (string, error) {
+	var b bytes.Buffer
+
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+
+	if err := encoder.Encode(v); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(b.String()), nil
+}
+
+// quote is a markdown blockquote of a text
+func quote(text string) string {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace("> " + line)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,40 @@
+package export
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNoteLinks rewrites the links to the contents of the site as wiki-links
+func TestNoteLinks(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		want     string
+	}{
+		{"link", "See [fasting](/en/answers/1/fasting).", "See [[islamqa/en/fatwa/1|fasting]]."},
+		{"absolute", "[ramadan](https://islamqa.info/ar/articles/2/title)", "[[islamqa/ar/article/2|ramadan]]"},
+		{"no text", "[](/en/answers/1)", "[[islamqa/en/fatwa/1]]"},
+		{"escaped brackets", `See [the \[first\] answer](/en/answers/1).`, "See [[islamqa/en/fatwa/1|the first answer]]."},
+		{"pipe", `[a | b](/en/answers/1)`, "[[islamqa/en/fatwa/1|a  b]]"},
+		{"parentheses", "[fasting](/en/answers/1/fasting-(sawm)) (1)", "[[islamqa/en/fatwa/1|fasting]] (1)"},
+		{"other site", "[example](https://example.com/en/answers/1_(x))", "[example](https://example.com/en/answers/1_(x))"},
+		{"escaped link", `\[not a link\](/en/answers/1)`, `\[not a link\](/en/answers/1)`},
+		{"two links", "[a](/en/answers/1) and [b](/en/answers/2)", "[[islamqa/en/fatwa/1|a]] and [[islamqa/en/fatwa/2|b]]"},
+		{"category", "[fasting](/en/categories/topics/52)", "[fasting](/en/categories/topics/52)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := note(&Record{Site: "islamqa", Markdown: tt.markdown})
+			require.NoError(t, err)
+
+			_, body, ok := strings.Cut(string(b), "---\n\n")
+			require.True(t, ok)
+			assert.Equal(t, tt.want+"\n", body)
+		})
+	}
+}
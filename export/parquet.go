@@ -0,0 +1,138 @@
+package export
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/writer"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultRowGroupSize is the in-memory size of a row group,
+	// rows are buffered until then, it bounds the memory in use
+	DefaultRowGroupSize = 64 * 1024 * 1024
+
+	// parquet writer goroutines
+	parquetParallel = 4
+)
+
+// parquetRow is the typed schema of the parquet export
+type parquetRow struct {
+	ID           int64    `parquet:"name=id, type=INT64"`
+	Site         string   `parquet:"name=site, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	QuestionID   int64    `parquet:"name=question_id, type=INT64"`
+	URL          string   `parquet:"name=url, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Language     string   `parquet:"name=language, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	Kind         string   `parquet:"name=kind, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	Title        string   `parquet:"name=title, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Question     string   `parquet:"name=question, type=BYTE_ARRAY, convertedtype=UTF8"`
+	AnswerText   string   `parquet:"name=answer_text, type=BYTE_ARRAY, convertedtype=UTF8"`
+	LastModified int64    `parquet:"name=last_modified, type=INT64, convertedtype=TIMESTAMP_MILLIS"`
+	Published    *int64   `parquet:"name=published, type=INT64, convertedtype=TIMESTAMP_MILLIS, repetitiontype=OPTIONAL"`
+	Modified     *int64   `parquet:"name=modified, type=INT64, convertedtype=TIMESTAMP_MILLIS, repetitiontype=OPTIONAL"`
+	Views        *int64   `parquet:"name=views, type=INT64, repetitiontype=OPTIONAL"`
+	Author       string   `parquet:"name=author, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	Publisher    string   `parquet:"name=publisher, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	Categories   []string `parquet:"name=categories, type=LIST, valuetype=BYTE_ARRAY, valueconvertedtype=UTF8"`
+	References   []string `parquet:"name=references, type=LIST, valuetype=BYTE_ARRAY, valueconvertedtype=UTF8"`
+	Hash         string   `parquet:"name=hash, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Deleted      bool     `parquet:"name=deleted, type=BOOLEAN"`
+	DeletedAt    *int64   `parquet:"name=deleted_at, type=INT64, convertedtype=TIMESTAMP_MILLIS, repetitiontype=OPTIONAL"`
+}
+
+// Parquet writes the records of q to dir as <source>.parquet,
+// snappy compressed, in row groups of rowGroupSize bytes
+// returns the number of rows written
+func Parquet(db *gorm.DB, q Query, dir string, rowGroupSize int64) (int, error) {
+	if len(q.Source) == 0 {
+		q.Source = SourceContents
+	}
+
+	if rowGroupSize <= 0 {
+		rowGroupSize = DefaultRowGroupSize
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return 0, err
+	}
+
+	file, err := os.Create(filepath.Join(dir, q.Source+".parquet"))
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	pw, err := writer.NewParquetWriterFromWriter(file, new(parquetRow), parquetParallel)
+	if err != nil {
+		return 0, err
+	}
+
+	pw.RowGroupSize = rowGroupSize
+	pw.CompressionType = parquet.CompressionCodec_SNAPPY
+
+	count := 0
+
+	err = Each(db, q, func(r *Record) error {
+		row := &parquetRow{
+			ID:           int64(r.ID),
+			Site:         r.Site,
+			QuestionID:   int64(r.QuestionID),
+			URL:          r.URL,
+			Language:     r.Language,
+			Kind:         r.Kind,
+			Title:        r.Title,
+			Question:     r.Question,
+			AnswerText:   r.Text,
+			LastModified: r.LastModified.UnixMilli(),
+			Published:    unixMilli(r.Published),
+			Modified:     unixMilli(r.Modified),
+			Views:        r.Views,
+			Author:       r.Author,
+			Publisher:    r.Publisher,
+			Categories:   []string{},
+			References:   []string{},
+			Hash:         r.Hash,
+			Deleted:      r.Deleted,
+			DeletedAt:    unixMilli(r.DeletedAt),
+		}
+
+		// a tombstone was last modified by its deletion
+		if r.DeletedAt != nil {
+			row.LastModified = r.DeletedAt.UnixMilli()
+		}
+
+		for _, c := range r.Categories {
+			row.Categories = append(row.Categories, c.Name)
+		}
+
+		for _, ref := range r.References {
+			row.References = append(row.References, ref.Text)
+		}
+
+		count++
+
+		return pw.Write(row)
+	})
+	if err != nil {
+		pw.WriteStop()
+		return 0, err
+	}
+
+	if err := pw.WriteStop(); err != nil {
+		return 0, err
+	}
+
+	return count, file.Close()
+}
+
+func unixMilli(t *time.Time) *int64 {
+	if t == nil {
+		return nil
+	}
+	ms := t.UnixMilli()
+	return &ms
+}
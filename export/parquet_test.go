@@ -0,0 +1,98 @@
+package export
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hamza72x/islamqa-scrapper/content"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xitongsys/parquet-go-source/local"
+	"github.com/xitongsys/parquet-go/reader"
+)
+
+// readParquet reads back the rows and the row group sizes of a file
+func readParquet(t *testing.T, file string) ([]parquetRow, []int64) {
+	t.Helper()
+
+	pf, err := local.NewLocalFileReader(file)
+	require.NoError(t, err)
+	defer pf.Close()
+
+	pr, err := reader.NewParquetReader(pf, new(parquetRow), 1)
+	require.NoError(t, err)
+	defer pr.ReadStop()
+
+	rows := make([]parquetRow, pr.GetNumRows())
+	require.NoError(t, pr.Read(&rows))
+
+	groups := []int64{}
+	for _, rg := range pr.Footer.RowGroups {
+		groups = append(groups, rg.NumRows)
+	}
+
+	return rows, groups
+}
+
+func TestParquet(t *testing.T) {
+	db := newTestDB(t, 20)
+	dir := t.TempDir()
+
+	// the rows are buffered by pages of every column before a row group
+	// is flushed, 20 answers of 64 KiB make more than a row group of 256 KiB
+	text := strings.Repeat("a", 64*1024)
+	require.NoError(t, db.Model(&content.Content{}).Where("id > 0").Update("text", text).Error)
+
+	count, err := Parquet(db, Query{}, dir, 256*1024)
+	require.NoError(t, err)
+	assert.Equal(t, 20, count)
+
+	rows, groups := readParquet(t, filepath.Join(dir, "contents.parquet"))
+	require.Len(t, rows, 20)
+	assert.Greater(t, len(groups), 1)
+
+	total := int64(0)
+	for _, n := range groups {
+		assert.Less(t, n, int64(20))
+		total += n
+	}
+	assert.Equal(t, int64(20), total)
+
+	row := rows[0]
+	assert.Equal(t, int64(1), row.QuestionID)
+	assert.Equal(t, "islamqa", row.Site)
+	assert.Equal(t, "Question 1", row.Title)
+	assert.Equal(t, text, row.AnswerText)
+	assert.Equal(t, []string{"Topic 1"}, row.Categories)
+	assert.Equal(t, []string{"Narrated by Muslim (1)"}, row.References)
+	assert.False(t, row.Deleted)
+	assert.Nil(t, row.DeletedAt)
+}
+
+// TestParquetTombstone writes a deleted content of a delta
+// with its deletion time
+func TestParquetTombstone(t *testing.T) {
+	db := newTestDB(t, 2)
+	dir := t.TempDir()
+
+	since := time.Now().UTC()
+	time.Sleep(10 * time.Millisecond)
+	require.NoError(t, db.Where("question_id = ?", 1).Delete(&content.Content{}).Error)
+
+	count, err := Parquet(db, Query{UpdatedSince: since}, dir, DefaultRowGroupSize)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	rows, _ := readParquet(t, filepath.Join(dir, "contents.parquet"))
+	require.Len(t, rows, 1)
+
+	row := rows[0]
+	assert.Equal(t, int64(1), row.QuestionID)
+	assert.True(t, row.Deleted)
+	require.NotNil(t, row.DeletedAt)
+	assert.GreaterOrEqual(t, *row.DeletedAt, since.UnixMilli())
+	assert.Equal(t, *row.DeletedAt, row.LastModified)
+}
@@ -0,0 +1,310 @@
+package export
+
+import (
+	"errors"
+	"time"
+
+	"github.com/hamza72x/islamqa-scrapper/content"
+	"github.com/hamza72x/islamqa-scrapper/sitemap"
+
+	"gorm.io/gorm"
+)
+
+const (
+	SourceContents = "contents"
+
+	SortID        = "id"
+	SortPublished = "published"
+	SortViews     = "views"
+
+	// rows are read from the database in batches of
+	batchSize = 500
+)
+
+// Record is an exported content,
+// joined with its sitemap url
+type Record struct {
+	Source     string `json:"source"`
+	Site       string `json:"site"`
+	ID         uint   `json:"id"`
+	QuestionID uint   `json:"question_id"`
+	URL        string `json:"url"`
+	Language   string `json:"language"`
+	Kind       string `json:"kind"`
+	Direction  string `json:"direction"`
+
+	Title string `json:"title"`
+
+	// Question is the question body, or the seo description of an article
+	Question string `json:"question,omitempty"`
+
+	// Summary and the answer formats are of the full crawls only
+	Summary  string `json:"summary,omitempty"`
+	HTML     string `json:"html,omitempty"`
+	Markdown string `json:"markdown,omitempty"`
+	Text     string `json:"text,omitempty"`
+
+	LastModified time.Time `json:"last_modified"`
+
+	// Published, Modified, Views, Author and Publisher are the
+	// metadata of the page, of the full crawls only
+	Published *time.Time `json:"published,omitempty"`
+	Modified  *time.Time `json:"modified,omitempty"`
+	Views     *int64     `json:"views,omitempty"`
+	Author    string     `json:"author,omitempty"`
+	Publisher string     `json:"publisher,omitempty"`
+
+	// Hash is the content hash, it changes with the content only
+	Hash string `json:"hash,omitempty"`
+
+	// SitemapURL and SitemapLastMod are from sitemap.URL
+	SitemapURL     string     `json:"sitemap_url,omitempty"`
+	SitemapLastMod *time.Time `json:"sitemap_last_mod,omitempty"`
+
+	Categories []Category  `json:"categories,omitempty"`
+	References []Reference `json:"references,omitempty"`
+
+	// Deleted is set for the tombstones of delta exports,
+	// the rows deleted since the checkpoint
+	Deleted   bool       `json:"deleted,omitempty"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+type Category struct {
+	TopicID uint   `json:"topic_id"`
+	Name    string `json:"name"`
+}
+
+type Reference struct {
+	Position     int    `json:"position"`
+	Marker       string `json:"marker,omitempty"`
+	MarkerAnchor string `json:"marker_anchor,omitempty"`
+	Anchor       string `json:"anchor,omitempty"`
+	Text         string `json:"text"`
+}
+
+// Query selects the exported rows, zero values select everything
+type Query struct {
+	// Source is SourceContents, the default, it names the files
+	Source string
+
+	// Site is the name of a site adapter, e.g. "islamqa"
+	Site     string
+	Language string
+	Kind     string
+
+	// ModifiedSince selects the rows last modified at or after it
+	ModifiedSince time.Time
+
+	// UpdatedSince selects the rows created, updated or deleted at or after it,
+	// the deleted rows are tombstones
+	UpdatedSince time.Time
+
+	// PublishedSince and PublishedUntil select the rows published
+	// at or after and before them, by the date of the page
+	PublishedSince time.Time
+	PublishedUntil time.Time
+
+	// MinViews selects the rows viewed at least as many times
+	MinViews int64
+
+	// Author selects the rows of an author
+	Author string
+
+	// Sort is SortID, the default, SortPublished, latest first,
+	// or SortViews, most viewed first, the rows without are last
+	Sort string
+}
+
+// sorts are the orders of Query.Sort
+var sorts = map[string]string{
+	SortPublished: "published_at IS NULL, published_at DESC, id",
+	SortViews:     "views IS NULL, views DESC, id",
+}
+
+// Each streams the records matching q, ordered by q.Sort, the times
+// of q are compared in UTC as the database stamps them
+func Each(db *gorm.DB, q Query, fn func(*Record) error) error {
+	tx := db.Session(&gorm.Session{})
+
+	if len(q.Site) > 0 {
+		tx = tx.Where("site = ?", q.Site)
+	}
+
+	if len(q.Language) > 0 {
+		tx = tx.Where("language = ?", q.Language)
+	}
+
+	if len(q.Kind) > 0 {
+		tx = tx.Where("kind = ?", q.Kind)
+	}
+
+	if !q.ModifiedSince.IsZero() {
+		tx = tx.Where("last_modified >= ?", q.ModifiedSince.UTC())
+	}
+
+	if !q.PublishedSince.IsZero() {
+		tx = tx.Where("published_at >= ?", q.PublishedSince.UTC())
+	}
+
+	if !q.PublishedUntil.IsZero() {
+		tx = tx.Where("published_at < ?", q.PublishedUntil.UTC())
+	}
+
+	if q.MinViews > 0 {
+		tx = tx.Where("views >= ?", q.MinViews)
+	}
+
+	if len(q.Author) > 0 {
+		tx = tx.Where("author = ?", q.Author)
+	}
+
+	if !q.UpdatedSince.IsZero() {
+		tx = tx.Unscoped().Where(
+			db.Where("updated_at >= ?", q.UpdatedSince.UTC()).Or("deleted_at >= ?", q.UpdatedSince.UTC()),
+		)
+	}
+
+	tx = tx.
+		Preload("Categories").
+		Preload("References", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position")
+		})
+
+	order, ok := sorts[q.Sort]
+	if !ok {
+		if len(q.Sort) > 0 && q.Sort != SortID {
+			return errors.New("unknown sort: " + q.Sort)
+		}
+
+		batch := []*content.Content{}
+		return tx.FindInBatches(&batch, batchSize, func(tx *gorm.DB, _ int) error {
+			return emitContents(db, batch, fn)
+		}).Error
+	}
+
+	// FindInBatches pages by id, the other orders are paged by offset
+	for offset := 0; ; offset += batchSize {
+		batch := []*content.Content{}
+		if err := tx.Order(order).Limit(batchSize).Offset(offset).Find(&batch).Error; err != nil {
+			return err
+		}
+
+		if err := emitContents(db, batch, fn); err != nil {
+			return err
+		}
+
+		if len(batch) < batchSize {
+			return nil
+		}
+	}
+}
+
+func emitContents(db *gorm.DB, batch []*content.Content, fn func(*Record) error) error {
+	records := []*Record{}
+	for _, c := range batch {
+		records = append(records, fromContent(c))
+	}
+	return emit(db, records, fn)
+}
+
+// emit joins the records with their sitemap urls and passes them to fn
+func emit(db *gorm.DB, records []*Record, fn func(*Record) error) error {
+	locs := []string{}
+	for _, r := range records {
+		locs = append(locs, r.URL)
+	}
+
+	urls := []*sitemap.URL{}
+	if len(locs) > 0 {
+		if err := db.Where("loc IN ?", locs).Find(&urls).Error; err != nil {
+			return err
+		}
+	}
+
+	byLoc := map[string]*sitemap.URL{}
+	for _, u := range urls {
+		byLoc[u.Loc] = u
+	}
+
+	for _, r := range records {
+		if u, ok := byLoc[r.URL]; ok {
+			lastMod := u.LastMod
+			r.SitemapURL = u.SitemapUrl
+			r.SitemapLastMod = &lastMod
+		}
+
+		if err := fn(r); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func fromContent(c *content.Content) *Record {
+	r := &Record{
+		Source:       SourceContents,
+		Site:         c.Site,
+		ID:           c.ID,
+		QuestionID:   c.QuestionID,
+		URL:          c.URL,
+		Language:     c.Language,
+		Kind:         c.Kind,
+		Direction:    content.Direction(c.Language),
+		Title:        value(c.Title),
+		Question:     value(c.Question),
+		Summary:      value(c.Summary),
+		HTML:         value(c.HTML),
+		Markdown:     value(c.Markdown),
+		Text:         value(c.Text),
+		LastModified: c.LastModified,
+		Published:    c.PublishedAt,
+		Modified:     c.ModifiedAt,
+		Views:        c.Views,
+		Author:       value(c.Author),
+		Publisher:    value(c.Publisher),
+		Hash:         c.ContentHash(),
+	}
+
+	if c.DeletedAt.Valid {
+		deletedAt := c.DeletedAt.Time
+		return &Record{
+			Source:     SourceContents,
+			Site:       c.Site,
+			ID:         c.ID,
+			QuestionID: c.QuestionID,
+			URL:        c.URL,
+			Language:   c.Language,
+			Kind:       c.Kind,
+			Deleted:    true,
+			DeletedAt:  &deletedAt,
+		}
+	}
+
+	for _, category := range c.Categories {
+		r.Categories = append(r.Categories, Category{
+			TopicID: category.TopicID,
+			Name:    category.Name,
+		})
+	}
+
+	for _, ref := range c.References {
+		r.References = append(r.References, Reference{
+			Position:     ref.Position,
+			Marker:       ref.Marker,
+			MarkerAnchor: ref.MarkerAnchor,
+			Anchor:       ref.Anchor,
+			Text:         ref.Text,
+		})
+	}
+
+	return r
+}
+
+func value(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
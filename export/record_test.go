@@ -0,0 +1,136 @@
+package export
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hamza72x/islamqa-scrapper/content"
+	"github.com/hamza72x/islamqa-scrapper/sitemap"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func collect(t *testing.T, db *gorm.DB, q Query) []*Record {
+	t.Helper()
+
+	records := []*Record{}
+	require.NoError(t, Each(db, q, func(r *Record) error {
+		records = append(records, r)
+		return nil
+	}))
+	return records
+}
+
+func questionIDs(records []*Record) []uint {
+	ids := []uint{}
+	for _, r := range records {
+		ids = append(ids, r.QuestionID)
+	}
+	return ids
+}
+
+func TestEach(t *testing.T) {
+	db := newTestDB(t, 3)
+
+	published := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, db.Model(&content.Content{}).Where("question_id = ?", 1).UpdateColumns(map[string]interface{}{
+		"views": 10, "author": "Author",
+	}).Error)
+	require.NoError(t, db.Model(&content.Content{}).Where("question_id = ?", 2).UpdateColumns(map[string]interface{}{
+		"views": 500, "published_at": published,
+	}).Error)
+
+	article := &content.Content{
+		URL: "https://islamqa.info/ar/articles/4", Site: "islamqa", Language: "ar", Kind: content.KindArticle, QuestionID: 4,
+	}
+	require.NoError(t, db.Create(article).Error)
+
+	lastMod := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, db.Create(&sitemap.URL{SitemapUrl: "sitemap.xml", Loc: "https://islamqa.info/en/answers/1", LastMod: lastMod}).Error)
+
+	tests := []struct {
+		name string
+		q    Query
+		want []uint
+	}{
+		{name: "everything", q: Query{}, want: []uint{1, 2, 3, 4}},
+		{name: "language", q: Query{Language: "ar"}, want: []uint{4}},
+		{name: "kind", q: Query{Kind: content.KindFatwa}, want: []uint{1, 2, 3}},
+		{name: "other site", q: Query{Site: "other"}, want: []uint{}},
+		{name: "most viewed", q: Query{Sort: SortViews}, want: []uint{2, 1, 3, 4}},
+		{name: "latest published", q: Query{Sort: SortPublished}, want: []uint{2, 1, 3, 4}},
+		{name: "published since", q: Query{PublishedSince: published}, want: []uint{2}},
+		{name: "min views", q: Query{MinViews: 100}, want: []uint{2}},
+		{name: "author", q: Query{Author: "Author"}, want: []uint{1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, questionIDs(collect(t, db, tt.q)))
+		})
+	}
+
+	found := collect(t, db, Query{Language: "en"})
+	require.Len(t, found, 3)
+
+	r := found[0]
+	assert.Equal(t, "islamqa", r.Site)
+	assert.Equal(t, "ltr", r.Direction)
+	assert.Equal(t, []Category{{TopicID: 1, Name: "Topic 1"}}, r.Categories)
+	assert.Equal(t, []Reference{{Position: 1, Marker: "1", Text: "Narrated by Muslim (1)"}}, r.References)
+	assert.Equal(t, "sitemap.xml", r.SitemapURL)
+	require.NotNil(t, r.SitemapLastMod)
+	assert.True(t, lastMod.Equal(*r.SitemapLastMod))
+
+	assert.Empty(t, found[1].SitemapURL)
+
+	assert.Error(t, Each(db, Query{Sort: "unknown"}, func(*Record) error { return nil }))
+}
+
+func TestJSONL(t *testing.T) {
+	db := newTestDB(t, 2)
+	dir := t.TempDir()
+
+	manifest, err := JSONL(db, Query{}, dir)
+	require.NoError(t, err)
+	require.Len(t, manifest.Shards, 1)
+
+	shard := manifest.Shards[0]
+	assert.Equal(t, "contents-en-fatwa.jsonl.gz", shard.File)
+	assert.Equal(t, 2, shard.Count)
+	assert.Len(t, shard.SHA256, 64)
+
+	f, err := os.Open(filepath.Join(dir, shard.File))
+	require.NoError(t, err)
+	defer f.Close()
+
+	info, err := f.Stat()
+	require.NoError(t, err)
+	assert.Equal(t, info.Size(), shard.Bytes)
+
+	gz, err := gzip.NewReader(f)
+	require.NoError(t, err)
+
+	lines := []*Record{}
+	scanner := bufio.NewScanner(gz)
+	for scanner.Scan() {
+		r := &Record{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), r))
+		lines = append(lines, r)
+	}
+	require.NoError(t, scanner.Err())
+	assert.Equal(t, []uint{1, 2}, questionIDs(lines))
+
+	written := &Manifest{}
+	b, err := os.ReadFile(filepath.Join(dir, "manifest.json"))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(b, written))
+	assert.Equal(t, manifest.Shards, written.Shards)
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/hamza72x/islamqa-scrapper/content"
+	"github.com/hamza72x/islamqa-scrapper/log"
+
+	"gorm.io/gorm"
+)
+
+// hadithCommand prints every content citing a hadith
+// usage: hadith <collection> <number>, e.g. hadith bukhari 1234
+func hadithCommand(db *gorm.DB, args []string) {
+	if len(args) != 2 {
+		log.Fatal("usage: hadith <collection> <number>")
+	}
+
+	collection, ok := content.HadithCollection(args[0])
+	if !ok {
+		log.Fatal("unknown hadith collection: " + args[0])
+	}
+
+	number, err := strconv.Atoi(args[1])
+	if err != nil {
+		log.Fatal("invalid hadith number: " + args[1])
+	}
+
+	contents, err := content.FindByHadith(db, collection, number)
+	if err != nil {
+		log.Fatal("failed to find contents: " + err.Error())
+	}
+
+	log.Ok("found", len(contents), "contents citing", collection, number)
+
+	for _, c := range contents {
+		title := ""
+		if c.Title != nil {
+			title = *c.Title
+		}
+		fmt.Println(c.URL + "\t" + title)
+	}
+}
@@ -1,12 +1,20 @@
 package main
 
 import (
+	"errors"
+	"flag"
+	"os"
+
 	"github.com/hamza72x/islamqa-scrapper/content"
 	"github.com/hamza72x/islamqa-scrapper/log"
+	"github.com/hamza72x/islamqa-scrapper/migrations"
 	"github.com/hamza72x/islamqa-scrapper/scrapper"
-	"github.com/hamza72x/islamqa-scrapper/sitemap"
+	"github.com/hamza72x/islamqa-scrapper/search"
+	"github.com/hamza72x/islamqa-scrapper/storage"
+
+	// the site adapters register themselves
+	_ "github.com/hamza72x/islamqa-scrapper/sites/islamqa"
 
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
@@ -14,39 +22,97 @@ func main() {
 
 	log.Initialize()
 
-	// open database
-	db, err := gorm.Open(sqlite.Open("local.db"), &gorm.Config{})
+	// without a command, it's the default sync
+	command := "sync"
+	if len(os.Args) > 1 {
+		command = os.Args[1]
+	}
+
+	// rules has no database
+	if command == "rules" {
+		rulesCommand(os.Args[2:])
+		return
+	}
+
+	// open database, sqlite by default, see storage.Open for the DSNs
+	db, err := storage.Open(os.Getenv("DATABASE_URL"))
 	if err != nil {
 		log.Fatal("failed to connect database: " + err.Error())
 	}
 
-	// migrate
-	if err := db.AutoMigrate(
-		&sitemap.URL{},
-		&content.Content{},
-		&content.ContentV2{},
-	); err != nil {
+	// migrate runs the migrations by itself
+	if command == "migrate" {
+		migrateCommand(db, os.Args[2:])
+		return
+	}
+
+	// apply the pending migrations
+	if _, err := migrations.Up(db); err != nil {
 		log.Fatal("failed to migrate database: " + err.Error())
 	}
 
+	// full-text search tables
+	if err := search.Migrate(db); err != nil {
+		if !errors.Is(err, search.ErrUnavailable) {
+			log.Fatal("failed to migrate search: " + err.Error())
+		}
+		log.Warn(err)
+	}
+
+	switch command {
+	case "sync":
+		args := []string{}
+		if len(os.Args) > 2 {
+			args = os.Args[2:]
+		}
+		syncCommand(db, args)
+	case "hadith":
+		hadithCommand(db, os.Args[2:])
+	case "search":
+		searchCommand(db, os.Args[2:])
+	case "serve":
+		serveCommand(db, os.Args[2:])
+	case "export":
+		exportCommand(db, os.Args[2:])
+	case "diff":
+		diffCommand(db, os.Args[2:])
+	case "build-site":
+		buildSiteCommand(db, os.Args[2:])
+	default:
+		log.Fatal("unknown command: " + command)
+	}
+}
+
+// usage: sync [-crawl light] [-limit 1000] [-rules rules.json]
+func syncCommand(db *gorm.DB, args []string) {
+	flags := flag.NewFlagSet("sync", flag.ExitOnError)
+	crawl := flags.String("crawl", content.CrawlLight, "full or light, the extractors run on every page")
+	limit := flags.Int("limit", 1000, "urls to fetch, latest first, 0 for every pending url")
+	rulesFile := flags.String("rules", "", "parsing rules replacing the ones of their site")
+	flags.Parse(args)
+
+	if len(*rulesFile) > 0 {
+		useRules(*rulesFile)
+	}
+
+	cfg, ok := content.Configs[*crawl]
+	if !ok {
+		log.Fatal("unknown crawl: " + *crawl)
+	}
+
 	// create scrapper
 	s := scrapper.New(db)
 
 	// sync sitemaps
-	errs := s.SyncSitemaps(siteMaps)
+	errs := s.SyncSitemaps(s.Sitemaps())
 	if len(errs) > 0 {
 		for _, err := range errs {
 			log.Err(err)
 		}
 	}
 
-	// // sync conents
-	// if err := s.SyncContents(); err != nil {
-	// 	log.Err(err)
-	// }
-
-	//  sync conents v2
-	if err := s.SyncContentsV2(); err != nil {
+	// sync conents
+	if err := s.SyncContents(cfg, *limit); err != nil {
 		log.Err(err)
 	}
 }
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/hamza72x/islamqa-scrapper/log"
+	"github.com/hamza72x/islamqa-scrapper/migrations"
+
+	"gorm.io/gorm"
+)
+
+// migrateCommand lists, applies or reverts the schema migrations
+// usage: migrate status|up|down [-steps 1]
+func migrateCommand(db *gorm.DB, args []string) {
+	if len(args) == 0 {
+		log.Fatal("usage: migrate status|up|down [-steps 1]")
+	}
+
+	flags := flag.NewFlagSet("migrate "+args[0], flag.ExitOnError)
+	steps := flags.Int("steps", 1, "number of migrations to revert")
+	flags.Parse(args[1:])
+
+	switch args[0] {
+	case "status":
+		states, err := migrations.Status(db)
+		if err != nil {
+			log.Fatal("failed to read migrations: " + err.Error())
+		}
+
+		for _, s := range states {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d\t%-20s\t%s\n", s.Version, applied, s.Name)
+		}
+	case "up":
+		applied, err := migrations.Up(db)
+		for _, m := range applied {
+			log.Ok("applied", fmt.Sprintf("%04d", m.Version), m.Name)
+		}
+		if err != nil {
+			log.Fatal("failed to migrate: " + err.Error())
+		}
+		if len(applied) == 0 {
+			log.Info("no pending migrations")
+		}
+	case "down":
+		reverted, err := migrations.Down(db, *steps)
+		for _, m := range reverted {
+			log.Ok("reverted", fmt.Sprintf("%04d", m.Version), m.Name)
+		}
+		if err != nil {
+			log.Fatal("failed to revert: " + err.Error())
+		}
+	default:
+		log.Fatal("unknown migrate command: " + args[0])
+	}
+}
@@ -0,0 +1,178 @@
+package migrations
+
+import (
+	"time"
+
+	"github.com/hamza72x/islamqa-scrapper/search"
+
+	"gorm.io/gorm"
+)
+
+// the databases created by AutoMigrate before the migrations
+// are adopted, the missing tables and columns are added
+func init() {
+	register(Migration{
+		Version: 1,
+		Name:    "create tables",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(tablesV1()...)
+		},
+		Down: func(tx *gorm.DB) error {
+			// the search tables index the dropped ones
+			if err := search.Drop(tx); err != nil {
+				return err
+			}
+
+			models := tablesV1()
+			for i := len(models) - 1; i >= 0; i-- {
+				if err := tx.Migrator().DropTable(models[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
+
+// tablesV1 are the tables of version 1, the models are copies of the
+// ones of the time, the later versions change them by their migrations
+func tablesV1() []interface{} {
+	return []interface{}{
+		&urlV1{},
+		&contentV1{},
+		&contentV2{},
+		&quranCitationV1{},
+		&hadithCitationV1{},
+		&referenceV1{},
+		&categoryV1{},
+		&revisionV1{},
+		&checkpointV1{},
+	}
+}
+
+type urlV1 struct {
+	ID         uint      `gorm:"primarykey;column:id"`
+	SitemapUrl string    `gorm:"column:sitemap_url;index"`
+	Loc        string    `gorm:"column:loc;uniqueIndex"`
+	LastMod    time.Time `gorm:"column:last_mod"`
+}
+
+func (urlV1) TableName() string {
+	return "urls"
+}
+
+type contentV1 struct {
+	gorm.Model
+
+	URL        string  `gorm:"column:url;uniqueIndex"`
+	Title      *string `gorm:"column:title"`
+	Content    *string `gorm:"column:content"`
+	Summary    *string `gorm:"column:summary"`
+	Body       string  `gorm:"column:body"`
+	Language   string  `gorm:"column:language;index"`
+	Kind       string  `gorm:"column:kind;index"`
+	QuestionID uint    `gorm:"column:question_id;index"`
+
+	HTML     *string `gorm:"column:html"`
+	Markdown *string `gorm:"column:markdown"`
+	Text     *string `gorm:"column:text"`
+
+	TitleNormalized string `gorm:"column:title_normalized;index"`
+	TextNormalized  string `gorm:"column:text_normalized"`
+	Hash            string `gorm:"column:hash;index"`
+
+	LastModified time.Time `gorm:"column:last_modified"`
+
+	// the foreign keys of the references and categories
+	References []referenceV1 `gorm:"foreignKey:ContentID"`
+	Categories []categoryV1  `gorm:"foreignKey:ContentID"`
+}
+
+func (contentV1) TableName() string {
+	return "contents"
+}
+
+type quranCitationV1 struct {
+	ID        uint   `gorm:"primarykey;column:id"`
+	ContentID uint   `gorm:"column:content_id;index"`
+	Surah     int    `gorm:"column:surah;index"`
+	AyahFrom  int    `gorm:"column:ayah_from"`
+	AyahTo    int    `gorm:"column:ayah_to"`
+	Raw       string `gorm:"column:raw"`
+}
+
+func (quranCitationV1) TableName() string {
+	return "quran_citations"
+}
+
+type hadithCitationV1 struct {
+	ID         uint   `gorm:"primarykey;column:id"`
+	ContentID  uint   `gorm:"column:content_id;index"`
+	Collection string `gorm:"column:collection;index:idx_hadith_citations_collection_number"`
+	Number     int    `gorm:"column:number;index:idx_hadith_citations_collection_number"`
+	Grading    string `gorm:"column:grading"`
+	Raw        string `gorm:"column:raw"`
+}
+
+func (hadithCitationV1) TableName() string {
+	return "hadith_citations"
+}
+
+type referenceV1 struct {
+	ID           uint   `gorm:"primarykey;column:id"`
+	ContentID    uint   `gorm:"column:content_id;index"`
+	Position     int    `gorm:"column:position"`
+	Marker       string `gorm:"column:marker"`
+	MarkerAnchor string `gorm:"column:marker_anchor"`
+	Anchor       string `gorm:"column:anchor"`
+	Text         string `gorm:"column:text"`
+}
+
+func (referenceV1) TableName() string {
+	return "content_references"
+}
+
+type categoryV1 struct {
+	ID        uint   `gorm:"primarykey;column:id"`
+	ContentID uint   `gorm:"column:content_id;index"`
+	TopicID   uint   `gorm:"column:topic_id;index"`
+	Name      string `gorm:"column:name"`
+}
+
+func (categoryV1) TableName() string {
+	return "content_categories"
+}
+
+type revisionV1 struct {
+	ID           uint      `gorm:"primarykey;column:id"`
+	Source       string    `gorm:"column:source;index:idx_content_revisions_content"`
+	ContentID    uint      `gorm:"column:content_id;index:idx_content_revisions_content"`
+	QuestionID   uint      `gorm:"column:question_id;index"`
+	Language     string    `gorm:"column:language"`
+	URL          string    `gorm:"column:url"`
+	Title        *string   `gorm:"column:title"`
+	Summary      *string   `gorm:"column:summary"`
+	Text         *string   `gorm:"column:text"`
+	Hash         string    `gorm:"column:hash"`
+	FetchedAt    time.Time `gorm:"column:fetched_at"`
+	LastModified time.Time `gorm:"column:last_modified"`
+}
+
+func (revisionV1) TableName() string {
+	return "content_revisions"
+}
+
+type checkpointV1 struct {
+	ID        uint      `gorm:"primarykey;column:id"`
+	Source    string    `gorm:"column:source;index"`
+	Format    string    `gorm:"column:format;index"`
+	Language  string    `gorm:"column:language"`
+	Kind      string    `gorm:"column:kind"`
+	SinceID   *uint     `gorm:"column:since_id"`
+	StartedAt time.Time `gorm:"column:started_at"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+}
+
+func (checkpointV1) TableName() string {
+	return "export_checkpoints"
+}
@@ -0,0 +1,108 @@
+package migrations
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// the rows synced before the url columns have no language,
+// kind or question id, they're parsed from the urls
+func init() {
+	register(Migration{
+		Version: 2,
+		Name:    "backfill language, kind and question id",
+		Up: func(tx *gorm.DB) error {
+			contents := []*contentV1{}
+			if err := tx.
+				Unscoped().
+				Where("language = '' OR language IS NULL OR question_id = 0 OR question_id IS NULL").
+				FindInBatches(&contents, batchSize, func(tx *gorm.DB, _ int) error {
+					for _, c := range contents {
+						if err := updateURLColumns(tx.Unscoped(), &contentV1{}, c.ID, c.URL); err != nil {
+							return err
+						}
+					}
+					return nil
+				}).Error; err != nil {
+				return err
+			}
+
+			contentsV2 := []*contentV2{}
+			return tx.
+				Where("language = '' OR language IS NULL OR question_id = 0 OR question_id IS NULL").
+				FindInBatches(&contentsV2, batchSize, func(tx *gorm.DB, _ int) error {
+					for _, c := range contentsV2 {
+						if err := updateURLColumns(tx.Unscoped(), &contentV2{}, c.ID, c.URL); err != nil {
+							return err
+						}
+					}
+					return nil
+				}).Error
+		},
+		Down: keep,
+	})
+}
+
+// rows are backfilled in batches of
+const batchSize = 500
+
+func updateURLColumns(tx *gorm.DB, model interface{}, id uint, loc string) error {
+	return tx.
+		Model(model).
+		Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{
+			"language":    languageV2(loc),
+			"kind":        kindV2(loc),
+			"question_id": questionIDV2(loc),
+		}).
+		Error
+}
+
+// languageV2, kindV2 and questionIDV2 parse the urls as version 2 did,
+// e.g. "en", "fatwa" and 1 for https://islamqa.info/en/answers/1/...
+func languageV2(loc string) string {
+	segments := pathSegmentsV2(loc)
+	if len(segments[0]) != 2 {
+		return ""
+	}
+
+	return strings.ToLower(segments[0])
+}
+
+func kindV2(loc string) string {
+	segments := pathSegmentsV2(loc)
+	if len(segments) < 2 {
+		return ""
+	}
+
+	return map[string]string{
+		"answers":  "fatwa",
+		"articles": "article",
+	}[segments[1]]
+}
+
+func questionIDV2(loc string) uint {
+	segments := pathSegmentsV2(loc)
+	if len(segments) < 3 {
+		return 0
+	}
+
+	id, err := strconv.ParseUint(segments[2], 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	return uint(id)
+}
+
+func pathSegmentsV2(loc string) []string {
+	u, err := url.Parse(loc)
+	if err != nil {
+		return []string{""}
+	}
+
+	return strings.Split(strings.Trim(u.Path, "/"), "/")
+}
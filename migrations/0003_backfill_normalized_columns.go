@@ -0,0 +1,81 @@
+package migrations
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/hamza72x/islamqa-scrapper/normalize"
+
+	"gorm.io/gorm"
+)
+
+// the rows synced before the normalised columns and the hashes
+// have none, they're populated as Normalize did at the time
+func init() {
+	register(Migration{
+		Version: 3,
+		Name:    "backfill normalised columns and hashes",
+		Up: func(tx *gorm.DB) error {
+			contents := []*contentV1{}
+			if err := tx.
+				Unscoped().
+				Where("hash = '' OR hash IS NULL").
+				FindInBatches(&contents, batchSize, func(tx *gorm.DB, _ int) error {
+					for _, c := range contents {
+						if err := tx.Unscoped().Model(c).UpdateColumns(map[string]interface{}{
+							"title_normalized": normalized(c.Title),
+							"text_normalized":  normalized(c.Text),
+							"hash":             hashV1(c.Title, c.Summary, c.Text),
+						}).Error; err != nil {
+							return err
+						}
+					}
+					return nil
+				}).Error; err != nil {
+				return err
+			}
+
+			contentsV2 := []*contentV2{}
+			return tx.
+				Where("hash = '' OR hash IS NULL").
+				FindInBatches(&contentsV2, batchSize, func(tx *gorm.DB, _ int) error {
+					for _, c := range contentsV2 {
+						if err := tx.Model(c).UpdateColumns(map[string]interface{}{
+							"title_normalized":   normalized(c.Title),
+							"content_normalized": normalized(c.Content),
+							"hash":               hashV1(c.Title, c.Content),
+						}).Error; err != nil {
+							return err
+						}
+					}
+					return nil
+				}).Error
+		},
+		Down: keep,
+	})
+}
+
+func normalized(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return normalize.Text(*s)
+}
+
+// hashV1 is the content hash of versions 1 to 7, the SHA-256 of
+// the normalised fields, a missing field differs from an empty one
+func hashV1(fields ...*string) string {
+	h := sha256.New()
+
+	for _, field := range fields {
+		if field == nil {
+			h.Write([]byte{0})
+			continue
+		}
+		h.Write([]byte{1})
+		h.Write([]byte(normalize.Text(*field)))
+		h.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,233 @@
+package migrations
+
+import (
+	"strings"
+
+	"github.com/hamza72x/islamqa-scrapper/normalize"
+	"github.com/hamza72x/islamqa-scrapper/storage"
+
+	"github.com/PuerkitoBio/goquery"
+	"gorm.io/gorm"
+)
+
+// the light crawls are stored in contents, the contents_v2 rows
+// are merged into it with their revisions, the url of a full crawl
+// keeps its content and history
+// the title of a full crawl was its question, both are parsed
+// again from the stored bodies
+func init() {
+	register(Migration{
+		Version: 4,
+		Name:    "merge contents_v2 into contents",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(&contentV4{}, &revisionV4{}); err != nil {
+				return err
+			}
+
+			if err := tx.Exec("UPDATE contents SET crawl = 'full' WHERE crawl = '' OR crawl IS NULL").Error; err != nil {
+				return err
+			}
+
+			if tx.Migrator().HasTable(&contentV2{}) {
+				if err := mergeContentsV2(tx); err != nil {
+					return err
+				}
+			}
+
+			if err := reparseContents(tx); err != nil {
+				return err
+			}
+
+			revisions := []*revisionRowV4{}
+			return tx.FindInBatches(&revisions, batchSize, func(tx *gorm.DB, _ int) error {
+				for _, r := range revisions {
+					if err := tx.Model(r).UpdateColumn("hash", hashV1(r.Title, r.Question, r.Summary, r.Text)).Error; err != nil {
+						return err
+					}
+				}
+				return nil
+			}).Error
+		},
+		// the full crawls keep their titles
+		Down: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(&contentV2{}); err != nil {
+				return err
+			}
+
+			statements := []string{
+				`INSERT INTO contents_v2 (title, content, url, language, kind, question_id,
+					title_normalized, content_normalized, hash, last_modified, created_at, updated_at)
+				SELECT title, question, url, language, kind, question_id,
+					title_normalized, text_normalized, hash, last_modified, created_at, updated_at
+				FROM contents WHERE crawl = 'light' AND deleted_at IS NULL`,
+				`UPDATE content_revisions SET
+					source = 'contents_v2',
+					content_id = (SELECT v.id FROM contents_v2 v WHERE v.url = content_revisions.url),
+					text = question,
+					question = NULL
+				WHERE source = 'contents' AND content_id IN (SELECT id FROM contents WHERE crawl = 'light' AND deleted_at IS NULL)`,
+				"DELETE FROM contents WHERE crawl = 'light' AND deleted_at IS NULL",
+			}
+
+			for _, statement := range statements {
+				if err := tx.Exec(statement).Error; err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	})
+}
+
+func mergeContentsV2(tx *gorm.DB) error {
+	statements := []string{
+		// the history of a full crawl is kept
+		`DELETE FROM content_revisions
+		WHERE source = 'contents_v2' AND url IN (SELECT url FROM contents)`,
+		`INSERT INTO contents (created_at, updated_at, url, title, question, language, kind, question_id,
+			title_normalized, text_normalized, hash, last_modified, crawl, body)
+		SELECT v.created_at, v.updated_at, v.url, v.title, v.content, v.language, v.kind, v.question_id,
+			v.title_normalized, v.content_normalized, '', v.last_modified, 'light', ''
+		FROM contents_v2 v
+		WHERE NOT EXISTS (SELECT 1 FROM contents c WHERE c.url = v.url)`,
+		`UPDATE content_revisions SET
+			source = 'contents',
+			content_id = (SELECT c.id FROM contents c WHERE c.url = content_revisions.url),
+			question = text,
+			text = NULL
+		WHERE source = 'contents_v2'`,
+	}
+
+	// the search tables of contents_v2, its triggers are dropped with it
+	if !storage.IsPostgres(tx) {
+		statements = append(statements,
+			"DROP TABLE IF EXISTS contents_v2_fts",
+			"DROP TABLE IF EXISTS contents_v2_fts_trigram",
+		)
+	}
+
+	for _, statement := range statements {
+		if err := tx.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+
+	return tx.Migrator().DropTable(&contentV2{})
+}
+
+// reparseContents parses the title and the question of the full crawls
+// from their bodies, and normalises and hashes every content
+func reparseContents(tx *gorm.DB) error {
+	contents := []*contentRowV4{}
+	return tx.
+		Unscoped().
+		FindInBatches(&contents, batchSize, func(tx *gorm.DB, _ int) error {
+			for _, c := range contents {
+				if len(c.Body) > 0 && c.Question == nil {
+					if err := c.parse(); err != nil {
+						return err
+					}
+				}
+
+				text := []string{}
+				if c.Question != nil {
+					text = append(text, *c.Question)
+				}
+				if c.Text != nil {
+					text = append(text, *c.Text)
+				}
+
+				if err := tx.Unscoped().Model(c).UpdateColumns(map[string]interface{}{
+					"title":            c.Title,
+					"question":         c.Question,
+					"title_normalized": normalized(c.Title),
+					"text_normalized":  normalize.Text(strings.Join(text, "\n")),
+					"hash":             hashV1(c.Title, c.Question, c.Summary, c.Text),
+				}).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+}
+
+// contentV4 are the columns added to contents by version 4
+type contentV4 struct {
+	// Question is the question of a fatwa, the seo description of an article
+	Question *string `gorm:"column:question"`
+	Crawl    string  `gorm:"column:crawl;index"`
+}
+
+func (contentV4) TableName() string {
+	return "contents"
+}
+
+// revisionV4 are the columns added to content_revisions by version 4
+type revisionV4 struct {
+	Question *string `gorm:"column:question"`
+}
+
+func (revisionV4) TableName() string {
+	return "content_revisions"
+}
+
+// contentRowV4 are the columns of contents reparsed by version 4
+type contentRowV4 struct {
+	ID       uint    `gorm:"primarykey;column:id"`
+	Kind     string  `gorm:"column:kind"`
+	Body     string  `gorm:"column:body"`
+	Title    *string `gorm:"column:title"`
+	Question *string `gorm:"column:question"`
+	Summary  *string `gorm:"column:summary"`
+	Text     *string `gorm:"column:text"`
+}
+
+func (contentRowV4) TableName() string {
+	return "contents"
+}
+
+// parse parses the title and the question, or the seo description
+// of an article, as the extractors of the time did
+func (c *contentRowV4) parse() error {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(c.Body))
+	if err != nil {
+		return err
+	}
+
+	question := strings.TrimSpace(doc.Find("section.single_fatwa__question").Find("div").Text())
+
+	title := strings.TrimSpace(doc.Find("div.single-layout__title").Find("h1").Text())
+	if len(title) == 0 {
+		title = question
+	}
+	c.Title = &title
+
+	if c.Kind != "article" && len(question) > 0 {
+		c.Question = &question
+		return nil
+	}
+
+	description, ok := doc.Find("meta[name='description']").Attr("content")
+	if !ok {
+		c.Question = nil
+		return nil
+	}
+	description = strings.TrimSpace(description)
+	c.Question = &description
+
+	return nil
+}
+
+// revisionRowV4 are the columns of content_revisions hashed by version 4
+type revisionRowV4 struct {
+	ID       uint    `gorm:"primarykey;column:id"`
+	Title    *string `gorm:"column:title"`
+	Question *string `gorm:"column:question"`
+	Summary  *string `gorm:"column:summary"`
+	Text     *string `gorm:"column:text"`
+}
+
+func (revisionRowV4) TableName() string {
+	return "content_revisions"
+}
@@ -0,0 +1,45 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+// the contents are of the site adapter of their url,
+// the ones synced before the adapters are of islamqa.info
+// the checkpoints before have no site filter
+func init() {
+	register(Migration{
+		Version: 5,
+		Name:    "add site column",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(&contentV5{}, &checkpointV5{}); err != nil {
+				return err
+			}
+
+			if err := tx.Exec("UPDATE export_checkpoints SET site = '' WHERE site IS NULL").Error; err != nil {
+				return err
+			}
+
+			return tx.Exec("UPDATE contents SET site = 'islamqa' WHERE site = '' OR site IS NULL").Error
+		},
+		Down: keep,
+	})
+}
+
+// contentV5 are the columns added to contents by version 5
+type contentV5 struct {
+	Site string `gorm:"column:site;index"`
+}
+
+func (contentV5) TableName() string {
+	return "contents"
+}
+
+// checkpointV5 are the columns added to export_checkpoints by version 5
+type checkpointV5 struct {
+	Site string `gorm:"column:site"`
+}
+
+func (checkpointV5) TableName() string {
+	return "export_checkpoints"
+}
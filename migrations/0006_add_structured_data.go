@@ -0,0 +1,75 @@
+package migrations
+
+import (
+	"github.com/hamza72x/islamqa-scrapper/content"
+	"github.com/hamza72x/islamqa-scrapper/sitemap"
+	"github.com/hamza72x/islamqa-scrapper/storage"
+
+	"gorm.io/gorm"
+)
+
+// the structured data of the full crawls is parsed from their bodies,
+// the fields preferring it are parsed again by the next changes
+func init() {
+	register(Migration{
+		Version: 6,
+		Name:    "add structured data columns",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(&contentV6{}); err != nil {
+				return err
+			}
+
+			contents := []*contentRowV6{}
+			return tx.
+				Where("body <> ''").
+				FindInBatches(&contents, batchSize, func(tx *gorm.DB, _ int) error {
+					for _, c := range contents {
+						p, err := content.ParsePage(&sitemap.URL{Loc: c.URL}, c.Body)
+						if err != nil {
+							return err
+						}
+						data := p.Structured()
+
+						if err := tx.Model(c).UpdateColumns(map[string]interface{}{
+							"json_ld":    storage.NewJSON(data.JSONLD),
+							"microdata":  storage.NewJSON(data.Microdata),
+							"open_graph": storage.NewJSON(data.OpenGraph),
+						}).Error; err != nil {
+							return err
+						}
+					}
+					return nil
+				}).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, column := range []string{"json_ld", "microdata", "open_graph"} {
+				if err := tx.Migrator().DropColumn(&contentV6{}, column); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
+
+// contentV6 are the columns added to contents by version 6
+type contentV6 struct {
+	JSONLD    storage.JSON[[]map[string]interface{}] `gorm:"column:json_ld"`
+	Microdata storage.JSON[[]map[string]interface{}] `gorm:"column:microdata"`
+	OpenGraph storage.JSON[map[string]string]        `gorm:"column:open_graph"`
+}
+
+func (contentV6) TableName() string {
+	return "contents"
+}
+
+// contentRowV6 are the columns of contents parsed by version 6
+type contentRowV6 struct {
+	ID   uint   `gorm:"primarykey;column:id"`
+	URL  string `gorm:"column:url"`
+	Body string `gorm:"column:body"`
+}
+
+func (contentRowV6) TableName() string {
+	return "contents"
+}
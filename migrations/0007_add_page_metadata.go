@@ -0,0 +1,93 @@
+package migrations
+
+import (
+	"time"
+
+	"github.com/hamza72x/islamqa-scrapper/content"
+	"github.com/hamza72x/islamqa-scrapper/sitemap"
+	"github.com/hamza72x/islamqa-scrapper/sites/islamqa"
+
+	"gorm.io/gorm"
+)
+
+// the publish and update dates, views, author and publisher of the
+// full crawls are parsed from their bodies
+func init() {
+	register(Migration{
+		Version: 7,
+		Name:    "add page metadata columns",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(&contentV7{}); err != nil {
+				return err
+			}
+
+			cfg := content.Config{
+				Name: content.CrawlFull,
+				Extractors: []string{
+					content.ExtractPublished, content.ExtractModified, content.ExtractViews,
+					content.ExtractAuthor, content.ExtractPublisher,
+				},
+			}
+
+			site := islamqa.New()
+
+			rows := []*contentRowV7{}
+			return tx.
+				Where("body <> '' AND site = 'islamqa'").
+				FindInBatches(&rows, batchSize, func(tx *gorm.DB, _ int) error {
+					for _, row := range rows {
+						p, err := content.ParsePage(&sitemap.URL{Loc: row.URL, LastMod: row.LastModified}, row.Body)
+						if err != nil {
+							return err
+						}
+
+						c := &content.Content{URL: row.URL, Language: row.Language, Kind: row.Kind}
+						if err := site.Parse(cfg, p, c); err != nil {
+							return err
+						}
+
+						if err := tx.Model(row).UpdateColumns(map[string]interface{}{
+							"published_at": c.PublishedAt,
+							"modified_at":  c.ModifiedAt,
+							"views":        c.Views,
+							"author":       c.Author,
+							"publisher":    c.Publisher,
+						}).Error; err != nil {
+							return err
+						}
+					}
+					return nil
+				}).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			return dropColumns(tx, "contents", "published_at", "modified_at", "views", "author", "publisher")
+		},
+	})
+}
+
+// contentV7 are the columns added to contents by version 7
+type contentV7 struct {
+	PublishedAt *time.Time `gorm:"column:published_at;index"`
+	ModifiedAt  *time.Time `gorm:"column:modified_at;index"`
+	Views       *int64     `gorm:"column:views;index"`
+	Author      *string    `gorm:"column:author;index"`
+	Publisher   *string    `gorm:"column:publisher"`
+}
+
+func (contentV7) TableName() string {
+	return "contents"
+}
+
+// contentRowV7 are the columns of contents parsed by version 7
+type contentRowV7 struct {
+	ID           uint      `gorm:"primarykey;column:id"`
+	URL          string    `gorm:"column:url"`
+	Language     string    `gorm:"column:language"`
+	Kind         string    `gorm:"column:kind"`
+	Body         string    `gorm:"column:body"`
+	LastModified time.Time `gorm:"column:last_modified"`
+}
+
+func (contentRowV7) TableName() string {
+	return "contents"
+}
@@ -0,0 +1,80 @@
+package migrations
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// the content hash is of the trimmed text instead of the normalised
+// one, the contents and their revisions are hashed again
+func init() {
+	register(Migration{
+		Version: 8,
+		Name:    "rehash contents",
+		Up: func(tx *gorm.DB) error {
+			return rehash(tx, hashV8)
+		},
+		Down: func(tx *gorm.DB) error {
+			return rehash(tx, hashV1)
+		},
+	})
+}
+
+func rehash(tx *gorm.DB, hash func(fields ...*string) string) error {
+	contents := []*contentRowV8{}
+	if err := tx.FindInBatches(&contents, batchSize, func(tx *gorm.DB, _ int) error {
+		for _, c := range contents {
+			if err := tx.Model(c).UpdateColumn("hash", hash(c.Title, c.Question, c.Summary, c.Text)).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}).Error; err != nil {
+		return err
+	}
+
+	revisions := []*revisionRowV4{}
+	return tx.FindInBatches(&revisions, batchSize, func(tx *gorm.DB, _ int) error {
+		for _, r := range revisions {
+			if err := tx.Model(r).UpdateColumn("hash", hash(r.Title, r.Question, r.Summary, r.Text)).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}).Error
+}
+
+// hashV8 is the content hash of version 8, the SHA-256 of the
+// trimmed fields, a missing field differs from an empty one
+func hashV8(fields ...*string) string {
+	h := sha256.New()
+
+	for _, field := range fields {
+		if field == nil {
+			h.Write([]byte{0})
+			continue
+		}
+		h.Write([]byte{1})
+		h.Write([]byte(strings.TrimSpace(*field)))
+		h.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// contentRowV8 are the columns of contents hashed by version 8,
+// the deleted contents are hashed too
+type contentRowV8 struct {
+	ID       uint    `gorm:"primarykey;column:id"`
+	Title    *string `gorm:"column:title"`
+	Question *string `gorm:"column:question"`
+	Summary  *string `gorm:"column:summary"`
+	Text     *string `gorm:"column:text"`
+}
+
+func (contentRowV8) TableName() string {
+	return "contents"
+}
@@ -0,0 +1,33 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+// a delta applies to the export of the same filters and options,
+// the checkpoints before have none
+func init() {
+	register(Migration{
+		Version: 9,
+		Name:    "add checkpoint filters",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(&checkpointV9{}); err != nil {
+				return err
+			}
+
+			return tx.Exec("UPDATE export_checkpoints SET filters = '' WHERE filters IS NULL").Error
+		},
+		Down: func(tx *gorm.DB) error {
+			return dropColumns(tx, "export_checkpoints", "filters")
+		},
+	})
+}
+
+// checkpointV9 are the columns added to export_checkpoints by version 9
+type checkpointV9 struct {
+	Filters string `gorm:"column:filters"`
+}
+
+func (checkpointV9) TableName() string {
+	return "export_checkpoints"
+}
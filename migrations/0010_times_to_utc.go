@@ -0,0 +1,82 @@
+package migrations
+
+import (
+	"time"
+
+	"github.com/hamza72x/islamqa-scrapper/storage"
+
+	"gorm.io/gorm"
+)
+
+// utcColumns are the time columns compared by the queries,
+// the sitemap lastmods against the last modified dates of
+// the contents and the dates of the export filters
+var utcColumns = []struct {
+	table   string
+	columns []string
+}{
+	{"urls", []string{"last_mod"}},
+	{"contents", []string{"last_modified", "published_at", "modified_at", "created_at", "updated_at", "deleted_at"}},
+}
+
+// the lastmods are parsed to UTC and the times stamped in UTC, SQLite
+// compares them as text, the ones stored with an offset before are
+// converted, the same times in PostgreSQL are equal whatever the offset
+func init() {
+	register(Migration{
+		Version: 10,
+		Name:    "times to utc",
+		Up: func(tx *gorm.DB) error {
+			if storage.IsPostgres(tx) {
+				return nil
+			}
+
+			for _, t := range utcColumns {
+				for _, column := range t.columns {
+					if err := timesToUTC(tx, t.table, column); err != nil {
+						return err
+					}
+				}
+			}
+
+			return nil
+		},
+		Down: keep,
+	})
+}
+
+// timeRowV10 is a time of a row
+type timeRowV10 struct {
+	ID   uint
+	Time *time.Time
+}
+
+// timesToUTC converts the times of a column stored with an
+// offset other than UTC's, "+00:00" as the sqlite driver writes it
+func timesToUTC(tx *gorm.DB, table string, column string) error {
+	for last := uint(0); ; {
+		rows := []*timeRowV10{}
+		err := tx.
+			Table(table).
+			Select("id, "+column+" AS time").
+			Where(column+" IS NOT NULL AND "+column+" NOT LIKE '%+00:00' AND id > ?", last).
+			Order("id").
+			Limit(batchSize).
+			Find(&rows).
+			Error
+		if err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			if err := tx.Table(table).Where("id = ?", row.ID).UpdateColumn(column, row.Time.UTC()).Error; err != nil {
+				return err
+			}
+			last = row.ID
+		}
+
+		if len(rows) < batchSize {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package migrations
+
+import "time"
+
+// contentV2 is the contents_v2 table of the light crawls,
+// merged into contents by migration 4
+type contentV2 struct {
+	ID    uint    `gorm:"primarykey;column:id"`
+	Title *string `gorm:"column:title"`
+
+	// Content is the question body of a fatwa,
+	// or the seo description of an article
+	Content *string `gorm:"column:content"`
+
+	URL        string `gorm:"column:url;uniqueIndex"`
+	Language   string `gorm:"column:language;index"`
+	Kind       string `gorm:"column:kind;index"`
+	QuestionID uint   `gorm:"column:question_id;index"`
+
+	TitleNormalized   string `gorm:"column:title_normalized;index"`
+	ContentNormalized string `gorm:"column:content_normalized"`
+	Hash              string `gorm:"column:hash;index"`
+
+	LastModified time.Time `gorm:"column:last_modified"`
+	CreatedAt    time.Time `gorm:"column:created_at"`
+	UpdatedAt    time.Time `gorm:"column:updated_at;index"`
+}
+
+func (contentV2) TableName() string {
+	return "contents_v2"
+}
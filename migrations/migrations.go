@@ -0,0 +1,178 @@
+package migrations
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migration is a numbered schema or data change, Down reverts Up
+type Migration struct {
+	Version uint
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// SchemaMigration is an applied migration
+type SchemaMigration struct {
+	Version   uint      `gorm:"primarykey;autoIncrement:false;column:version"`
+	Name      string    `gorm:"column:name"`
+	AppliedAt time.Time `gorm:"column:applied_at"`
+}
+
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// State is a migration and when it was applied, nil when pending
+type State struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// migrations are registered by their files, in any order
+var migrations = []Migration{}
+
+func register(m Migration) {
+	migrations = append(migrations, m)
+}
+
+// All are the migrations ordered by version
+func All() []Migration {
+	all := append([]Migration{}, migrations...)
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].Version < all[j].Version
+	})
+	return all
+}
+
+// Status is the state of every migration
+func Status(db *gorm.DB) ([]State, error) {
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	states := []State{}
+	for _, m := range All() {
+		s := State{Migration: m}
+		if a, ok := applied[m.Version]; ok {
+			appliedAt := a.AppliedAt
+			s.AppliedAt = &appliedAt
+		}
+		states = append(states, s)
+	}
+
+	return states, nil
+}
+
+// Up applies the pending migrations in order, each in a transaction
+// returns the applied ones
+func Up(db *gorm.DB) ([]Migration, error) {
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	done := []Migration{}
+
+	for _, m := range All() {
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := m.Up(tx); err != nil {
+				return err
+			}
+
+			return tx.Create(&SchemaMigration{
+				Version:   m.Version,
+				Name:      m.Name,
+				AppliedAt: time.Now().UTC(),
+			}).Error
+		})
+		if err != nil {
+			return done, errors.New(m.Name + ": " + err.Error())
+		}
+
+		done = append(done, m)
+	}
+
+	return done, nil
+}
+
+// Down reverts the last steps applied migrations, latest first
+// returns the reverted ones
+func Down(db *gorm.DB, steps int) ([]Migration, error) {
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	all := All()
+	done := []Migration{}
+
+	for i := len(all) - 1; i >= 0 && len(done) < steps; i-- {
+		m := all[i]
+
+		if _, ok := applied[m.Version]; !ok {
+			continue
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := m.Down(tx); err != nil {
+				return err
+			}
+
+			return tx.Delete(&SchemaMigration{}, m.Version).Error
+		})
+		if err != nil {
+			return done, errors.New(m.Name + ": " + err.Error())
+		}
+
+		done = append(done, m)
+	}
+
+	return done, nil
+}
+
+func appliedMigrations(db *gorm.DB) (map[uint]SchemaMigration, error) {
+	if err := db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return nil, err
+	}
+
+	rows := []SchemaMigration{}
+	if err := db.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	applied := map[uint]SchemaMigration{}
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+
+	return applied, nil
+}
+
+// keep is the Down of the data migrations, the backfilled values are kept
+func keep(tx *gorm.DB) error {
+	return nil
+}
+
+// dropColumns drops columns and their indexes in place, the sqlite
+// migrator of gorm copies the table instead, losing its triggers
+func dropColumns(tx *gorm.DB, table string, columns ...string) error {
+	for _, column := range columns {
+		if err := tx.Exec("DROP INDEX IF EXISTS idx_" + table + "_" + column).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("ALTER TABLE " + table + " DROP COLUMN " + column).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package migrations
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hamza72x/islamqa-scrapper/content"
+	"github.com/hamza72x/islamqa-scrapper/export"
+	"github.com/hamza72x/islamqa-scrapper/repository"
+	"github.com/hamza72x/islamqa-scrapper/sitemap"
+	"github.com/hamza72x/islamqa-scrapper/storage"
+	"github.com/hamza72x/islamqa-scrapper/storage/storagetest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// TestSchema checks that the migrations create every column of the models
+func TestSchema(t *testing.T) {
+	db := storagetest.Open(t)
+
+	done, err := Up(db)
+	require.NoError(t, err)
+	assert.Len(t, done, len(All()))
+
+	models := []interface{}{
+		&sitemap.URL{},
+		&content.Content{},
+		&content.QuranCitation{},
+		&content.HadithCitation{},
+		&content.Reference{},
+		&content.Category{},
+		&content.Revision{},
+		&export.Checkpoint{},
+	}
+
+	for _, model := range models {
+		stmt := &gorm.Statement{DB: db}
+		require.NoError(t, stmt.Parse(model))
+
+		for _, field := range stmt.Schema.Fields {
+			if len(field.DBName) > 0 {
+				assert.True(t, db.Migrator().HasColumn(model, field.DBName), stmt.Schema.Table+"."+field.DBName)
+			}
+		}
+	}
+
+	assert.False(t, db.Migrator().HasTable(&contentV2{}))
+}
+
+func TestDownUp(t *testing.T) {
+	db := storagetest.Open(t)
+
+	_, err := Up(db)
+	require.NoError(t, err)
+
+	done, err := Down(db, len(All()))
+	require.NoError(t, err)
+	assert.Len(t, done, len(All()))
+	assert.False(t, db.Migrator().HasTable(&contentV1{}))
+
+	done, err = Up(db)
+	require.NoError(t, err)
+	assert.Len(t, done, len(All()))
+}
+
+// TestBackfill migrates the rows of version 1
+func TestBackfill(t *testing.T) {
+	db := storagetest.Open(t)
+
+	_, err := Up(db)
+	require.NoError(t, err)
+	_, err = Down(db, len(All())-1)
+	require.NoError(t, err)
+
+	body := `<html><head><meta name="description" content="description"></head><body>
+		<div class="single-layout__title"><h1> Title </h1></div>
+		<section class="single_fatwa__question"><div> Question? </div></section>
+	</body></html>`
+	text := "Answer"
+
+	full := &contentV1{URL: "https://islamqa.info/en/answers/1/a", Body: body, Text: &text}
+	require.NoError(t, db.Create(full).Error)
+
+	title, question := "Light", "Light question"
+	light := &contentV2{URL: "https://islamqa.info/en/articles/2/b", Title: &title, Content: &question}
+	require.NoError(t, db.Create(light).Error)
+
+	_, err = Up(db)
+	require.NoError(t, err)
+
+	contents := []*content.Content{}
+	require.NoError(t, db.Order("id").Find(&contents).Error)
+	require.Len(t, contents, 2)
+
+	c := contents[0]
+	assert.Equal(t, "en", c.Language)
+	assert.Equal(t, content.KindFatwa, c.Kind)
+	assert.Equal(t, uint(1), c.QuestionID)
+	assert.Equal(t, "Title", *c.Title)
+	assert.Equal(t, "Question?", *c.Question)
+	assert.Equal(t, content.CrawlFull, c.Crawl)
+	assert.Equal(t, "islamqa", c.Site)
+	assert.Equal(t, c.ContentHash(), c.Hash)
+
+	c = contents[1]
+	assert.Equal(t, content.KindArticle, c.Kind)
+	assert.Equal(t, "Light", *c.Title)
+	assert.Equal(t, "Light question", *c.Question)
+	assert.Equal(t, content.CrawlLight, c.Crawl)
+	assert.Equal(t, c.ContentHash(), c.Hash)
+}
+
+// TestTimesToUTC upgrades the lastmods stored with an offset, the
+// contents synced since are no longer pending
+func TestTimesToUTC(t *testing.T) {
+	db := storagetest.Open(t)
+
+	_, err := Up(db)
+	require.NoError(t, err)
+	_, err = Down(db, 1)
+	require.NoError(t, err)
+
+	lastMod := time.Date(2023, 6, 27, 12, 0, 0, 0, time.FixedZone("", 3*60*60))
+	loc := "https://islamqa.info/en/answers/1/a"
+
+	require.NoError(t, db.Create(&sitemap.URL{Loc: loc, LastMod: lastMod}).Error)
+	require.NoError(t, db.Create(&content.Content{URL: loc, Crawl: content.CrawlFull, LastModified: lastMod}).Error)
+
+	_, err = Up(db)
+	require.NoError(t, err)
+
+	if !storage.IsPostgres(db) {
+		for _, column := range []string{"urls.last_mod", "contents.last_modified"} {
+			table, name, _ := strings.Cut(column, ".")
+			stored := ""
+			require.NoError(t, db.Raw("SELECT CAST("+name+" AS TEXT) FROM "+table).Scan(&stored).Error)
+			assert.Equal(t, "2023-06-27 09:00:00+00:00", stored, column)
+		}
+	}
+
+	store := repository.NewGorm(db)
+
+	updated, err := store.UpdateLastMod([]*sitemap.URL{{Loc: loc, LastMod: lastMod.UTC()}})
+	require.NoError(t, err)
+	assert.Zero(t, updated)
+
+	pending, err := store.ListPending(content.Full, 0)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
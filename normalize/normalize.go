@@ -0,0 +1,85 @@
+package normalize
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// letters are the arabic script letter variants unified by Text
+// hamza forms are not listed, they lose the hamza as a mark
+var letters = strings.NewReplacer(
+	// alef wasla
+	"ٱ", "ا",
+	// alef maksura, farsi yeh
+	"ى", "ي",
+	"ی", "ي",
+	// ta marbuta, urdu heh goal and do-chashmi heh
+	"ة", "ه",
+	"ۃ", "ه",
+	"ہ", "ه",
+	"ھ", "ه",
+	// keheh
+	"ک", "ك",
+)
+
+// Text normalises s for search and duplicate detection
+// diacritics (tashkeel and latin accents), tatweel and invisible
+// marks are removed, arabic letter variants are unified,
+// digits are ASCII, the result is lower case NFC with single spaces
+func Text(s string) string {
+	s = norm.NFD.String(s)
+
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case isDiacritic(r):
+			return -1
+		// tatweel, zero width (non) joiner, bidi marks
+		case r == '\u0640', r == '\u200c', r == '\u200d', r == '\u200e', r == '\u200f', r == '\u061c':
+			return -1
+		}
+		return r
+	}, s)
+
+	s = letters.Replace(norm.NFC.String(s))
+	s = Digits(strings.ToLower(s))
+
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// Digits converts Arabic-Indic, Persian and fullwidth digits to ASCII
+func Digits(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= '٠' && r <= '٩':
+			return '0' + (r - '٠')
+		case r >= '۰' && r <= '۹':
+			return '0' + (r - '۰')
+		case r >= '０' && r <= '９':
+			return '0' + (r - '０')
+		}
+		return r
+	}, s)
+}
+
+// isDiacritic reports the combining marks of latin, cyrillic and arabic
+// other scripts, e.g. devanagari, need their marks
+func isDiacritic(r rune) bool {
+	if !unicode.Is(unicode.Mn, r) {
+		return false
+	}
+
+	switch {
+	case r >= 0x0300 && r <= 0x036F:
+		return true
+	case r >= 0x0610 && r <= 0x061A:
+		return true
+	case r >= 0x064B && r <= 0x065F, r == 0x0670:
+		return true
+	case r >= 0x06D6 && r <= 0x06ED:
+		return true
+	}
+
+	return false
+}
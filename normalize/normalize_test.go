@@ -0,0 +1,92 @@
+package normalize
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestText(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{
+			name: "tashkeel",
+			text: "الْحَمْدُ لِلَّهِ",
+			want: "الحمد لله",
+		},
+		{
+			name: "superscript alef and quranic marks",
+			text: "الرَّحْمٰنِ ذٰلِكَ ۚ",
+			want: "الرحمن ذلك",
+		},
+		{
+			name: "tatweel",
+			text: "الـــحـمـد",
+			want: "الحمد",
+		},
+		{
+			name: "hamza forms",
+			text: "أحمد إسلام آمن",
+			want: "احمد اسلام امن",
+		},
+		{
+			name: "alef wasla",
+			text: "ٱلصلاة",
+			want: "الصلاه",
+		},
+		{
+			name: "alef maksura and farsi yeh",
+			text: "موسى فتوی",
+			want: "موسي فتوي",
+		},
+		{
+			name: "taa marbuta",
+			text: "صلاة زكاة",
+			want: "صلاه زكاه",
+		},
+		{
+			name: "urdu heh and keheh",
+			text: "نماز ہے کتاب",
+			want: "نماز هے كتاب",
+		},
+		{
+			name: "latin accents and case",
+			text: "Ḥadīth of ÉCOLE",
+			want: "hadith of ecole",
+		},
+		{
+			name: "cyrillic case",
+			text: "Пост Рамадана",
+			want: "пост рамадана",
+		},
+		{
+			name: "whitespace",
+			text: "  fasting \t\n in\u00a0 ramadan  ",
+			want: "fasting in ramadan",
+		},
+		{
+			name: "invisible marks",
+			text: "می\u200cخواهم \u200fنماز",
+			want: "ميخواهم نماز",
+		},
+		{
+			name: "digits",
+			text: "١٤٤٤ ۱۴۴۴ １４４４",
+			want: "1444 1444 1444",
+		},
+		{
+			name: "devanagari marks are kept",
+			text: "रमज़ान",
+			want: "रमज़ान",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Text(tt.text))
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package repository
+
+import (
+	"github.com/hamza72x/islamqa-scrapper/content"
+	"github.com/hamza72x/islamqa-scrapper/sitemap"
+	"github.com/hamza72x/islamqa-scrapper/storage"
+
+	"gorm.io/gorm"
+)
+
+// the values of an IN condition are bound by batches of
+const batchSize = 500
+
+// Gorm is the database implementation of the stores
+type Gorm struct {
+	db *gorm.DB
+}
+
+var (
+	_ URLStore     = (*Gorm)(nil)
+	_ ContentStore = (*Gorm)(nil)
+)
+
+func NewGorm(db *gorm.DB) *Gorm {
+	return &Gorm{db: db}
+}
+
+func (g *Gorm) InsertMissing(urls []*sitemap.URL) (int64, error) {
+	rows := [][]interface{}{}
+	for _, url := range urls {
+		rows = append(rows, []interface{}{url.SitemapUrl, url.Loc, url.LastMod})
+	}
+
+	return storage.InsertIgnore(g.db, sitemap.URL{}.TableName(), []string{"sitemap_url", "loc", "last_mod"}, rows)
+}
+
+func (g *Gorm) UpdateLastMod(urls []*sitemap.URL) (int64, error) {
+	updated := int64(0)
+
+	err := g.db.Session(&gorm.Session{PrepareStmt: true}).Transaction(func(tx *gorm.DB) error {
+		for _, url := range urls {
+			result := tx.
+				Model(&sitemap.URL{}).
+				Where("loc = ? AND last_mod <> ?", url.Loc, url.LastMod).
+				UpdateColumn("last_mod", url.LastMod)
+			if result.Error != nil {
+				return result.Error
+			}
+			updated += result.RowsAffected
+		}
+		return nil
+	})
+
+	return updated, err
+}
+
+func (g *Gorm) FindByLoc(loc string) (*sitemap.URL, error) {
+	url := &sitemap.URL{}
+	if err := g.db.Where("loc = ?", loc).Limit(1).Find(url).Error; err != nil {
+		return nil, err
+	}
+	if url.ID == 0 {
+		return nil, ErrNotFound
+	}
+	return url, nil
+}
+
+func (g *Gorm) ListPending(cfg content.Config, limit int) ([]*sitemap.URL, error) {
+	urls := []*sitemap.URL{}
+
+	// the urls of the deleted contents are removed, a url back
+	// in the sitemaps restores its content
+	tx := g.db.
+		Model(&sitemap.URL{}).
+		Select("urls.*").
+		Joins("LEFT JOIN contents c ON c.url = urls.loc").
+		Where("c.id IS NULL OR c.deleted_at IS NOT NULL OR c.last_modified <> urls.last_mod OR c.crawl NOT IN ?", cfg.CoveredBy()).
+		Order("urls.last_mod DESC")
+
+	if limit > 0 {
+		tx = tx.Limit(limit)
+	}
+
+	if err := tx.Find(&urls).Error; err != nil {
+		return nil, err
+	}
+
+	return urls, nil
+}
+
+func (g *Gorm) ListLocs(sitemapURLs []string) ([]string, error) {
+	locs := []string{}
+	if len(sitemapURLs) == 0 {
+		return locs, nil
+	}
+
+	err := g.db.
+		Model(&sitemap.URL{}).
+		Where("sitemap_url IN ?", sitemapURLs).
+		Order("id").
+		Pluck("loc", &locs).
+		Error
+
+	return locs, err
+}
+
+func (g *Gorm) Remove(locs []string) error {
+	return inBatches(locs, func(batch []string) error {
+		return g.db.Where("loc IN ?", batch).Delete(&sitemap.URL{}).Error
+	})
+}
+
+func (g *Gorm) FindByURL(url string) (*content.Content, error) {
+	c := &content.Content{}
+	if err := g.db.Unscoped().Where("url = ?", url).Limit(1).Find(c).Error; err != nil {
+		return nil, err
+	}
+	if c.ID == 0 {
+		return nil, ErrNotFound
+	}
+	return c, nil
+}
+
+func (g *Gorm) Upsert(c *content.Content) error {
+	if c.ID == 0 {
+		return g.db.Create(c).Error
+	}
+	return g.db.Unscoped().Save(c).Error
+}
+
+func (g *Gorm) Delete(urls []string) error {
+	return inBatches(urls, func(batch []string) error {
+		return g.db.Where("url IN ?", batch).Delete(&content.Content{}).Error
+	})
+}
+
+func (g *Gorm) Touch(c *content.Content) error {
+	return g.db.
+		Model(c).
+		UpdateColumns(map[string]interface{}{
+			"last_modified": c.LastModified,
+			"hash":          c.Hash,
+			"crawl":         c.Crawl,
+			"json_ld":       c.JSONLD,
+			"microdata":     c.Microdata,
+			"open_graph":    c.OpenGraph,
+			"published_at":  c.PublishedAt,
+			"modified_at":   c.ModifiedAt,
+			"views":         c.Views,
+			"author":        c.Author,
+			"publisher":     c.Publisher,
+			"updated_at":    g.db.NowFunc(),
+		}).
+		Error
+}
+
+func (g *Gorm) ReplaceRelated(contentID uint, related Related) error {
+	return g.db.Transaction(func(tx *gorm.DB) error {
+		if err := replaceContentRows(tx, contentID, related.QuranCitations); err != nil {
+			return err
+		}
+
+		if err := replaceContentRows(tx, contentID, related.HadithCitations); err != nil {
+			return err
+		}
+
+		if err := replaceContentRows(tx, contentID, related.References); err != nil {
+			return err
+		}
+
+		return replaceContentRows(tx, contentID, related.Categories)
+	})
+}
+
+// replaceContentRows deletes the rows of T belonging to the content
+// and inserts the given rows instead
+func replaceContentRows[T any](tx *gorm.DB, contentID uint, rows []T) error {
+	if err := tx.
+		Where("content_id = ?", contentID).
+		Delete(new(T)).
+		Error; err != nil {
+		return err
+	}
+
+	if len(rows) == 0 {
+		return nil
+	}
+
+	return tx.Create(&rows).Error
+}
+
+// inBatches calls fn with the values by batchSize, the parameters
+// of a statement are limited
+func inBatches(values []string, fn func(batch []string) error) error {
+	for len(values) > 0 {
+		n := len(values)
+		if n > batchSize {
+			n = batchSize
+		}
+		if err := fn(values[:n]); err != nil {
+			return err
+		}
+		values = values[n:]
+	}
+	return nil
+}
+
+func (g *Gorm) LatestRevision(source string, contentID uint) (*content.Revision, error) {
+	r := &content.Revision{}
+	if err := g.db.
+		Where("source = ? AND content_id = ?", source, contentID).
+		Order("id DESC").
+		Limit(1).
+		Find(r).
+		Error; err != nil {
+		return nil, err
+	}
+	if r.ID == 0 {
+		return nil, ErrNotFound
+	}
+	return r, nil
+}
+
+func (g *Gorm) CreateRevision(r *content.Revision) error {
+	return g.db.Create(r).Error
+}
@@ -0,0 +1,283 @@
+package repository
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hamza72x/islamqa-scrapper/content"
+	"github.com/hamza72x/islamqa-scrapper/sitemap"
+
+	"gorm.io/gorm"
+)
+
+// Memory is the in-memory implementation of the stores,
+// for running the sync logic without a database
+type Memory struct {
+	mu sync.Mutex
+
+	urls      map[string]*sitemap.URL
+	contents  map[string]*content.Content
+	related   map[uint]Related
+	revisions []*content.Revision
+
+	lastID uint
+}
+
+var (
+	_ URLStore     = (*Memory)(nil)
+	_ ContentStore = (*Memory)(nil)
+)
+
+func NewMemory() *Memory {
+	return &Memory{
+		urls:     map[string]*sitemap.URL{},
+		contents: map[string]*content.Content{},
+		related:  map[uint]Related{},
+	}
+}
+
+func (m *Memory) nextID() uint {
+	m.lastID++
+	return m.lastID
+}
+
+func (m *Memory) InsertMissing(urls []*sitemap.URL) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	inserted := int64(0)
+	for _, url := range urls {
+		if _, ok := m.urls[url.Loc]; ok {
+			continue
+		}
+		u := *url
+		u.ID = m.nextID()
+		m.urls[url.Loc] = &u
+		inserted++
+	}
+
+	return inserted, nil
+}
+
+func (m *Memory) UpdateLastMod(urls []*sitemap.URL) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	updated := int64(0)
+	for _, url := range urls {
+		if u, ok := m.urls[url.Loc]; ok && !u.LastMod.Equal(url.LastMod) {
+			u.LastMod = url.LastMod
+			updated++
+		}
+	}
+
+	return updated, nil
+}
+
+func (m *Memory) FindByLoc(loc string) (*sitemap.URL, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	url, ok := m.urls[loc]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	u := *url
+	return &u, nil
+}
+
+func (m *Memory) ListPending(cfg content.Config, limit int) ([]*sitemap.URL, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	covered := map[string]bool{}
+	for _, name := range cfg.CoveredBy() {
+		covered[name] = true
+	}
+
+	urls := []*sitemap.URL{}
+
+	for loc, url := range m.urls {
+		c, ok := m.contents[loc]
+		if !ok || c.DeletedAt.Valid || !c.LastModified.Equal(url.LastMod) || !covered[c.Crawl] {
+			u := *url
+			urls = append(urls, &u)
+		}
+	}
+
+	sort.Slice(urls, func(i, j int) bool {
+		return urls[i].LastMod.After(urls[j].LastMod)
+	})
+
+	if limit > 0 && len(urls) > limit {
+		urls = urls[:limit]
+	}
+
+	return urls, nil
+}
+
+func (m *Memory) ListLocs(sitemapURLs []string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sitemaps := map[string]bool{}
+	for _, s := range sitemapURLs {
+		sitemaps[s] = true
+	}
+
+	urls := []*sitemap.URL{}
+	for _, url := range m.urls {
+		if sitemaps[url.SitemapUrl] {
+			urls = append(urls, url)
+		}
+	}
+
+	sort.Slice(urls, func(i, j int) bool {
+		return urls[i].ID < urls[j].ID
+	})
+
+	locs := []string{}
+	for _, url := range urls {
+		locs = append(locs, url.Loc)
+	}
+
+	return locs, nil
+}
+
+func (m *Memory) Remove(locs []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, loc := range locs {
+		delete(m.urls, loc)
+	}
+
+	return nil
+}
+
+func (m *Memory) FindByURL(url string) (*content.Content, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, ok := m.contents[url]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	copied := *c
+	return &copied, nil
+}
+
+func (m *Memory) Upsert(c *content.Content) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if c.ID == 0 {
+		c.ID = m.nextID()
+		c.CreatedAt = now
+	}
+	c.UpdatedAt = now
+
+	copied := *c
+	m.contents[c.URL] = &copied
+
+	return nil
+}
+
+func (m *Memory) Delete(urls []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	for _, url := range urls {
+		if c, ok := m.contents[url]; ok && !c.DeletedAt.Valid {
+			c.DeletedAt = gorm.DeletedAt{Time: now, Valid: true}
+		}
+	}
+
+	return nil
+}
+
+func (m *Memory) Touch(c *content.Content) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.contents[c.URL]
+	if !ok {
+		return ErrNotFound
+	}
+	stored.LastModified = c.LastModified
+	stored.Hash = c.Hash
+	stored.Crawl = c.Crawl
+	stored.JSONLD = c.JSONLD
+	stored.Microdata = c.Microdata
+	stored.OpenGraph = c.OpenGraph
+	stored.PublishedAt = c.PublishedAt
+	stored.ModifiedAt = c.ModifiedAt
+	stored.Views = c.Views
+	stored.Author = c.Author
+	stored.Publisher = c.Publisher
+	stored.UpdatedAt = time.Now()
+
+	return nil
+}
+
+func (m *Memory) ReplaceRelated(contentID uint, related Related) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.related[contentID] = related
+
+	return nil
+}
+
+// RelatedOf returns the related rows of a content
+func (m *Memory) RelatedOf(contentID uint) Related {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.related[contentID]
+}
+
+func (m *Memory) LatestRevision(source string, contentID uint) (*content.Revision, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := len(m.revisions) - 1; i >= 0; i-- {
+		r := m.revisions[i]
+		if r.Source == source && r.ContentID == contentID {
+			copied := *r
+			return &copied, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func (m *Memory) CreateRevision(r *content.Revision) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r.ID = m.nextID()
+	copied := *r
+	m.revisions = append(m.revisions, &copied)
+
+	return nil
+}
+
+// Revisions returns the revisions of a content, oldest first
+func (m *Memory) Revisions(source string, contentID uint) []*content.Revision {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	revisions := []*content.Revision{}
+	for _, r := range m.revisions {
+		if r.Source == source && r.ContentID == contentID {
+			copied := *r
+			revisions = append(revisions, &copied)
+		}
+	}
+
+	return revisions
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/hamza72x/islamqa-scrapper/content"
+	"github.com/hamza72x/islamqa-scrapper/sitemap"
+)
+
+const SourceContents = content.RevisionSourceContents
+
+// ErrNotFound is returned by the finds of missing rows
+var ErrNotFound = errors.New("not found")
+
+// URLStore stores the sitemap urls
+type URLStore interface {
+	// InsertMissing inserts the urls whose loc isn't stored yet,
+	// the stored ones are kept as they are
+	// returns the number of inserted urls
+	InsertMissing(urls []*sitemap.URL) (int64, error)
+
+	// UpdateLastMod saves the lastmod of the stored urls changed
+	// in the sitemaps, their contents are pending again
+	// returns the number of updated urls
+	UpdateLastMod(urls []*sitemap.URL) (int64, error)
+
+	// FindByLoc returns ErrNotFound for a missing url
+	FindByLoc(loc string) (*sitemap.URL, error)
+
+	// ListPending lists the urls without a content, with a deleted
+	// content, a content of another last modified date, or crawled by
+	// a config not covering cfg, latest lastmod first, every pending
+	// url when limit is 0
+	ListPending(cfg content.Config, limit int) ([]*sitemap.URL, error)
+
+	// ListLocs lists the locs of the urls of the sitemaps
+	ListLocs(sitemapURLs []string) ([]string, error)
+
+	// Remove deletes the urls of locs, e.g. the ones gone from the sitemaps
+	Remove(locs []string) error
+}
+
+// Related are the rows parsed from a content, stored by content id
+type Related struct {
+	QuranCitations  []content.QuranCitation
+	HadithCitations []content.HadithCitation
+	References      []content.Reference
+	Categories      []content.Category
+}
+
+// ContentStore stores the contents, their related rows and revisions
+type ContentStore interface {
+	// FindByURL returns ErrNotFound for a missing content,
+	// the deleted contents are found, with their DeletedAt
+	FindByURL(url string) (*content.Content, error)
+
+	// Upsert creates a content without id, saves it otherwise,
+	// a deleted content is restored when its DeletedAt is cleared
+	Upsert(c *content.Content) error
+
+	// Delete soft deletes the contents of urls, the tombstones
+	// of the delta exports
+	Delete(urls []string) error
+
+	// Touch saves the last modified date, hash, crawl, structured data
+	// and metadata of an unchanged content, its updated_at is bumped
+	// so that the delta exports have the metadata
+	Touch(c *content.Content) error
+
+	// ReplaceRelated replaces the related rows of a content
+	ReplaceRelated(contentID uint, related Related) error
+
+	// LatestRevision returns ErrNotFound for a content without revisions
+	LatestRevision(source string, contentID uint) (*content.Revision, error)
+	CreateRevision(r *content.Revision) error
+}
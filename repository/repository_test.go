@@ -0,0 +1,297 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hamza72x/islamqa-scrapper/content"
+	"github.com/hamza72x/islamqa-scrapper/migrations"
+	"github.com/hamza72x/islamqa-scrapper/sitemap"
+	"github.com/hamza72x/islamqa-scrapper/storage/storagetest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// store is both stores of an implementation
+type store interface {
+	URLStore
+	ContentStore
+}
+
+// testStores runs a test on every implementation of the stores,
+// the database one on storagetest.Open
+func testStores(t *testing.T, test func(t *testing.T, s store)) {
+	t.Run("memory", func(t *testing.T) {
+		test(t, NewMemory())
+	})
+
+	t.Run("gorm", func(t *testing.T) {
+		db := storagetest.Open(t)
+		_, err := migrations.Up(db)
+		require.NoError(t, err)
+
+		test(t, NewGorm(db))
+	})
+}
+
+var (
+	day1 = time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	day2 = time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)
+	day3 = time.Date(2023, 1, 3, 0, 0, 0, 0, time.UTC)
+)
+
+func str(s string) *string {
+	return &s
+}
+
+func locs(urls []*sitemap.URL) []string {
+	locs := []string{}
+	for _, url := range urls {
+		locs = append(locs, url.Loc)
+	}
+	return locs
+}
+
+func TestInsertMissing(t *testing.T) {
+	testStores(t, func(t *testing.T, s store) {
+		inserted, err := s.InsertMissing([]*sitemap.URL{
+			{SitemapUrl: "sitemap-1.xml", Loc: "https://example.com/1", LastMod: day1},
+			{SitemapUrl: "sitemap-1.xml", Loc: "https://example.com/2", LastMod: day1},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), inserted)
+
+		inserted, err = s.InsertMissing([]*sitemap.URL{
+			{SitemapUrl: "sitemap-1.xml", Loc: "https://example.com/1", LastMod: day2},
+			{SitemapUrl: "sitemap-2.xml", Loc: "https://example.com/3", LastMod: day2},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), inserted)
+
+		url, err := s.FindByLoc("https://example.com/1")
+		require.NoError(t, err)
+		assert.Equal(t, "sitemap-1.xml", url.SitemapUrl)
+		assert.True(t, day1.Equal(url.LastMod), url.LastMod)
+
+		_, err = s.FindByLoc("https://example.com/4")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
+
+func TestUpdateLastMod(t *testing.T) {
+	testStores(t, func(t *testing.T, s store) {
+		_, err := s.InsertMissing([]*sitemap.URL{
+			{SitemapUrl: "sitemap.xml", Loc: "https://example.com/1", LastMod: day1},
+			{SitemapUrl: "sitemap.xml", Loc: "https://example.com/2", LastMod: day1},
+		})
+		require.NoError(t, err)
+
+		updated, err := s.UpdateLastMod([]*sitemap.URL{
+			{SitemapUrl: "sitemap.xml", Loc: "https://example.com/1", LastMod: day2},
+			{SitemapUrl: "sitemap.xml", Loc: "https://example.com/2", LastMod: day1},
+			{SitemapUrl: "sitemap.xml", Loc: "https://example.com/3", LastMod: day2},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), updated)
+
+		url, err := s.FindByLoc("https://example.com/1")
+		require.NoError(t, err)
+		assert.True(t, day2.Equal(url.LastMod), url.LastMod)
+
+		_, err = s.FindByLoc("https://example.com/3")
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		// the content of the changed url is pending again
+		require.NoError(t, s.Upsert(&content.Content{URL: "https://example.com/1", LastModified: day1, Crawl: content.CrawlFull}))
+		require.NoError(t, s.Upsert(&content.Content{URL: "https://example.com/2", LastModified: day1, Crawl: content.CrawlFull}))
+
+		urls, err := s.ListPending(content.Full, 0)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"https://example.com/1"}, locs(urls))
+	})
+}
+
+func TestListPending(t *testing.T) {
+	testStores(t, func(t *testing.T, s store) {
+		_, err := s.InsertMissing([]*sitemap.URL{
+			{SitemapUrl: "sitemap.xml", Loc: "https://example.com/new", LastMod: day1},
+			{SitemapUrl: "sitemap.xml", Loc: "https://example.com/full", LastMod: day1},
+			{SitemapUrl: "sitemap.xml", Loc: "https://example.com/light", LastMod: day2},
+			{SitemapUrl: "sitemap.xml", Loc: "https://example.com/changed", LastMod: day3},
+			{SitemapUrl: "sitemap.xml", Loc: "https://example.com/deleted", LastMod: day1},
+		})
+		require.NoError(t, err)
+
+		contents := []*content.Content{
+			{URL: "https://example.com/full", LastModified: day1, Crawl: content.CrawlFull},
+			{URL: "https://example.com/light", LastModified: day2, Crawl: content.CrawlLight},
+			{URL: "https://example.com/changed", LastModified: day1, Crawl: content.CrawlFull},
+			{URL: "https://example.com/deleted", LastModified: day1, Crawl: content.CrawlFull},
+		}
+		for _, c := range contents {
+			require.NoError(t, s.Upsert(c))
+		}
+		require.NoError(t, s.Delete([]string{"https://example.com/deleted"}))
+
+		urls, err := s.ListPending(content.Full, 0)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"https://example.com/changed", "https://example.com/light"}, locs(urls)[:2])
+		assert.ElementsMatch(t, []string{
+			"https://example.com/changed", "https://example.com/light",
+			"https://example.com/new", "https://example.com/deleted",
+		}, locs(urls))
+
+		// a full crawl covers a light one
+		urls, err = s.ListPending(content.Light, 0)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{
+			"https://example.com/changed", "https://example.com/new", "https://example.com/deleted",
+		}, locs(urls))
+
+		urls, err = s.ListPending(content.Full, 1)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"https://example.com/changed"}, locs(urls))
+	})
+}
+
+func TestListLocsRemove(t *testing.T) {
+	testStores(t, func(t *testing.T, s store) {
+		_, err := s.InsertMissing([]*sitemap.URL{
+			{SitemapUrl: "sitemap-1.xml", Loc: "https://example.com/1", LastMod: day1},
+			{SitemapUrl: "sitemap-1.xml", Loc: "https://example.com/2", LastMod: day1},
+			{SitemapUrl: "sitemap-2.xml", Loc: "https://example.com/3", LastMod: day1},
+		})
+		require.NoError(t, err)
+
+		found, err := s.ListLocs([]string{"sitemap-1.xml"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"https://example.com/1", "https://example.com/2"}, found)
+
+		found, err = s.ListLocs(nil)
+		require.NoError(t, err)
+		assert.Empty(t, found)
+
+		require.NoError(t, s.Remove([]string{"https://example.com/1", "https://example.com/3"}))
+
+		found, err = s.ListLocs([]string{"sitemap-1.xml", "sitemap-2.xml"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"https://example.com/2"}, found)
+
+		_, err = s.FindByLoc("https://example.com/1")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
+
+func TestDeleteRestore(t *testing.T) {
+	testStores(t, func(t *testing.T, s store) {
+		c := &content.Content{URL: "https://example.com/1", Title: str("Title"), LastModified: day1}
+		require.NoError(t, s.Upsert(c))
+		require.NotZero(t, c.ID)
+
+		_, err := s.FindByURL("https://example.com/2")
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		require.NoError(t, s.Delete([]string{"https://example.com/1", "https://example.com/2"}))
+
+		// the deleted contents are found, with their DeletedAt
+		deleted, err := s.FindByURL("https://example.com/1")
+		require.NoError(t, err)
+		assert.Equal(t, c.ID, deleted.ID)
+		assert.True(t, deleted.DeletedAt.Valid)
+
+		deleted.DeletedAt.Valid = false
+		deleted.Title = str("Restored")
+		require.NoError(t, s.Upsert(deleted))
+
+		restored, err := s.FindByURL("https://example.com/1")
+		require.NoError(t, err)
+		assert.Equal(t, c.ID, restored.ID)
+		assert.False(t, restored.DeletedAt.Valid)
+		assert.Equal(t, "Restored", *restored.Title)
+	})
+}
+
+func TestTouch(t *testing.T) {
+	testStores(t, func(t *testing.T, s store) {
+		c := &content.Content{URL: "https://example.com/1", Title: str("Title"), Crawl: content.CrawlLight, LastModified: day1}
+		require.NoError(t, s.Upsert(c))
+
+		created, err := s.FindByURL("https://example.com/1")
+		require.NoError(t, err)
+		time.Sleep(10 * time.Millisecond)
+
+		views := int64(10)
+		touched := *c
+		touched.Title = str("Not saved")
+		touched.LastModified = day2
+		touched.Crawl = content.CrawlFull
+		touched.Hash = "hash"
+		touched.Views = &views
+		require.NoError(t, s.Touch(&touched))
+
+		found, err := s.FindByURL("https://example.com/1")
+		require.NoError(t, err)
+		assert.Equal(t, "Title", *found.Title)
+		assert.True(t, day2.Equal(found.LastModified), found.LastModified)
+		assert.Equal(t, content.CrawlFull, found.Crawl)
+		assert.Equal(t, "hash", found.Hash)
+		require.NotNil(t, found.Views)
+		assert.Equal(t, views, *found.Views)
+		assert.True(t, found.UpdatedAt.After(created.UpdatedAt), found.UpdatedAt)
+	})
+}
+
+func TestRevisions(t *testing.T) {
+	testStores(t, func(t *testing.T, s store) {
+		c := &content.Content{URL: "https://example.com/1", Title: str("Title")}
+		require.NoError(t, s.Upsert(c))
+
+		_, err := s.LatestRevision(SourceContents, c.ID)
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		for _, title := range []string{"First", "Second"} {
+			require.NoError(t, s.CreateRevision(&content.Revision{
+				Source: SourceContents, ContentID: c.ID, URL: c.URL, Title: str(title), FetchedAt: day1,
+			}))
+		}
+		require.NoError(t, s.CreateRevision(&content.Revision{
+			Source: SourceContents, ContentID: c.ID + 1, Title: str("Other"), FetchedAt: day1,
+		}))
+
+		r, err := s.LatestRevision(SourceContents, c.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Second", *r.Title)
+	})
+}
+
+func TestReplaceRelated(t *testing.T) {
+	db := storagetest.Open(t)
+	_, err := migrations.Up(db)
+	require.NoError(t, err)
+
+	s := NewGorm(db)
+
+	c := &content.Content{URL: "https://example.com/1"}
+	require.NoError(t, s.Upsert(c))
+
+	require.NoError(t, s.ReplaceRelated(c.ID, Related{
+		References: []content.Reference{
+			{ContentID: c.ID, Position: 1, Text: "First"},
+			{ContentID: c.ID, Position: 2, Text: "Second"},
+		},
+		Categories: []content.Category{{ContentID: c.ID, TopicID: 1, Name: "Fasting"}},
+	}))
+	require.NoError(t, s.ReplaceRelated(c.ID, Related{
+		References: []content.Reference{{ContentID: c.ID, Position: 1, Text: "Only"}},
+	}))
+
+	references := []content.Reference{}
+	require.NoError(t, db.Where("content_id = ?", c.ID).Find(&references).Error)
+	require.Len(t, references, 1)
+	assert.Equal(t, "Only", references[0].Text)
+
+	categories := int64(0)
+	require.NoError(t, db.Model(&content.Category{}).Where("content_id = ?", c.ID).Count(&categories).Error)
+	assert.Zero(t, categories)
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/hamza72x/islamqa-scrapper/log"
+	"github.com/hamza72x/islamqa-scrapper/rules"
+	"github.com/hamza72x/islamqa-scrapper/sites"
+)
+
+// rulesCommand validates parsing rules and checks them against
+// their fixtures, the rules in use of every site without a file
+// usage: rules check [rules.json]
+func rulesCommand(args []string) {
+	if len(args) == 0 || args[0] != "check" || len(args) > 2 {
+		log.Fatal("usage: rules check [rules.json]")
+	}
+
+	if len(args) == 2 {
+		rs, err := rules.Load(args[1])
+		if err != nil {
+			log.Fatal("invalid rules: " + err.Error())
+		}
+		if err := rs.Check(os.DirFS(filepath.Dir(args[1]))); err != nil {
+			log.Fatal("fixtures failed: " + err.Error())
+		}
+		log.Ok(args[1], "is valid,", len(rs.Fixtures), "fixtures passed")
+		return
+	}
+
+	for _, site := range sites.All() {
+		rb, ok := site.(sites.RuleBased)
+		if !ok {
+			continue
+		}
+		rs, files := rb.Rules()
+		if err := rs.Check(files); err != nil {
+			log.Fatal(site.Name() + " fixtures failed: " + err.Error())
+		}
+		log.Ok(site.Name(), "rules are valid,", len(rs.Fixtures), "fixtures passed")
+	}
+}
+
+// useRules replaces the rules of the site of a rules file
+func useRules(file string) {
+	rs, err := rules.Load(file)
+	if err != nil {
+		log.Fatal("invalid rules: " + err.Error())
+	}
+
+	site, ok := sites.Get(rs.Site)
+	if !ok {
+		log.Fatal("unknown site: " + rs.Site)
+	}
+
+	rb, ok := site.(sites.RuleBased)
+	if !ok {
+		log.Fatal(rs.Site + " has no parsing rules")
+	}
+
+	if err := rb.UseRules(rs, os.DirFS(filepath.Dir(file))); err != nil {
+		log.Fatal("failed to use rules: " + err.Error())
+	}
+
+	log.Info("parsing", rs.Site, "by", file)
+}
@@ -0,0 +1,140 @@
+package rules
+
+import (
+	"strings"
+
+	"github.com/hamza72x/islamqa-scrapper/content"
+	"github.com/hamza72x/islamqa-scrapper/log"
+)
+
+// Extractors are the extractors of the rules, by kind and field,
+// the rule set is validated already
+func (rs *RuleSet) Extractors() content.Extractors {
+	extractors := content.Extractors{}
+
+	for i := range rs.Rules {
+		r := rs.Rules[i]
+
+		ruleKinds := r.Kinds
+		if len(ruleKinds) == 0 {
+			ruleKinds = []string{content.AnyKind}
+		}
+
+		for _, kind := range ruleKinds {
+			extractors.Register(kind, r.Field, r.extract)
+		}
+	}
+
+	return extractors
+}
+
+func (r Rule) extract(p *content.Page, c *content.Content) error {
+	value := r.Value(p)
+
+	if value == nil || len(*value) == 0 {
+		if r.Warn {
+			log.Warn("failed to parse", r.Field, "for", p.URL.Loc)
+		}
+	}
+
+	if err := fields[r.Field](c, value); err != nil {
+		log.Warn("failed to parse", r.Field, "for", p.URL.Loc+":", err)
+	}
+
+	return nil
+}
+
+// Value is the value of the first selector having one, or the
+// missing value, nil for MissingNull
+func (r Rule) Value(p *content.Page) *string {
+	for _, s := range r.Selectors {
+		if value, ok := s.value(p); ok && len(value) > 0 {
+			return &value
+		}
+	}
+
+	if r.Missing == MissingNull {
+		return nil
+	}
+
+	empty := ""
+	return &empty
+}
+
+func (s Selector) value(p *content.Page) (string, bool) {
+	value, ok := s.raw(p)
+	if !ok {
+		return "", false
+	}
+
+	for _, step := range s.Post {
+		value = step.apply(value)
+	}
+
+	return value, true
+}
+
+// raw is the value before the post-processing
+func (s Selector) raw(p *content.Page) (string, bool) {
+	if len(s.Structured) > 0 {
+		value, _ := p.Structured().Fields().Field(s.Structured)
+		return value, len(value) > 0
+	}
+
+	selection := p.Doc.Selection
+	for _, css := range s.Chain {
+		selection = selection.Find(css)
+	}
+
+	if selection.Length() == 0 {
+		return "", false
+	}
+
+	switch s.Value {
+	case ValueHTML:
+		html, err := selection.Html()
+		if err != nil {
+			return "", false
+		}
+		return html, true
+	case ValueAttr:
+		return selection.Attr(s.Attr)
+	}
+
+	return selection.Text(), true
+}
+
+func (step Step) apply(value string) string {
+	switch step.Op {
+	case OpTrim:
+		return strings.TrimSpace(value)
+	case OpCollapse:
+		return strings.Join(strings.Fields(value), " ")
+	case OpRegex:
+		m := step.regex.FindStringSubmatch(value)
+		if m == nil {
+			return ""
+		}
+		return m[step.Group]
+	case OpReplace:
+		return step.regex.ReplaceAllString(value, step.With)
+	}
+
+	return value
+}
+
+// ParseRelated parses the references and the categories of a content
+// from its body, none without the rules of them
+func (rs *RuleSet) ParseRelated(c *content.Content) ([]content.Reference, []content.Category) {
+	references, categories := []content.Reference{}, []content.Category{}
+
+	if refs := rs.References; refs != nil {
+		references = content.ParseReferences(c.Body, refs.Answer, refs.heading)
+	}
+
+	if cats := rs.Categories; cats != nil {
+		categories = content.ParseCategories(c.Body, cats.Links, cats.pattern)
+	}
+
+	return references, categories
+}
@@ -0,0 +1,141 @@
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hamza72x/islamqa-scrapper/content"
+	"github.com/hamza72x/islamqa-scrapper/sitemap"
+)
+
+// Fixture is a saved page and the fields expected of it
+type Fixture struct {
+	// File is the html page, relative to the rule set file
+	File string `json:"file"`
+
+	URL      string `json:"url"`
+	Kind     string `json:"kind,omitempty"`
+	Language string `json:"language,omitempty"`
+
+	// Expect are the values by field, null for a missing value,
+	// the fields left out aren't checked
+	Expect map[string]*string `json:"expect"`
+
+	// References are the texts of the expected references and
+	// Categories the expected categories, in order, not checked
+	// when left out
+	References []string          `json:"references,omitempty"`
+	Categories []FixtureCategory `json:"categories,omitempty"`
+}
+
+// FixtureCategory is an expected category
+type FixtureCategory struct {
+	TopicID uint   `json:"topic_id"`
+	Name    string `json:"name"`
+}
+
+// values read the parsed fields, the dates in RFC 3339
+var values = map[string]func(c *content.Content) *string{
+	content.ExtractTitle:     func(c *content.Content) *string { return c.Title },
+	content.ExtractQuestion:  func(c *content.Content) *string { return c.Question },
+	content.ExtractSummary:   func(c *content.Content) *string { return c.Summary },
+	content.ExtractAnswer:    func(c *content.Content) *string { return c.Content },
+	content.ExtractAuthor:    func(c *content.Content) *string { return c.Author },
+	content.ExtractPublisher: func(c *content.Content) *string { return c.Publisher },
+	content.ExtractPublished: func(c *content.Content) *string { return formatTime(c.PublishedAt) },
+	content.ExtractModified:  func(c *content.Content) *string { return formatTime(c.ModifiedAt) },
+	content.ExtractViews: func(c *content.Content) *string {
+		if c.Views == nil {
+			return nil
+		}
+		count := strconv.FormatInt(*c.Views, 10)
+		return &count
+	},
+}
+
+func formatTime(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	value := t.UTC().Format(time.RFC3339)
+	return &value
+}
+
+// Check parses the fixtures read from fsys by the rules,
+// the mismatching fields of every fixture are returned
+func (rs *RuleSet) Check(fsys fs.FS) error {
+	extractors := rs.Extractors()
+	errs := []error{}
+
+	for _, f := range rs.Fixtures {
+		b, err := fs.ReadFile(fsys, f.File)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		p, err := content.ParsePage(&sitemap.URL{Loc: f.URL}, string(b))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", f.File, err))
+			continue
+		}
+
+		c := &content.Content{URL: f.URL, Kind: f.Kind, Language: f.Language}
+
+		names := []string{}
+		for field := range f.Expect {
+			names = append(names, field)
+		}
+		sort.Strings(names)
+
+		cfg := content.Config{Name: "fixture", Extractors: names}
+		if err := cfg.Extract(extractors, p, c); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", f.File, err))
+			continue
+		}
+
+		for _, field := range names {
+			want, got := f.Expect[field], values[field](c)
+			if format(want) != format(got) {
+				errs = append(errs, fmt.Errorf("%s: %s is %s, expected %s", f.File, field, format(got), format(want)))
+			}
+		}
+
+		c.Body = p.Body
+		references, categories := rs.ParseRelated(c)
+
+		if f.References != nil {
+			got := []string{}
+			for _, r := range references {
+				got = append(got, r.Text)
+			}
+			if want := strings.Join(f.References, "\n"); strings.Join(got, "\n") != want {
+				errs = append(errs, fmt.Errorf("%s: references are %q, expected %q", f.File, got, f.References))
+			}
+		}
+
+		if f.Categories != nil {
+			got := []FixtureCategory{}
+			for _, category := range categories {
+				got = append(got, FixtureCategory{TopicID: category.TopicID, Name: category.Name})
+			}
+			if fmt.Sprint(got) != fmt.Sprint(f.Categories) {
+				errs = append(errs, fmt.Errorf("%s: categories are %v, expected %v", f.File, got, f.Categories))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func format(value *string) string {
+	if value == nil {
+		return "null"
+	}
+	return `"` + strings.ReplaceAll(*value, "\n", `\n`) + `"`
+}
@@ -0,0 +1,424 @@
+package rules
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hamza72x/islamqa-scrapper/content"
+	"github.com/hamza72x/islamqa-scrapper/dates"
+	"github.com/hamza72x/islamqa-scrapper/normalize"
+	"github.com/hamza72x/islamqa-scrapper/structured"
+
+	"github.com/andybalholm/cascadia"
+)
+
+const (
+	ValueText = "text"
+	ValueHTML = "html"
+	ValueAttr = "attr"
+
+	MissingEmpty = "empty"
+	MissingNull  = "null"
+
+	OpTrim     = "trim"
+	OpCollapse = "collapse"
+	OpRegex    = "regex"
+	OpReplace  = "replace"
+)
+
+// RuleSet are the parsing rules of the pages of a site, e.g.
+/*
+	{
+		"site": "islamqa",
+		"rules": [
+			{
+				"field": "title",
+				"selectors": [
+					{"structured": "title"},
+					{"chain": ["div.single-layout__title", "h1"], "post": [{"op": "trim"}]}
+				]
+			}
+		],
+		"references": {"answer": "section.single_fatwa__answer__body div.content", "heading": "(?i)^\\s*references?\\s*:?\\s*$"},
+		"categories": {"links": "nav.breadcrumb a[href]", "pattern": "/categories/topics/(\\d+)"},
+		"fixtures": [
+			{"file": "fixtures/fatwa.html", "url": "https://islamqa.info/en/answers/1", "kind": "fatwa", "expect": {"title": "..."}, "categories": [{"topic_id": 52, "name": "..."}]}
+		]
+	}
+*/
+type RuleSet struct {
+	// Site is the name of the site adapter parsing by the rules
+	Site  string `json:"site"`
+	Rules []Rule `json:"rules"`
+
+	// References and Categories parse the related rows of the
+	// pages, there are none without them, see ParseRelated
+	References *References `json:"references,omitempty"`
+	Categories *Categories `json:"categories,omitempty"`
+
+	// Fixtures are the pages the rules are checked against, see Check
+	Fixtures []Fixture `json:"fixtures,omitempty"`
+}
+
+// References are the footnotes and the reference section of an answer
+type References struct {
+	// Answer is the css selector of the answer, the body of the
+	// page when it matches nothing
+	Answer string `json:"answer"`
+
+	// Heading is the pattern of the heading of the reference section,
+	// the trailing numbered paragraphs are references without it
+	Heading string `json:"heading,omitempty"`
+
+	heading *regexp.Regexp
+}
+
+// Categories are the topic links of a page
+type Categories struct {
+	// Links is the css selector of the links
+	Links string `json:"links"`
+
+	// Pattern is matched against the href of a link, its first
+	// group is the topic id
+	Pattern string `json:"pattern"`
+
+	pattern *regexp.Regexp
+}
+
+// Rule parses a field of the pages of kinds
+type Rule struct {
+	// Field is content.ExtractTitle, ExtractQuestion, ExtractAnswer,
+	// ExtractSummary, or the metadata ExtractPublished, ExtractModified
+	// (dates, see dates.Parse), ExtractViews, ExtractAuthor
+	// and ExtractPublisher
+	Field string `json:"field"`
+
+	// Kinds are content.KindFatwa or content.KindArticle, every kind
+	// when empty, the rule of the page kind is preferred
+	Kinds []string `json:"kinds,omitempty"`
+
+	// Selectors are tried in order, the first non-empty value is
+	// the field, the next ones are fallbacks
+	Selectors []Selector `json:"selectors"`
+
+	// Missing is the field when no selector has a value,
+	// MissingEmpty (default) or MissingNull
+	Missing string `json:"missing,omitempty"`
+
+	// Warn logs the pages without a value
+	Warn bool `json:"warn,omitempty"`
+}
+
+// Selector is a value of a page
+type Selector struct {
+	// Chain are css selectors, each one narrows the selection
+	// of the previous one
+	Chain []string `json:"chain,omitempty"`
+
+	// Structured is a field of the structured data of the page
+	// instead of the Chain, e.g. structured.FieldQuestion, the
+	// JSON-LD, microdata or OpenGraph value of it
+	Structured string `json:"structured,omitempty"`
+
+	// Value is ValueText (default), ValueHTML of the first element,
+	// or ValueAttr, the attribute Attr of the first element
+	Value string `json:"value,omitempty"`
+	Attr  string `json:"attr,omitempty"`
+
+	// Post are applied to the value in order
+	Post []Step `json:"post,omitempty"`
+}
+
+// Step is a post-processing of a value
+type Step struct {
+	// Op is OpTrim, OpCollapse of the white space, OpRegex keeping
+	// the Group of the first match of Pattern, empty without a match,
+	// or OpReplace replacing the matches of Pattern by With
+	Op      string `json:"op"`
+	Pattern string `json:"pattern,omitempty"`
+	Group   int    `json:"group,omitempty"`
+	With    string `json:"with,omitempty"`
+
+	regex *regexp.Regexp
+}
+
+// fields set the parsed values, a value failing to parse is nil
+var fields = map[string]func(c *content.Content, value *string) error{
+	content.ExtractTitle:     func(c *content.Content, value *string) error { c.Title = value; return nil },
+	content.ExtractQuestion:  func(c *content.Content, value *string) error { c.Question = value; return nil },
+	content.ExtractSummary:   func(c *content.Content, value *string) error { c.Summary = value; return nil },
+	content.ExtractAuthor:    func(c *content.Content, value *string) error { c.Author = empty(value); return nil },
+	content.ExtractPublisher: func(c *content.Content, value *string) error { c.Publisher = empty(value); return nil },
+	content.ExtractAnswer: func(c *content.Content, value *string) error {
+		c.Content = value
+		c.HTML, c.Markdown, c.Text = nil, nil, nil
+		if value == nil {
+			return nil
+		}
+
+		converted := content.Convert(*value, c.Language)
+		c.HTML = &converted.HTML
+		c.Markdown = &converted.Markdown
+		c.Text = &converted.Text
+
+		return nil
+	},
+	content.ExtractPublished: func(c *content.Content, value *string) (err error) {
+		c.PublishedAt, err = parseDate(value, c.Language)
+		return err
+	},
+	content.ExtractModified: func(c *content.Content, value *string) (err error) {
+		c.ModifiedAt, err = parseDate(value, c.Language)
+		return err
+	},
+	content.ExtractViews: func(c *content.Content, value *string) (err error) {
+		c.Views, err = parseCount(value)
+		return err
+	},
+}
+
+// empty is nil for an empty value
+func empty(value *string) *string {
+	if value == nil || len(*value) == 0 {
+		return nil
+	}
+	return value
+}
+
+// parseDate is nil for an empty value
+func parseDate(value *string, language string) (*time.Time, error) {
+	if empty(value) == nil {
+		return nil, nil
+	}
+
+	t, err := dates.Parse(*value, language)
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+// parseCount reads the digits of a count, e.g. "12,345 views",
+// it's nil for an empty value
+func parseCount(value *string) (*int64, error) {
+	if empty(value) == nil {
+		return nil, nil
+	}
+
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, normalize.Digits(*value))
+
+	count, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return nil, errors.New("invalid count: " + *value)
+	}
+
+	return &count, nil
+}
+
+var kinds = map[string]bool{
+	content.KindFatwa:   true,
+	content.KindArticle: true,
+}
+
+// Load reads and validates the rule set of a JSON file
+func Load(file string) (*RuleSet, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	rs, err := Parse(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+
+	return rs, nil
+}
+
+// Parse reads and validates a JSON rule set, unknown keys are errors
+func Parse(b []byte) (*RuleSet, error) {
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.DisallowUnknownFields()
+
+	rs := &RuleSet{}
+	if err := d.Decode(rs); err != nil {
+		return nil, err
+	}
+
+	if err := rs.Validate(); err != nil {
+		return nil, err
+	}
+
+	return rs, nil
+}
+
+// Validate checks every rule, the css selectors and the regular
+// expressions are compiled, the errors of every rule are returned
+func (rs *RuleSet) Validate() error {
+	errs := []error{}
+
+	fail := func(format string, a ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, a...))
+	}
+
+	if len(rs.Site) == 0 {
+		fail("site is missing")
+	}
+
+	if len(rs.Rules) == 0 {
+		fail("no rules")
+	}
+
+	// a field has a rule per kind at most
+	seen := map[string]bool{}
+
+	for i := range rs.Rules {
+		r := &rs.Rules[i]
+		name := "rule " + strconv.Itoa(i+1) + " (" + r.Field + ")"
+
+		if _, ok := fields[r.Field]; !ok {
+			fail("%s: unknown field %q", name, r.Field)
+		}
+
+		ruleKinds := r.Kinds
+		if len(ruleKinds) == 0 {
+			ruleKinds = []string{content.AnyKind}
+		}
+		for _, kind := range ruleKinds {
+			if kind != content.AnyKind && !kinds[kind] {
+				fail("%s: unknown kind %q", name, kind)
+			}
+			if seen[kind+"/"+r.Field] {
+				fail("%s: duplicate rule of kind %q", name, kind)
+			}
+			seen[kind+"/"+r.Field] = true
+		}
+
+		switch r.Missing {
+		case "", MissingEmpty, MissingNull:
+		default:
+			fail("%s: unknown missing %q", name, r.Missing)
+		}
+
+		if len(r.Selectors) == 0 {
+			fail("%s: no selectors", name)
+		}
+
+		for j := range r.Selectors {
+			s := &r.Selectors[j]
+			name := name + " selector " + strconv.Itoa(j+1)
+
+			if len(s.Structured) > 0 {
+				if _, ok := (structured.Fields{}).Field(s.Structured); !ok {
+					fail("%s: unknown structured field %q", name, s.Structured)
+				}
+				if len(s.Chain) > 0 || len(s.Value) > 0 || len(s.Attr) > 0 {
+					fail("%s: chain, value or attr of a structured field", name)
+				}
+			} else if len(s.Chain) == 0 {
+				fail("%s: empty chain", name)
+			}
+			for _, css := range s.Chain {
+				if _, err := cascadia.ParseGroup(css); err != nil {
+					fail("%s: invalid css %q: %v", name, css, err)
+				}
+			}
+
+			switch s.Value {
+			case "", ValueText, ValueHTML:
+				if len(s.Attr) > 0 {
+					fail("%s: attr of a %s value", name, s.Value)
+				}
+			case ValueAttr:
+				if len(s.Attr) == 0 {
+					fail("%s: attr is missing", name)
+				}
+			default:
+				fail("%s: unknown value %q", name, s.Value)
+			}
+
+			for k := range s.Post {
+				step := &s.Post[k]
+				name := name + " step " + strconv.Itoa(k+1)
+
+				switch step.Op {
+				case OpTrim, OpCollapse:
+				case OpRegex, OpReplace:
+					regex, err := regexp.Compile(step.Pattern)
+					if err != nil {
+						fail("%s: invalid pattern %q: %v", name, step.Pattern, err)
+						continue
+					}
+					if step.Op == OpRegex && (step.Group < 0 || step.Group > regex.NumSubexp()) {
+						fail("%s: no group %d in %q", name, step.Group, step.Pattern)
+					}
+					step.regex = regex
+				default:
+					fail("%s: unknown op %q", name, step.Op)
+				}
+			}
+		}
+	}
+
+	if refs := rs.References; refs != nil {
+		if _, err := cascadia.ParseGroup(refs.Answer); err != nil {
+			fail("references: invalid css %q: %v", refs.Answer, err)
+		}
+		if len(refs.Heading) > 0 {
+			regex, err := regexp.Compile(refs.Heading)
+			if err != nil {
+				fail("references: invalid heading %q: %v", refs.Heading, err)
+			}
+			refs.heading = regex
+		}
+	}
+
+	if categories := rs.Categories; categories != nil {
+		if _, err := cascadia.ParseGroup(categories.Links); err != nil {
+			fail("categories: invalid css %q: %v", categories.Links, err)
+		}
+		regex, err := regexp.Compile(categories.Pattern)
+		if err != nil {
+			fail("categories: invalid pattern %q: %v", categories.Pattern, err)
+		} else if regex.NumSubexp() < 1 {
+			fail("categories: no group in %q", categories.Pattern)
+		}
+		categories.pattern = regex
+	}
+
+	for i, f := range rs.Fixtures {
+		name := "fixture " + strconv.Itoa(i+1)
+		if len(f.File) == 0 || len(f.URL) == 0 {
+			fail("%s: file or url is missing", name)
+		}
+		if len(f.Kind) > 0 && !kinds[f.Kind] {
+			fail("%s: unknown kind %q", name, f.Kind)
+		}
+		for field := range f.Expect {
+			if _, ok := fields[field]; !ok {
+				fail("%s: unknown field %q", name, field)
+			}
+		}
+		if f.References != nil && rs.References == nil {
+			fail("%s: references without a references rule", name)
+		}
+		if f.Categories != nil && rs.Categories == nil {
+			fail("%s: categories without a categories rule", name)
+		}
+	}
+
+	return errors.Join(errs...)
+}
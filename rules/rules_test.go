@@ -0,0 +1,127 @@
+package rules
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/hamza72x/islamqa-scrapper/content"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPage = `<html><body>
+	<nav class="breadcrumb"><a href="/en/topics/7/prayer">Prayer</a></nav>
+	<h1> Title </h1>
+	<div class="answer">
+		<p>Answer [1]</p>
+		<h3>Sources</h3>
+		<p>[1] Narrated by Muslim</p>
+	</div>
+</body></html>`
+
+func TestParse(t *testing.T) {
+	rule := `"rules": [{"field": "title", "selectors": [{"chain": ["h1"], "post": [{"op": "trim"}]}]}]`
+
+	tests := []struct {
+		name string
+		json string
+		err  string
+	}{
+		{
+			name: "valid",
+			json: `{"site": "test", ` + rule + `,
+				"references": {"answer": "div.answer", "heading": "^Sources$"},
+				"categories": {"links": "nav a[href]", "pattern": "/topics/(\\d+)"}}`,
+		},
+		{
+			name: "unknown key",
+			json: `{"site": "test", ` + rule + `, "related": {}}`,
+			err:  "unknown field",
+		},
+		{
+			name: "invalid references",
+			json: `{"site": "test", ` + rule + `, "references": {"answer": "div[", "heading": "("}}`,
+			err:  "references: invalid css",
+		},
+		{
+			name: "category pattern without a group",
+			json: `{"site": "test", ` + rule + `, "categories": {"links": "a", "pattern": "/topics/\\d+"}}`,
+			err:  "categories: no group",
+		},
+		{
+			name: "fixture categories without a rule",
+			json: `{"site": "test", ` + rule + `, "fixtures": [{"file": "page.html", "url": "https://example.com/en/answers/1", "categories": []}]}`,
+			err:  "categories without a categories rule",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.json))
+			if len(tt.err) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tt.err)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	rs, err := Parse([]byte(`{
+		"site": "test",
+		"rules": [{"field": "title", "selectors": [{"chain": ["h1"], "post": [{"op": "trim"}]}]}],
+		"references": {"answer": "div.answer", "heading": "^Sources$"},
+		"categories": {"links": "nav a[href]", "pattern": "/topics/(\\d+)"},
+		"fixtures": [
+			{
+				"file": "page.html", "url": "https://example.com/en/answers/1",
+				"expect": {"title": "Title"},
+				"references": ["Narrated by Muslim"],
+				"categories": [{"topic_id": 7, "name": "Prayer"}]
+			},
+			{
+				"file": "page.html", "url": "https://example.com/en/answers/1",
+				"expect": {"title": "Other"},
+				"references": [],
+				"categories": [{"topic_id": 8, "name": "Prayer"}]
+			}
+		]
+	}`))
+	require.NoError(t, err)
+
+	fsys := fstest.MapFS{"page.html": {Data: []byte(testPage)}}
+
+	err = rs.Check(fsys)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `page.html: title is "Title", expected "Other"`)
+	assert.Contains(t, err.Error(), `page.html: references are ["Narrated by Muslim"], expected []`)
+	assert.Contains(t, err.Error(), `page.html: categories are [{7 Prayer}], expected [{8 Prayer}]`)
+
+	rs.Fixtures = rs.Fixtures[:1]
+	assert.NoError(t, rs.Check(fsys))
+}
+
+func TestParseRelated(t *testing.T) {
+	c := &content.Content{Body: testPage}
+
+	rs := &RuleSet{}
+	references, categories := rs.ParseRelated(c)
+	assert.Empty(t, references)
+	assert.Empty(t, categories)
+
+	rs, err := Parse([]byte(`{
+		"site": "test",
+		"rules": [{"field": "title", "selectors": [{"chain": ["h1"]}]}],
+		"references": {"answer": "div.answer", "heading": "^Sources$"},
+		"categories": {"links": "nav a[href]", "pattern": "/topics/(\\d+)"}
+	}`))
+	require.NoError(t, err)
+
+	references, categories = rs.ParseRelated(c)
+	assert.Equal(t, []content.Reference{{Position: 1, Marker: "1", Text: "Narrated by Muslim"}}, references)
+	assert.Equal(t, []content.Category{{TopicID: 7, Name: "Prayer"}}, categories)
+}
@@ -7,7 +7,9 @@ import (
 
 	"github.com/hamza72x/islamqa-scrapper/content"
 	"github.com/hamza72x/islamqa-scrapper/log"
+	"github.com/hamza72x/islamqa-scrapper/repository"
 	"github.com/hamza72x/islamqa-scrapper/sitemap"
+	"github.com/hamza72x/islamqa-scrapper/sites"
 
 	"gorm.io/gorm"
 )
@@ -17,24 +19,42 @@ const (
 )
 
 type Scapper struct {
-	db *gorm.DB
+	urls     repository.URLStore
+	contents repository.ContentStore
+
+	// sites are the adapters of the synced urls
+	sites []sites.Site
+
+	// the pages are fetched by, replaced to sync without network
+	getSitemap func(url string) (sitemap.Sitemap, error)
+	fetch      func(url *sitemap.URL) (*content.Page, error)
 }
 
+// New is a Scapper of the registered sites, see sites.Register
 func New(db *gorm.DB) *Scapper {
+	store := repository.NewGorm(db)
+	return NewWithStores(store, store, sites.All())
+}
+
+// NewWithStores is a Scapper of any stores, e.g. repository.Memory
+func NewWithStores(urls repository.URLStore, contents repository.ContentStore, sites []sites.Site) *Scapper {
 	return &Scapper{
-		db: db,
+		urls:       urls,
+		contents:   contents,
+		sites:      sites,
+		getSitemap: sitemap.Get,
+		fetch:      content.Fetch,
 	}
 }
 
-func (s *Scapper) SyncContents() error {
+// SyncContents fetches the pending urls, latest first, and parses them
+// by the extractors of cfg, every pending url when limit is 0
+func (s *Scapper) SyncContents(cfg content.Config, limit int) error {
 	ch := make(chan int, THREADS)
 	wg := &sync.WaitGroup{}
 
-	urls := []*sitemap.URL{}
-
-	if err := s.db.
-		Find(&urls).
-		Error; err != nil {
+	urls, err := s.urls.ListPending(cfg, limit)
+	if err != nil {
 		return err
 	}
 
@@ -50,7 +70,7 @@ func (s *Scapper) SyncContents() error {
 			defer wg.Done()
 			ch <- i
 
-			if err := s.syncContent(url); err != nil {
+			if err := s.syncContent(cfg, url); err != nil {
 				log.Err(err)
 			}
 
@@ -69,12 +89,28 @@ func (s *Scapper) SyncContents() error {
 	return nil
 }
 
+// Sitemaps are the sitemaps of every site
+func (s *Scapper) Sitemaps() []string {
+	sitemaps := []string{}
+	for _, site := range s.sites {
+		sitemaps = append(sitemaps, site.Sitemaps()...)
+	}
+	return sitemaps
+}
+
+// SyncSitemaps inserts the new urls of the sitemaps and saves the changed
+// lastmods, when every sitemap is synced the stored urls not in them
+// anymore are removed and their contents deleted
 func (s *Scapper) SyncSitemaps(sitemaps []string) []error {
 	ch := make(chan int, THREADS)
 	wg := &sync.WaitGroup{}
+	mu := &sync.Mutex{}
 
 	errs := []error{}
 
+	// the locs of every sitemap
+	seen := map[string]bool{}
+
 	// sync all site maps
 	for i, url := range sitemaps {
 		wg.Add(1)
@@ -83,9 +119,16 @@ func (s *Scapper) SyncSitemaps(sitemaps []string) []error {
 			defer wg.Done()
 			ch <- i
 
-			if err := s.syncSitemap(url); err != nil {
+			locs, err := s.syncSitemap(url)
+
+			mu.Lock()
+			if err != nil {
 				errs = append(errs, err)
 			}
+			for _, loc := range locs {
+				seen[loc] = true
+			}
+			mu.Unlock()
 
 			<-ch
 		}(i, url)
@@ -93,192 +136,234 @@ func (s *Scapper) SyncSitemaps(sitemaps []string) []error {
 
 	wg.Wait()
 
-	return errs
+	// a url may have moved to a sitemap that failed
+	if len(errs) > 0 {
+		return errs
+	}
+
+	stored, err := s.urls.ListLocs(sitemaps)
+	if err != nil {
+		return []error{err}
+	}
+
+	gone := []string{}
+	for _, loc := range stored {
+		if !seen[loc] {
+			gone = append(gone, loc)
+		}
+	}
+
+	if err := s.remove(gone); err != nil {
+		return []error{err}
+	}
+
+	return nil
 }
 
-func (s *Scapper) syncSitemap(sitemapURL string) error {
+// syncSitemap inserts the new urls of a sitemap and saves the changed
+// lastmods, returns its locs
+func (s *Scapper) syncSitemap(sitemapURL string) ([]string, error) {
 
 	log.Info("syncing sitemap", sitemapURL)
 
-	smap, err := sitemap.Get(sitemapURL)
+	smap, err := s.getSitemap(sitemapURL)
 
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	locs := []string{}
 	for _, url := range smap.URLS {
-		existingCounter := int64(0)
-
 		url.SitemapUrl = sitemapURL
+		locs = append(locs, url.Loc)
+	}
 
-		if err := s.db.
-			Model(&sitemap.URL{}).
-			Where("loc = ?", url.Loc).
-			Count(&existingCounter).
-			Error; err != nil {
-			return err
-		}
+	inserted, err := s.urls.InsertMissing(smap.URLS)
+	if err != nil {
+		return nil, err
+	}
 
-		if existingCounter > 0 {
-			continue
-		}
+	// a changed lastmod makes the content pending again
+	updated, err := s.urls.UpdateLastMod(smap.URLS)
+	if err != nil {
+		return nil, err
+	}
 
-		if err := s.db.
-			Create(url).
-			Error; err != nil {
-			return err
-		}
+	log.Ok("completed syncing", sitemapURL+",", inserted, "new urls,", updated, "changed urls")
+
+	return locs, nil
+}
+
+// remove deletes the contents of the gone urls, and the urls
+func (s *Scapper) remove(locs []string) error {
+	if len(locs) == 0 {
+		return nil
+	}
+
+	if err := s.contents.Delete(locs); err != nil {
+		return err
+	}
+
+	if err := s.urls.Remove(locs); err != nil {
+		return err
 	}
 
-	log.Ok("completed syncing", sitemapURL)
+	log.Ok("removed", len(locs), "gone urls")
 
 	return nil
 }
 
-func (s *Scapper) syncContent(url *sitemap.URL) error {
-	existingContent := &content.Content{}
+func (s *Scapper) syncContent(cfg content.Config, url *sitemap.URL) error {
+	site, _, ok := sites.ForURL(s.sites, url.Loc)
+	if !ok {
+		return errors.New("no site for " + url.Loc)
+	}
 
-	if err := s.db.
-		Model(&content.Content{}).
-		Where("url = ?", url.Loc).
-		First(existingContent).
-		Error; err != nil {
-		if !errors.Is(err, gorm.ErrRecordNotFound) {
+	existingContent, err := s.contents.FindByURL(url.Loc)
+	if err != nil {
+		if !errors.Is(err, repository.ErrNotFound) {
 			return err
 		}
+		existingContent = &content.Content{}
 	}
 
-	if existingContent.ID > 0 {
+	deleted := existingContent.DeletedAt.Valid
+
+	// unchanged since the last sync, by a config covering cfg
+	if existingContent.ID > 0 && !deleted && existingContent.LastModified.Equal(url.LastMod) && covers(cfg, existingContent.Crawl) {
+		// the same time stored with another offset is pending as
+		// SQLite compares them as text, it's saved as the lastmod
+		if offset(existingContent.LastModified) != offset(url.LastMod) {
+			existingContent.LastModified = url.LastMod
+			return s.contents.Touch(existingContent)
+		}
 		return nil
 	}
 
-	newContent, err := content.New(url)
+	page, err := s.fetch(url)
+	if errors.Is(err, content.ErrGone) {
+		return s.remove([]string{url.Loc})
+	}
 	if err != nil {
 		return err
 	}
 
+	// the fields of the extractors not in cfg are kept,
+	// a deleted content is restored
+	newContent := *existingContent
+	newContent.DeletedAt = gorm.DeletedAt{}
+	if err := sites.Apply(site, cfg, page, &newContent); err != nil {
+		return err
+	}
+
 	// update the content if it exists
 	if existingContent.ID > 0 {
 
-		if existingContent.Title == newContent.Title &&
-			existingContent.Content == newContent.Content &&
-			existingContent.Summary == newContent.Summary &&
-			existingContent.Body == newContent.Body {
-			return nil
+		// the hash is computed, the rows synced before the hashes have none
+		// the metadata, e.g. the views, changes without a revision
+		if !deleted && existingContent.ContentHash() == newContent.Hash {
+			return s.contents.Touch(&newContent)
 		}
 
-		existingContent.Title = newContent.Title
-		existingContent.Content = newContent.Content
-		existingContent.Summary = newContent.Summary
-		existingContent.Body = newContent.Body
+		previous := content.NewRevision(existingContent, existingContent.UpdatedAt)
 
-		if err := s.db.Save(existingContent).Error; err != nil {
+		if err := s.contents.Upsert(&newContent); err != nil {
 			return err
 		}
 
-		return nil
+		if err := s.saveRevision(previous, content.NewRevision(&newContent, time.Now())); err != nil {
+			return err
+		}
+
+		return s.syncRelated(site, cfg, &newContent)
 	}
 
 	// otherwise create a new one
-	if err := s.db.Create(newContent).Error; err != nil {
+	if err := s.contents.Upsert(&newContent); err != nil {
 		return err
 	}
 
-	return nil
-}
-
-// for ContentV2
-func (s *Scapper) SyncContentsV2() error {
-	ch := make(chan int, THREADS)
-	wg := &sync.WaitGroup{}
-
-	urls := []*sitemap.URL{}
-
-	if err := s.db.
-		Limit(1000).
-		Order("last_mod desc").
-		Find(&urls).
-		Error; err != nil {
+	if err := s.saveRevision(nil, content.NewRevision(&newContent, time.Now())); err != nil {
 		return err
 	}
 
-	count := len(urls)
-	completed := 0
-
-	log.Ok("total urls to sync:", count)
-	time.Sleep(1 * time.Second)
-
-	for i, url := range urls {
-		wg.Add(1)
-		go func(i int, url *sitemap.URL) {
-			defer wg.Done()
-			ch <- i
-
-			if err := s.syncContentV2(url); err != nil {
-				log.Err(err)
-			}
-
-			completed++
+	return s.syncRelated(site, cfg, &newContent)
+}
 
-			if completed%100 == 0 {
-				log.Ok("completed", completed, "out of", count)
-			}
+func offset(t time.Time) int {
+	_, seconds := t.Zone()
+	return seconds
+}
 
-			<-ch
-		}(i, url)
+func covers(cfg content.Config, crawl string) bool {
+	for _, name := range cfg.CoveredBy() {
+		if name == crawl {
+			return true
+		}
 	}
-
-	wg.Wait()
-
-	return nil
+	return false
 }
 
-func (s *Scapper) syncContentV2(url *sitemap.URL) error {
-	existingContent := &content.ContentV2{}
+// saveRevision stores the current revision of a content when it differs
+// from the latest stored one, the previous revision is stored first
+// for the contents synced before the revisions were kept
+func (s *Scapper) saveRevision(previous *content.Revision, current *content.Revision) error {
+	latest, err := s.contents.LatestRevision(current.Source, current.ContentID)
+	if err != nil {
+		if !errors.Is(err, repository.ErrNotFound) {
+			return err
+		}
+		latest = nil
+	}
 
-	if err := s.db.
-		Model(&content.ContentV2{}).
-		Where("url = ?", url.Loc).
-		First(existingContent).
-		Error; err != nil {
-		if !errors.Is(err, gorm.ErrRecordNotFound) {
+	if latest == nil && previous != nil {
+		if err := s.contents.CreateRevision(previous); err != nil {
 			return err
 		}
+		latest = previous
 	}
 
-	if existingContent.ID > 0 && existingContent.LastModified == url.LastMod {
+	if latest != nil && latest.Hash == current.Hash {
 		return nil
 	}
 
-	newContent, err := content.NewV2(url)
-	if err != nil {
-		return err
+	return s.contents.CreateRevision(current)
+}
+
+// syncRelated replaces the stored quran, hadith citations,
+// references and categories of the content, they're parsed from
+// the body, kept as they are when cfg doesn't extract it
+func (s *Scapper) syncRelated(site sites.Site, cfg content.Config, c *content.Content) error {
+	if !cfg.Has(content.ExtractBody) {
+		return nil
 	}
 
-	// update the content if it exists
-	if existingContent.ID > 0 {
+	text := c.PlainText()
 
-		if existingContent.Title == newContent.Title &&
-			existingContent.Content == newContent.Content &&
-			existingContent.LastModified == newContent.LastModified {
-			return nil
-		}
+	quranCitations := content.ExtractQuranCitations(text)
+	hadithCitations := content.ExtractHadithCitations(text)
+	references, categories := site.ParseRelated(c)
 
-		existingContent.Title = newContent.Title
-		existingContent.Content = newContent.Content
-		existingContent.LastModified = newContent.LastModified
+	for i := range quranCitations {
+		quranCitations[i].ContentID = c.ID
+	}
 
-		if err := s.db.Save(existingContent).Error; err != nil {
-			return err
-		}
+	for i := range hadithCitations {
+		hadithCitations[i].ContentID = c.ID
+	}
 
-		return nil
+	for i := range references {
+		references[i].ContentID = c.ID
 	}
 
-	// otherwise create a new one
-	if err := s.db.Create(newContent).Error; err != nil {
-		return err
+	for i := range categories {
+		categories[i].ContentID = c.ID
 	}
 
-	return nil
+	return s.contents.ReplaceRelated(c.ID, repository.Related{
+		QuranCitations:  quranCitations,
+		HadithCitations: hadithCitations,
+		References:      references,
+		Categories:      categories,
+	})
 }
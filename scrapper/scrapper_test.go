@@ -0,0 +1,367 @@
+package scrapper
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/hamza72x/islamqa-scrapper/content"
+	"github.com/hamza72x/islamqa-scrapper/migrations"
+	"github.com/hamza72x/islamqa-scrapper/repository"
+	"github.com/hamza72x/islamqa-scrapper/sitemap"
+	"github.com/hamza72x/islamqa-scrapper/sites"
+	"github.com/hamza72x/islamqa-scrapper/storage"
+	"github.com/hamza72x/islamqa-scrapper/storage/storagetest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSitemap = "https://example.com/sitemap.xml"
+
+// testSite is a site of example.com, the title of a page is its h1,
+// the answer its paragraph
+type testSite struct{}
+
+func (testSite) Name() string       { return "test" }
+func (testSite) Host() string       { return "example.com" }
+func (testSite) Sitemaps() []string { return []string{testSitemap} }
+
+func (testSite) Classify(loc *url.URL) (sites.URL, bool) {
+	if loc.Hostname() != "example.com" {
+		return sites.URL{}, false
+	}
+	s := loc.String()
+	return sites.URL{Language: content.Language(s), Kind: content.Kind(s), QuestionID: content.QuestionID(s)}, true
+}
+
+func (testSite) Parse(cfg content.Config, p *content.Page, c *content.Content) error {
+	if cfg.Has(content.ExtractTitle) {
+		title := p.Doc.Find("h1").Text()
+		c.Title = &title
+	}
+	if cfg.Has(content.ExtractAnswer) {
+		text := p.Doc.Find("p").Text()
+		c.Text = &text
+	}
+	if cfg.Has(content.ExtractBody) {
+		c.Body = p.Body
+	}
+	c.Normalize()
+	return nil
+}
+
+func (testSite) ParseRelated(c *content.Content) ([]content.Reference, []content.Category) {
+	return nil, nil
+}
+
+// testWeb serves the sitemap and the pages of the test site,
+// the missing pages are gone
+type testWeb struct {
+	urls    []*sitemap.URL
+	pages   map[string]string
+	fetched []string
+}
+
+func newTestScrapper(t *testing.T) (*Scapper, *repository.Memory, *testWeb) {
+	t.Helper()
+
+	store := repository.NewMemory()
+	s, web := newTestScrapperOf(store, store)
+
+	return s, store, web
+}
+
+// newTestScrapperOf syncs the test site to the stores
+func newTestScrapperOf(urls repository.URLStore, contents repository.ContentStore) (*Scapper, *testWeb) {
+	web := &testWeb{pages: map[string]string{}}
+
+	s := NewWithStores(urls, contents, []sites.Site{testSite{}})
+	s.getSitemap = func(loc string) (sitemap.Sitemap, error) {
+		if loc != testSitemap {
+			return sitemap.Sitemap{}, errors.New("unknown sitemap: " + loc)
+		}
+		urls := []*sitemap.URL{}
+		for _, u := range web.urls {
+			copied := *u
+			urls = append(urls, &copied)
+		}
+		return sitemap.Sitemap{URLS: urls}, nil
+	}
+	s.fetch = func(u *sitemap.URL) (*content.Page, error) {
+		web.fetched = append(web.fetched, u.Loc)
+		body, ok := web.pages[u.Loc]
+		if !ok {
+			return nil, content.ErrGone
+		}
+		return content.ParsePage(u, body)
+	}
+
+	return s, web
+}
+
+// publish adds a page to the sitemap, or changes it
+func (web *testWeb) publish(loc string, title string, lastMod time.Time) {
+	web.pages[loc] = page(title)
+
+	for _, u := range web.urls {
+		if u.Loc == loc {
+			u.LastMod = lastMod
+			return
+		}
+	}
+	web.urls = append(web.urls, &sitemap.URL{Loc: loc, LastMod: lastMod})
+}
+
+func page(title string) string {
+	return "<html><body><h1>" + title + "</h1><p>Answer of " + title + "</p></body></html>"
+}
+
+// syncAll syncs the sitemaps and the contents, as main does
+func syncAll(t *testing.T, s *Scapper, cfg content.Config) {
+	t.Helper()
+
+	require.Empty(t, s.SyncSitemaps(s.Sitemaps()))
+	require.NoError(t, s.SyncContents(cfg, 0))
+}
+
+func TestSyncGonePage(t *testing.T) {
+	s, store, web := newTestScrapper(t)
+
+	loc := "https://example.com/en/answers/1/a"
+	web.publish(loc, "A", time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC))
+	syncAll(t, s, content.Light)
+
+	// a 404 of a url still in the sitemap, fetched again by the full crawl
+	delete(web.pages, loc)
+	web.publish("https://example.com/en/answers/2/b", "B", time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC))
+	syncAll(t, s, content.Full)
+
+	c, err := store.FindByURL(loc)
+	require.NoError(t, err)
+	assert.True(t, c.DeletedAt.Valid)
+
+	_, err = store.FindByLoc(loc)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	b, err := store.FindByURL("https://example.com/en/answers/2/b")
+	require.NoError(t, err)
+	assert.False(t, b.DeletedAt.Valid)
+}
+
+func TestSyncGoneFromSitemap(t *testing.T) {
+	s, store, web := newTestScrapper(t)
+
+	loc := "https://example.com/en/answers/1/a"
+	lastMod := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	web.publish(loc, "A", lastMod)
+	syncAll(t, s, content.Full)
+
+	// the url leaves the sitemap, its content is a tombstone
+	web.urls = nil
+	syncAll(t, s, content.Full)
+
+	c, err := store.FindByURL(loc)
+	require.NoError(t, err)
+	assert.True(t, c.DeletedAt.Valid)
+
+	_, err = store.FindByLoc(loc)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	// back in the sitemap, the content is restored
+	web.publish(loc, "A", lastMod)
+	syncAll(t, s, content.Full)
+
+	restored, err := store.FindByURL(loc)
+	require.NoError(t, err)
+	assert.False(t, restored.DeletedAt.Valid)
+	assert.Equal(t, c.ID, restored.ID)
+	assert.Equal(t, "A", *restored.Title)
+}
+
+func TestSyncSitemapsFailed(t *testing.T) {
+	s, store, web := newTestScrapper(t)
+
+	loc := "https://example.com/en/answers/1/a"
+	web.publish(loc, "A", time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC))
+	syncAll(t, s, content.Full)
+
+	// the urls aren't removed when a sitemap failed
+	web.urls = nil
+	assert.Len(t, s.SyncSitemaps([]string{testSitemap, "https://example.com/missing.xml"}), 1)
+
+	c, err := store.FindByURL(loc)
+	require.NoError(t, err)
+	assert.False(t, c.DeletedAt.Valid)
+}
+
+// TestSyncLightToFull crawls a light content again by a full crawl,
+// a full content is covered by both crawls
+func TestSyncLightToFull(t *testing.T) {
+	s, store, web := newTestScrapper(t)
+
+	loc := "https://example.com/en/answers/1/a"
+	web.publish(loc, "A", time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	syncAll(t, s, content.Light)
+	syncAll(t, s, content.Light)
+	assert.Len(t, web.fetched, 1)
+
+	light, err := store.FindByURL(loc)
+	require.NoError(t, err)
+	assert.Equal(t, content.CrawlLight, light.Crawl)
+	assert.Equal(t, "A", *light.Title)
+	assert.Nil(t, light.Text)
+	assert.Empty(t, light.Body)
+
+	syncAll(t, s, content.Full)
+	assert.Len(t, web.fetched, 2)
+
+	full, err := store.FindByURL(loc)
+	require.NoError(t, err)
+	assert.Equal(t, light.ID, full.ID)
+	assert.Equal(t, content.CrawlFull, full.Crawl)
+	require.NotNil(t, full.Text)
+	assert.Equal(t, "Answer of A", *full.Text)
+	assert.Equal(t, page("A"), full.Body)
+	assert.Len(t, store.Revisions(repository.SourceContents, full.ID), 2)
+
+	syncAll(t, s, content.Full)
+	syncAll(t, s, content.Light)
+	assert.Len(t, web.fetched, 2)
+}
+
+// TestSyncUnchangedLastMod skips the urls of an unchanged lastmod,
+// a page changed without its lastmod isn't fetched
+func TestSyncUnchangedLastMod(t *testing.T) {
+	s, store, web := newTestScrapper(t)
+
+	loc := "https://example.com/en/answers/1/a"
+	web.publish(loc, "A", time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC))
+	syncAll(t, s, content.Full)
+	assert.Equal(t, []string{loc}, web.fetched)
+
+	web.pages[loc] = page("B")
+	syncAll(t, s, content.Full)
+	assert.Len(t, web.fetched, 1)
+
+	c, err := store.FindByURL(loc)
+	require.NoError(t, err)
+	assert.Equal(t, "A", *c.Title)
+
+	lastMod := time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)
+	web.publish(loc, "B", lastMod)
+	syncAll(t, s, content.Full)
+	assert.Len(t, web.fetched, 2)
+
+	c, err = store.FindByURL(loc)
+	require.NoError(t, err)
+	assert.Equal(t, "B", *c.Title)
+	assert.True(t, lastMod.Equal(c.LastModified))
+}
+
+// TestSyncLastModOffset saves the last modified date stored with another
+// offset than the lastmod of the same time, the url is no longer pending
+func TestSyncLastModOffset(t *testing.T) {
+	db := storagetest.Open(t)
+	_, err := migrations.Up(db)
+	require.NoError(t, err)
+
+	store := repository.NewGorm(db)
+	s, web := newTestScrapperOf(store, store)
+
+	loc := "https://example.com/en/answers/1/a"
+	lastMod := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	web.publish(loc, "A", lastMod)
+	syncAll(t, s, content.Full)
+
+	riyadh := lastMod.In(time.FixedZone("", 3*60*60))
+	require.NoError(t, db.Model(&content.Content{}).Where("url = ?", loc).UpdateColumn("last_modified", riyadh).Error)
+
+	if !storage.IsPostgres(db) {
+		pending, err := store.ListPending(content.Full, 0)
+		require.NoError(t, err)
+		require.Len(t, pending, 1)
+	}
+
+	syncAll(t, s, content.Full)
+	assert.Len(t, web.fetched, 1)
+
+	pending, err := store.ListPending(content.Full, 0)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+// TestSyncRevision creates a revision of a changed content, a new
+// lastmod of the same content only touches it
+func TestSyncRevision(t *testing.T) {
+	s, store, web := newTestScrapper(t)
+
+	loc := "https://example.com/en/answers/1/a"
+	web.publish(loc, "A", time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC))
+	syncAll(t, s, content.Full)
+
+	c, err := store.FindByURL(loc)
+	require.NoError(t, err)
+	require.Len(t, store.Revisions(repository.SourceContents, c.ID), 1)
+
+	touched := time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)
+	web.publish(loc, "A", touched)
+	syncAll(t, s, content.Full)
+
+	c, err = store.FindByURL(loc)
+	require.NoError(t, err)
+	assert.True(t, touched.Equal(c.LastModified))
+	assert.Len(t, store.Revisions(repository.SourceContents, c.ID), 1)
+
+	web.publish(loc, "B", time.Date(2023, 1, 3, 0, 0, 0, 0, time.UTC))
+	syncAll(t, s, content.Full)
+	assert.Len(t, web.fetched, 3)
+
+	changed, err := store.FindByURL(loc)
+	require.NoError(t, err)
+	assert.Equal(t, c.ID, changed.ID)
+	assert.Equal(t, "B", *changed.Title)
+
+	revisions := store.Revisions(repository.SourceContents, c.ID)
+	require.Len(t, revisions, 2)
+	assert.Equal(t, "A", *revisions[0].Title)
+	assert.Equal(t, "B", *revisions[1].Title)
+	assert.Equal(t, changed.Hash, revisions[1].Hash)
+}
+
+// TestSyncRevisionBackfill stores the revision of a content synced before
+// the revisions were kept when it changes, and its diff to the new one
+func TestSyncRevisionBackfill(t *testing.T) {
+	db := storagetest.Open(t)
+	_, err := migrations.Up(db)
+	require.NoError(t, err)
+
+	store := repository.NewGorm(db)
+	s, web := newTestScrapperOf(store, store)
+
+	loc := "https://example.com/en/answers/1/a"
+	web.publish(loc, "A", time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC))
+	syncAll(t, s, content.Full)
+
+	// synced before the revisions were kept
+	require.NoError(t, db.Where("question_id = ?", 1).Delete(&content.Revision{}).Error)
+	synced, err := store.FindByURL(loc)
+	require.NoError(t, err)
+
+	web.publish(loc, "B", time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC))
+	syncAll(t, s, content.Full)
+
+	revisions := []*content.Revision{}
+	require.NoError(t, db.Where("question_id = ?", 1).Order("id").Find(&revisions).Error)
+	require.Len(t, revisions, 2)
+
+	assert.Equal(t, "A", *revisions[0].Title)
+	assert.True(t, synced.UpdatedAt.Equal(revisions[0].FetchedAt), revisions[0].FetchedAt)
+	assert.True(t, synced.LastModified.Equal(revisions[0].LastModified))
+	assert.Equal(t, "B", *revisions[1].Title)
+	assert.True(t, revisions[1].FetchedAt.After(revisions[0].FetchedAt))
+
+	assert.Equal(t, "title:\n[-A-] {+B+}\ntext:\nAnswer of [-A-] {+B+}\n", content.DiffRevisions(revisions[0], revisions[1]))
+}
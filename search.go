@@ -0,0 +1,89 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hamza72x/islamqa-scrapper/log"
+	"github.com/hamza72x/islamqa-scrapper/search"
+
+	"gorm.io/gorm"
+)
+
+// searchCommand prints the ranked contents matching a query
+// usage: search [-lang en] [-limit 20] [-sort rank] <query>
+func searchCommand(db *gorm.DB, args []string) {
+	flags := flag.NewFlagSet("search", flag.ExitOnError)
+	language := flags.String("lang", "", "language code, e.g. en")
+	limit := flags.Int("limit", 20, "maximum number of results")
+	publishedSince := flags.String("published-since", "", "published at or after, RFC 3339 or YYYY-MM-DD")
+	publishedUntil := flags.String("published-until", "", "published before, RFC 3339 or YYYY-MM-DD")
+	minViews := flags.Int64("min-views", 0, "viewed at least as many times")
+	author := flags.String("author", "", "author name")
+	sort := flags.String("sort", search.SortRank, "rank, published (latest first) or views (most viewed first)")
+	flags.Parse(args)
+
+	query := strings.Join(flags.Args(), " ")
+	if len(query) == 0 {
+		log.Fatal("usage: search [-lang en] [-limit 20] [-sort rank] <query>")
+	}
+
+	opts := search.Options{
+		Language:       *language,
+		MinViews:       *minViews,
+		Author:         *author,
+		Sort:           *sort,
+		Limit:          *limit,
+		HighlightStart: "\033[1m",
+		HighlightEnd:   "\033[0m",
+	}
+
+	if len(*publishedSince) > 0 {
+		t, err := parseTime(*publishedSince)
+		if err != nil {
+			log.Fatal("invalid published-since: " + *publishedSince)
+		}
+		opts.PublishedSince = t
+	}
+
+	if len(*publishedUntil) > 0 {
+		t, err := parseTime(*publishedUntil)
+		if err != nil {
+			log.Fatal("invalid published-until: " + *publishedUntil)
+		}
+		opts.PublishedUntil = t
+	}
+
+	results, err := search.Search(db, query, opts)
+	if err != nil {
+		if errors.Is(err, search.ErrUnavailable) {
+			log.Fatal(err)
+		}
+		log.Fatal("failed to search: " + err.Error())
+	}
+
+	log.Ok("found", len(results), "results for", query)
+
+	for _, r := range results {
+		fmt.Printf("%s\t%s\n\t%s\n\t%s\n", r.Language, r.URL, r.Title, r.Snippet)
+
+		meta := []string{}
+		if r.Published != nil {
+			meta = append(meta, "published "+r.Published.Format("2006-01-02"))
+		}
+		if r.Views != nil {
+			meta = append(meta, strconv.FormatInt(*r.Views, 10)+" views")
+		}
+		if len(r.Author) > 0 {
+			meta = append(meta, r.Author)
+		}
+		if len(meta) > 0 {
+			fmt.Printf("\t%s\n", strings.Join(meta, ", "))
+		}
+
+		fmt.Println()
+	}
+}
@@ -0,0 +1,103 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hamza72x/islamqa-scrapper/normalize"
+
+	"gorm.io/gorm"
+)
+
+// postgres indexes a tsvector column generated from the normalised
+// columns, titles weigh more than texts
+// the 'simple' configuration is used, the columns are normalised already
+/*
+	search_vector tsvector GENERATED ALWAYS AS (
+		setweight(to_tsvector('simple', coalesce(title_normalized, '')), 'A') ||
+		setweight(to_tsvector('simple', coalesce(text_normalized, '')), 'B')
+	) STORED
+*/
+
+func migratePostgres(db *gorm.DB) error {
+	for _, src := range sources {
+		vector := fmt.Sprintf(
+			"setweight(to_tsvector('simple', coalesce(%s, '')), 'A') || setweight(to_tsvector('simple', coalesce(%s, '')), 'B')",
+			src.Columns[0], src.Columns[1],
+		)
+
+		statements := []string{
+			fmt.Sprintf(
+				"ALTER TABLE %s ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (%s) STORED",
+				src.Table, vector,
+			),
+			fmt.Sprintf(
+				"CREATE INDEX IF NOT EXISTS idx_%s_search_vector ON %s USING GIN (search_vector)",
+				src.Table, src.Table,
+			),
+		}
+
+		for _, statement := range statements {
+			if err := db.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// searchPostgres searches a table by its tsvector, or by substrings
+// for the trigram languages, which have no spaces between words
+func searchPostgres(db *gorm.DB, src source, trigram bool, query string, opts Options) ([]Result, error) {
+	if trigram {
+		return searchLike(db, src, query, opts)
+	}
+
+	results := []Result{}
+
+	terms := strings.Fields(normalize.Text(query))
+	if len(terms) == 0 {
+		return results, nil
+	}
+
+	tsquery := "plainto_tsquery('simple', ?)"
+	q := strings.Join(terms, " ")
+	headline := fmt.Sprintf(
+		`StartSel="%s", StopSel="%s", MaxWords=16, MinWords=8, MaxFragments=1, FragmentDelimiter="…"`,
+		strings.ReplaceAll(opts.HighlightStart, `"`, ""), strings.ReplaceAll(opts.HighlightEnd, `"`, ""),
+	)
+
+	snippet := fmt.Sprintf("ts_headline('simple', coalesce(c.%s, ''), %s, ?)", src.Columns[1], tsquery)
+	rank := "-ts_rank(c.search_vector, " + tsquery + ")"
+	where := "c.search_vector @@ " + tsquery + " AND " + fmt.Sprintf(tokenizers[0].Condition, "c")
+	args := []interface{}{q, headline, q, q}
+
+	if src.SoftDelete {
+		where += " AND c.deleted_at IS NULL"
+	}
+
+	conditions, conditionArgs := filters(opts)
+	where += conditions
+	args = append(args, conditionArgs...)
+
+	args = append(args, opts.Offset+opts.Limit)
+
+	if err := db.Raw(fmt.Sprintf(`
+		SELECT
+			'%s' AS source, c.id AS id, c.url AS url, coalesce(c.title, '') AS title,
+			coalesce(c.language, '') AS language,
+			%s AS snippet,
+			%s,
+			%s AS rank
+		FROM %s c
+		WHERE %s
+		ORDER BY %s
+		LIMIT ?`,
+		src.Table, snippet, metadataColumns, rank, src.Table, where, orders[opts.Sort],
+	), args...).Scan(&results).Error; err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
@@ -0,0 +1,526 @@
+package search
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/hamza72x/islamqa-scrapper/normalize"
+	"github.com/hamza72x/islamqa-scrapper/storage"
+
+	"gorm.io/gorm"
+)
+
+// ErrUnavailable is returned when sqlite is built without FTS5,
+// PostgreSQL always has full-text search
+// build with `-tags sqlite_fts5` to have it
+var ErrUnavailable = errors.New("sqlite FTS5 is not available, build with -tags sqlite_fts5")
+
+// source is a table being indexed, by its normalised columns
+type source struct {
+	Table   string
+	Columns []string
+
+	// SoftDelete tables have deleted_at (gorm.Model)
+	SoftDelete bool
+}
+
+// tokenizer is an FTS5 tokenizer, and the languages it indexes
+type tokenizer struct {
+	Suffix    string
+	Tokenize  string
+	Condition string
+}
+
+var (
+	sources = []source{
+		{"contents", []string{"title_normalized", "text_normalized"}, true},
+	}
+
+	// trigramLanguages have no spaces between words, chinese,
+	// japanese and thai are indexed by trigrams
+	trigramLanguages = []string{"ja", "th", "zh"}
+
+	tokenizers = []tokenizer{
+		{"fts", "unicode61 remove_diacritics 2", "coalesce(%s.language, '') NOT IN " + sqlList(trigramLanguages)},
+		{"fts_trigram", "trigram", "coalesce(%s.language, '') IN " + sqlList(trigramLanguages)},
+	}
+)
+
+// minTrigramTerm is the shortest term the trigram tables match,
+// the shorter ones are searched by LIKE
+const minTrigramTerm = 3
+
+const (
+	SortRank      = "rank"
+	SortPublished = "published"
+	SortViews     = "views"
+)
+
+// Result is a ranked search hit
+type Result struct {
+	Source   string
+	ID       uint
+	URL      string
+	Title    string
+	Language string
+	Snippet  string
+
+	// Published, Views and Author are the metadata of the page
+	Published *time.Time
+	Views     *int64
+	Author    string
+
+	// Rank is lower for better results, bm25 on sqlite,
+	// the negated ts_rank on PostgreSQL
+	Rank float64
+}
+
+// Options of Search, zero values are the defaults
+type Options struct {
+	// Language filters by language code, e.g. "en"
+	Language string
+
+	// PublishedSince and PublishedUntil filter by the date of the
+	// page, published at or after and before them
+	PublishedSince time.Time
+	PublishedUntil time.Time
+
+	// MinViews filters the results viewed at least as many times
+	MinViews int64
+
+	// Author filters by author name
+	Author string
+
+	// Sort is SortRank, the default, SortPublished, latest first, or
+	// SortViews, most viewed first, the results without are last
+	Sort string
+
+	// Limit is 20 by default
+	Limit int
+
+	// Offset skips the first results
+	Offset int
+
+	// HighlightStart and HighlightEnd wrap the matched terms
+	// in snippets, "**" by default
+	HighlightStart string
+	HighlightEnd   string
+}
+
+// Migrate creates the FTS5 tables of contents
+// and the triggers keeping them in sync, existing rows are indexed
+// when a table is created
+// PostgreSQL has generated tsvector columns and GIN indexes instead
+func Migrate(db *gorm.DB) error {
+	if storage.IsPostgres(db) {
+		return migratePostgres(db)
+	}
+
+	for _, src := range sources {
+		for _, tok := range tokenizers {
+			if err := migrate(db, src, tok); err != nil {
+				if strings.Contains(err.Error(), "no such module: fts5") {
+					return ErrUnavailable
+				}
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Drop drops the FTS5 tables and their triggers,
+// or the tsvector columns on PostgreSQL
+func Drop(db *gorm.DB) error {
+	statements := []string{}
+
+	for _, src := range sources {
+		if storage.IsPostgres(db) {
+			statements = append(statements,
+				fmt.Sprintf("DROP INDEX IF EXISTS idx_%s_search_vector", src.Table),
+				fmt.Sprintf("ALTER TABLE IF EXISTS %s DROP COLUMN IF EXISTS search_vector", src.Table),
+			)
+			continue
+		}
+
+		for _, tok := range tokenizers {
+			statements = append(statements, dropTable(src.Table+"_"+tok.Suffix)...)
+		}
+	}
+
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			if strings.Contains(err.Error(), "no such module: fts5") {
+				return ErrUnavailable
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+func migrate(db *gorm.DB, src source, tok tokenizer) error {
+	fts := src.Table + "_" + tok.Suffix
+	columns := strings.Join(src.Columns, ", ")
+
+	exists := int64(0)
+	if err := db.
+		Raw("SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = ?", fts).
+		Scan(&exists).
+		Error; err != nil {
+		return err
+	}
+
+	// the tables of the languages indexed before are indexed again
+	statements := []string{}
+	if exists > 0 {
+		insert := ""
+		if err := db.
+			Raw("SELECT coalesce(sql, '') FROM sqlite_master WHERE type = 'trigger' AND name = ?", fts+"_insert").
+			Scan(&insert).
+			Error; err != nil {
+			return err
+		}
+
+		if strings.Contains(insert, fmt.Sprintf(tok.Condition, "new")) {
+			return nil
+		}
+		statements = dropTable(fts)
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		statements = append(statements,
+			fmt.Sprintf(
+				"CREATE VIRTUAL TABLE %s USING fts5(%s, content='%s', content_rowid='id', tokenize='%s')",
+				fts, columns, src.Table, tok.Tokenize,
+			),
+			fmt.Sprintf(
+				"INSERT INTO %s(rowid, %s) SELECT id, %s FROM %s WHERE %s",
+				fts, columns, columns, src.Table, fmt.Sprintf(tok.Condition, src.Table),
+			),
+			trigger(fts, src, "insert", "AFTER INSERT", insertRow(fts, src, tok)),
+			trigger(fts, src, "delete", "AFTER DELETE", deleteRow(fts, src, tok)),
+			// the old row is deleted first, a single trigger keeps the order
+			trigger(fts, src, "update", "AFTER UPDATE", deleteRow(fts, src, tok)+"; "+insertRow(fts, src, tok)),
+		)
+
+		for _, statement := range statements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// dropTable drops an FTS5 table and its triggers
+func dropTable(fts string) []string {
+	statements := []string{}
+	for _, name := range []string{"insert", "delete", "update"} {
+		statements = append(statements, fmt.Sprintf("DROP TRIGGER IF EXISTS %s_%s", fts, name))
+	}
+	return append(statements, "DROP TABLE IF EXISTS "+fts)
+}
+
+func trigger(fts string, src source, name string, event string, body string) string {
+	return fmt.Sprintf(
+		"CREATE TRIGGER IF NOT EXISTS %s_%s %s ON %s BEGIN %s; END",
+		fts, name, event, src.Table, body,
+	)
+}
+
+func insertRow(fts string, src source, tok tokenizer) string {
+	return fmt.Sprintf(
+		"INSERT INTO %s(rowid, %s) SELECT new.id, %s WHERE %s",
+		fts, strings.Join(src.Columns, ", "), prefixed("new", src.Columns), fmt.Sprintf(tok.Condition, "new"),
+	)
+}
+
+// external content tables are told the old values to delete them
+func deleteRow(fts string, src source, tok tokenizer) string {
+	return fmt.Sprintf(
+		"INSERT INTO %s(%s, rowid, %s) SELECT 'delete', old.id, %s WHERE %s",
+		fts, fts, strings.Join(src.Columns, ", "), prefixed("old", src.Columns), fmt.Sprintf(tok.Condition, "old"),
+	)
+}
+
+// sqlList is the sql list of quoted values, they're not user input
+func sqlList(values []string) string {
+	return "('" + strings.Join(values, "', '") + "')"
+}
+
+// isTrigram is whether a language is indexed by trigrams
+func isTrigram(language string) bool {
+	for _, l := range trigramLanguages {
+		if l == language {
+			return true
+		}
+	}
+	return false
+}
+
+func prefixed(prefix string, columns []string) string {
+	values := []string{}
+	for _, c := range columns {
+		values = append(values, prefix+"."+c)
+	}
+	return strings.Join(values, ", ")
+}
+
+// Search returns the contents matching every term of the query,
+// best ranked first, the query is normalised as the indexed text is
+func Search(db *gorm.DB, query string, opts Options) ([]Result, error) {
+	if opts.Limit <= 0 {
+		opts.Limit = 20
+	}
+	if len(opts.HighlightStart) == 0 && len(opts.HighlightEnd) == 0 {
+		opts.HighlightStart, opts.HighlightEnd = "**", "**"
+	}
+
+	if len(opts.Sort) == 0 {
+		opts.Sort = SortRank
+	}
+	if _, ok := orders[opts.Sort]; !ok {
+		return nil, errors.New("unknown sort: " + opts.Sort)
+	}
+
+	match := matchQuery(query)
+	if len(match) == 0 {
+		return []Result{}, nil
+	}
+
+	results := []Result{}
+
+	for _, src := range sources {
+		for _, tok := range tokenizers {
+			trigram := tok.Suffix == "fts_trigram"
+
+			// the other tokenizer has no rows of the language
+			if len(opts.Language) > 0 && isTrigram(opts.Language) != trigram {
+				continue
+			}
+
+			var rows []Result
+			var err error
+			switch {
+			case storage.IsPostgres(db):
+				rows, err = searchPostgres(db, src, trigram, query, opts)
+			case trigram && hasShortTerm(query):
+				rows, err = searchLike(db, src, query, opts)
+			default:
+				rows, err = searchTable(db, src, src.Table+"_"+tok.Suffix, match, opts)
+			}
+			if err != nil {
+				if strings.Contains(err.Error(), "no such table") {
+					return nil, ErrUnavailable
+				}
+				return nil, err
+			}
+
+			results = append(results, rows...)
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return less(opts.Sort, results[i], results[j])
+	})
+
+	if opts.Offset >= len(results) {
+		return []Result{}, nil
+	}
+	results = results[opts.Offset:]
+
+	if len(results) > opts.Limit {
+		results = results[:opts.Limit]
+	}
+
+	return results, nil
+}
+
+func searchTable(db *gorm.DB, src source, fts string, match string, opts Options) ([]Result, error) {
+	results := []Result{}
+
+	where := fts + " MATCH ?"
+	args := []interface{}{opts.HighlightStart, opts.HighlightEnd, match}
+
+	if src.SoftDelete {
+		where += " AND c.deleted_at IS NULL"
+	}
+
+	conditions, conditionArgs := filters(opts)
+	where += conditions
+	args = append(args, conditionArgs...)
+
+	args = append(args, opts.Offset+opts.Limit)
+
+	// title matches weigh more than the text ones
+	if err := db.Raw(fmt.Sprintf(`
+		SELECT
+			'%s' AS source, c.id AS id, c.url AS url, coalesce(c.title, '') AS title,
+			coalesce(c.language, '') AS language,
+			snippet(%s, -1, ?, ?, '…', 16) AS snippet,
+			%s,
+			bm25(%s, 5.0, 1.0) AS rank
+		FROM %s
+		JOIN %s c ON c.id = %s.rowid
+		WHERE %s
+		ORDER BY %s
+		LIMIT ?`,
+		src.Table, fts, metadataColumns, fts, fts, src.Table, fts, where, orders[opts.Sort],
+	), args...).Scan(&results).Error; err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// searchLike searches the contents of the trigram languages by
+// substrings, the terms are not indexed, the title matches weigh more
+func searchLike(db *gorm.DB, src source, query string, opts Options) ([]Result, error) {
+	results := []Result{}
+
+	terms := strings.Fields(normalize.Text(query))
+	if len(terms) == 0 {
+		return results, nil
+	}
+
+	title := fmt.Sprintf("coalesce(c.%s, '')", src.Columns[0])
+	text := fmt.Sprintf("coalesce(c.%s, '')", src.Columns[1])
+
+	args := []interface{}{likePattern(terms[0])}
+
+	conditions := []string{fmt.Sprintf(tokenizers[1].Condition, "c")}
+	for _, term := range terms {
+		conditions = append(conditions, title+" || ' ' || "+text+` LIKE ? ESCAPE '\'`)
+		args = append(args, likePattern(term))
+	}
+	where := strings.Join(conditions, " AND ")
+
+	if src.SoftDelete {
+		where += " AND c.deleted_at IS NULL"
+	}
+
+	filterConditions, filterArgs := filters(opts)
+	where += filterConditions
+	args = append(args, filterArgs...)
+
+	args = append(args, opts.Offset+opts.Limit)
+
+	if err := db.Raw(fmt.Sprintf(`
+		SELECT
+			'%s' AS source, c.id AS id, c.url AS url, coalesce(c.title, '') AS title,
+			coalesce(c.language, '') AS language,
+			substr(%s, 1, 64) AS snippet,
+			%s,
+			CASE WHEN %s LIKE ? ESCAPE '\' THEN -5 ELSE -1 END AS rank
+		FROM %s c
+		WHERE %s
+		ORDER BY %s
+		LIMIT ?`,
+		src.Table, text, metadataColumns, title, src.Table, where, orders[opts.Sort],
+	), args...).Scan(&results).Error; err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// hasShortTerm is whether a normalised term of the query
+// is too short for the trigram tables
+func hasShortTerm(query string) bool {
+	for _, term := range strings.Fields(normalize.Text(query)) {
+		if utf8.RuneCountInString(term) < minTrigramTerm {
+			return true
+		}
+	}
+	return false
+}
+
+// likePattern matches the term anywhere, its wildcards escaped
+func likePattern(term string) string {
+	return "%" + strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(term) + "%"
+}
+
+// metadataColumns are the metadata of the results
+const metadataColumns = "c.published_at AS published, c.views AS views, coalesce(c.author, '') AS author"
+
+// orders are the ORDER BY of the sorts, of the contents c
+var orders = map[string]string{
+	SortRank:      "rank",
+	SortPublished: "c.published_at IS NULL, c.published_at DESC, rank",
+	SortViews:     "c.views IS NULL, c.views DESC, rank",
+}
+
+// filters are the conditions of the options on the contents c,
+// each one starting with AND
+func filters(opts Options) (string, []interface{}) {
+	where := ""
+	args := []interface{}{}
+
+	if len(opts.Language) > 0 {
+		where += " AND c.language = ?"
+		args = append(args, opts.Language)
+	}
+
+	if !opts.PublishedSince.IsZero() {
+		where += " AND c.published_at >= ?"
+		args = append(args, opts.PublishedSince)
+	}
+
+	if !opts.PublishedUntil.IsZero() {
+		where += " AND c.published_at < ?"
+		args = append(args, opts.PublishedUntil)
+	}
+
+	if opts.MinViews > 0 {
+		where += " AND c.views >= ?"
+		args = append(args, opts.MinViews)
+	}
+
+	if len(opts.Author) > 0 {
+		where += " AND c.author = ?"
+		args = append(args, opts.Author)
+	}
+
+	return where, args
+}
+
+// less orders the results of the tables as orders does
+func less(sort string, a Result, b Result) bool {
+	switch sort {
+	case SortPublished:
+		if (a.Published == nil) != (b.Published == nil) {
+			return a.Published != nil
+		}
+		if a.Published != nil && !a.Published.Equal(*b.Published) {
+			return a.Published.After(*b.Published)
+		}
+	case SortViews:
+		if (a.Views == nil) != (b.Views == nil) {
+			return a.Views != nil
+		}
+		if a.Views != nil && *a.Views != *b.Views {
+			return *a.Views > *b.Views
+		}
+	}
+
+	return a.Rank < b.Rank
+}
+
+// matchQuery quotes every normalised term, so user input
+// is never read as FTS5 query syntax
+func matchQuery(query string) string {
+	terms := []string{}
+	for _, term := range strings.Fields(normalize.Text(query)) {
+		term = strings.ReplaceAll(term, `"`, `""`)
+		terms = append(terms, `"`+term+`"`)
+	}
+	return strings.Join(terms, " ")
+}
@@ -0,0 +1,268 @@
+package search_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hamza72x/islamqa-scrapper/content"
+	"github.com/hamza72x/islamqa-scrapper/migrations"
+	"github.com/hamza72x/islamqa-scrapper/search"
+	"github.com/hamza72x/islamqa-scrapper/storage"
+	"github.com/hamza72x/islamqa-scrapper/storage/storagetest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func str(s string) *string {
+	return &s
+}
+
+// newTestDB is a migrated and indexed database of contents,
+// skipped when sqlite is built without FTS5
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db := storagetest.Open(t)
+
+	_, err := migrations.Up(db)
+	require.NoError(t, err)
+
+	views := []int64{10, 500}
+	published := []time.Time{
+		time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	contents := []*content.Content{
+		{
+			URL: "https://example.com/en/1", Language: "en", Title: str("Fasting in Ramadan"),
+			Text: str("The fast is obligatory."), PublishedAt: &published[0], Views: &views[0], Author: str("Author"),
+		},
+		{
+			URL: "https://example.com/en/2", Language: "en", Title: str("Prayer"),
+			Text: str("Prayer while fasting on a journey."), PublishedAt: &published[1], Views: &views[1],
+		},
+		{
+			URL: "https://example.com/ar/1", Language: "ar", Title: str("صيام رمضان"),
+			Text: str("صيام رمضان واجب."),
+		},
+		{
+			URL: "https://example.com/zh/1", Language: "zh", Title: str("斋月的斋戒"),
+			Text: str("斋戒是主命。"),
+		},
+		{
+			URL: "https://example.com/ja/1", Language: "ja", Title: str("ラマダンの断食"),
+			Text: str("断食は義務です。"),
+		},
+		{
+			URL: "https://example.com/th/1", Language: "th", Title: str("การถือศีลอดเดือนรอมฎอน"),
+			Text: str("การถือศีลอดเป็นข้อบังคับ"),
+		},
+		{
+			URL: "https://example.com/en/3", Language: "en", Title: str("Fasting, deleted"),
+			Text: str("Fasting."),
+		},
+	}
+
+	for _, c := range contents {
+		c.Normalize()
+		require.NoError(t, db.Create(c).Error)
+	}
+
+	// the index is populated from the existing rows and kept
+	// in sync by the triggers afterwards
+	err = search.Migrate(db)
+	if errors.Is(err, search.ErrUnavailable) {
+		t.Skip(err)
+	}
+	require.NoError(t, err)
+
+	require.NoError(t, db.Where("url = ?", "https://example.com/en/3").Delete(&content.Content{}).Error)
+
+	updated := &content.Content{}
+	require.NoError(t, db.Where("url = ?", "https://example.com/ar/1").First(updated).Error)
+	updated.Title = str("صيام التطوع")
+	updated.Normalize()
+	require.NoError(t, db.Save(updated).Error)
+
+	return db
+}
+
+func urls(results []search.Result) []string {
+	urls := []string{}
+	for _, r := range results {
+		urls = append(urls, r.URL)
+	}
+	return urls
+}
+
+func TestSearch(t *testing.T) {
+	db := newTestDB(t)
+
+	tests := []struct {
+		name  string
+		query string
+		opts  search.Options
+		want  []string
+	}{
+		{
+			name:  "title first",
+			query: "fasting",
+			want:  []string{"https://example.com/en/1", "https://example.com/en/2"},
+		},
+		{
+			name:  "every term",
+			query: "fasting journey",
+			want:  []string{"https://example.com/en/2"},
+		},
+		{
+			name:  "diacritics and case",
+			query: "RAMADÁN",
+			want:  []string{"https://example.com/en/1"},
+		},
+		{
+			name:  "updated row",
+			query: "التطوع",
+			want:  []string{"https://example.com/ar/1"},
+		},
+		{
+			name:  "chinese",
+			query: "斋戒是主命",
+			want:  []string{"https://example.com/zh/1"},
+		},
+		{
+			name:  "chinese term of a character",
+			query: "斋",
+			want:  []string{"https://example.com/zh/1"},
+		},
+		{
+			name:  "chinese terms of two characters",
+			query: "主命 斋戒",
+			want:  []string{"https://example.com/zh/1"},
+		},
+		{
+			name:  "short term wildcards",
+			query: "斋%",
+			want:  []string{},
+		},
+		{
+			name:  "japanese",
+			query: "断食は義務",
+			want:  []string{"https://example.com/ja/1"},
+		},
+		{
+			name:  "japanese short term",
+			query: "断食",
+			opts:  search.Options{Language: "ja"},
+			want:  []string{"https://example.com/ja/1"},
+		},
+		{
+			name:  "thai",
+			query: "ศีลอด",
+			want:  []string{"https://example.com/th/1"},
+		},
+		{
+			name:  "trigram language",
+			query: "斋戒是主命",
+			opts:  search.Options{Language: "ja"},
+			want:  []string{},
+		},
+		{
+			name:  "language",
+			query: "fasting",
+			opts:  search.Options{Language: "ar"},
+			want:  []string{},
+		},
+		{
+			name:  "most viewed",
+			query: "fasting",
+			opts:  search.Options{Sort: search.SortViews},
+			want:  []string{"https://example.com/en/2", "https://example.com/en/1"},
+		},
+		{
+			name:  "published since",
+			query: "fasting",
+			opts:  search.Options{PublishedSince: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)},
+			want:  []string{"https://example.com/en/2"},
+		},
+		{
+			name:  "author",
+			query: "fasting",
+			opts:  search.Options{Author: "Author"},
+			want:  []string{"https://example.com/en/1"},
+		},
+		{
+			name:  "offset",
+			query: "fasting",
+			opts:  search.Options{Offset: 1},
+			want:  []string{"https://example.com/en/2"},
+		},
+		{
+			name:  "query syntax",
+			query: `"fasting" OR NEAR(`,
+			want:  []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := search.Search(db, tt.query, tt.opts)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, urls(results))
+		})
+	}
+}
+
+func TestSearchSnippet(t *testing.T) {
+	db := newTestDB(t)
+
+	results, err := search.Search(db, "journey", search.Options{HighlightStart: "<b>", HighlightEnd: "</b>"})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Contains(t, results[0].Snippet, "<b>journey</b>")
+	assert.Equal(t, "Prayer", results[0].Title)
+	assert.Equal(t, "en", results[0].Language)
+}
+
+func TestSearchUnknownSort(t *testing.T) {
+	db := newTestDB(t)
+
+	_, err := search.Search(db, "fasting", search.Options{Sort: "unknown"})
+	assert.Error(t, err)
+}
+
+// TestMigrateLanguages indexes again the tables of the languages
+// indexed before, chinese only was indexed by trigrams
+func TestMigrateLanguages(t *testing.T) {
+	db := newTestDB(t)
+	if storage.IsPostgres(db) {
+		t.Skip("PostgreSQL has no FTS5 tables")
+	}
+
+	require.NoError(t, search.Drop(db))
+	for _, tok := range []struct{ suffix, tokenize, condition string }{
+		{"fts", "unicode61 remove_diacritics 2", "coalesce(new.language, '') != 'zh'"},
+		{"fts_trigram", "trigram", "coalesce(new.language, '') = 'zh'"},
+	} {
+		fts := "contents_" + tok.suffix
+		require.NoError(t, db.Exec(
+			"CREATE VIRTUAL TABLE "+fts+" USING fts5(title_normalized, text_normalized, content='contents', content_rowid='id', tokenize='"+tok.tokenize+"')",
+		).Error)
+		require.NoError(t, db.Exec(
+			"CREATE TRIGGER "+fts+"_insert AFTER INSERT ON contents BEGIN INSERT INTO "+fts+"(rowid, title_normalized, text_normalized) SELECT new.id, new.title_normalized, new.text_normalized WHERE "+tok.condition+"; END",
+		).Error)
+	}
+
+	require.NoError(t, search.Migrate(db))
+
+	results, err := search.Search(db, "断食は義務", search.Options{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"https://example.com/ja/1"}, urls(results))
+
+	results, err = search.Search(db, "fasting", search.Options{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"https://example.com/en/1", "https://example.com/en/2"}, urls(results))
+}
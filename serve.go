@@ -0,0 +1,39 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"net/http"
+	"os"
+
+	"github.com/hamza72x/islamqa-scrapper/api"
+	"github.com/hamza72x/islamqa-scrapper/log"
+
+	"gorm.io/gorm"
+)
+
+// serveCommand serves the read-only JSON API
+// usage: serve [-addr :8080] [-openapi]
+func serveCommand(db *gorm.DB, args []string) {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := flags.String("addr", ":8080", "address to listen on")
+	openAPI := flags.Bool("openapi", false, "print the OpenAPI spec and exit")
+	flags.Parse(args)
+
+	server := api.New(db)
+
+	if *openAPI {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(server.OpenAPI()); err != nil {
+			log.Fatal("failed to write OpenAPI spec: " + err.Error())
+		}
+		return
+	}
+
+	log.Info("serving on", *addr)
+
+	if err := http.ListenAndServe(*addr, server); err != nil {
+		log.Fatal("failed to serve: " + err.Error())
+	}
+}
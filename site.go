@@ -0,0 +1,26 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/hamza72x/islamqa-scrapper/log"
+	"github.com/hamza72x/islamqa-scrapper/staticsite"
+
+	"gorm.io/gorm"
+)
+
+// buildSiteCommand renders the contents into a static html site
+// usage: build-site [-lang en] [-out site]
+func buildSiteCommand(db *gorm.DB, args []string) {
+	flags := flag.NewFlagSet("build-site", flag.ExitOnError)
+	language := flags.String("lang", "", "language code, e.g. en, every language by default")
+	out := flags.String("out", "site", "output directory")
+	flags.Parse(args)
+
+	count, err := staticsite.Build(db, *out, *language)
+	if err != nil {
+		log.Fatal("failed to build site: " + err.Error())
+	}
+
+	log.Ok("built", count, "pages to", *out)
+}
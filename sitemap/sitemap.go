@@ -3,8 +3,10 @@ package sitemap
 import (
 	"encoding/xml"
 	"errors"
+	"strings"
 	"time"
 
+	"github.com/hamza72x/islamqa-scrapper/dates"
 	"github.com/hamza72x/islamqa-scrapper/helper"
 )
 
@@ -45,6 +47,31 @@ func (URL) TableName() string {
 	return "urls"
 }
 
+// UnmarshalXML reads the lastmod by dates.Parse, in UTC, a W3C datetime
+// without seconds or a date isn't RFC 3339
+func (u *URL) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type url URL
+	raw := struct {
+		*url
+		LastMod string `xml:"lastmod"`
+	}{url: (*url)(u)}
+
+	if err := d.DecodeElement(&raw, &start); err != nil {
+		return err
+	}
+
+	u.LastMod = time.Time{}
+	if lastMod := strings.TrimSpace(raw.LastMod); len(lastMod) > 0 {
+		t, err := dates.Parse(lastMod, "")
+		if err != nil {
+			return err
+		}
+		u.LastMod = t
+	}
+
+	return nil
+}
+
 // Get sitemap data from URL
 func Get(URL string) (Sitemap, error) {
 	data, err := helper.GetURLBytes(URL, "")
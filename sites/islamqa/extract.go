@@ -0,0 +1,61 @@
+package islamqa
+
+import (
+	"embed"
+	"errors"
+	"io/fs"
+
+	"github.com/hamza72x/islamqa-scrapper/content"
+	"github.com/hamza72x/islamqa-scrapper/rules"
+)
+
+// files are the default rules and their fixtures
+//
+//go:embed rules.json fixtures/*.html
+var files embed.FS
+
+// New is the site parsing by the embedded rules
+func New() *Site {
+	b, err := files.ReadFile("rules.json")
+	if err != nil {
+		panic(err)
+	}
+
+	rs, err := rules.Parse(b)
+	if err != nil {
+		panic("islamqa rules.json: " + err.Error())
+	}
+
+	site := &Site{}
+	if err := site.UseRules(rs, files); err != nil {
+		panic(err)
+	}
+
+	return site
+}
+
+// Rules are the rules in use and the files of their fixtures
+func (site *Site) Rules() (*rules.RuleSet, fs.FS) {
+	return site.ruleSet, site.files
+}
+
+// UseRules replaces the parsing rules, e.g. by the ones of a config file,
+// files are the files of their fixtures
+func (site *Site) UseRules(rs *rules.RuleSet, files fs.FS) error {
+	if rs.Site != Name {
+		return errors.New("rules of " + rs.Site + ", not " + Name)
+	}
+
+	e := rs.Extractors()
+	e.Register(content.AnyKind, content.ExtractStructured, content.StructuredExtractor)
+	e.Register(content.AnyKind, content.ExtractBody, extractBody)
+
+	site.ruleSet, site.files, site.extractors = rs, files, e
+
+	return nil
+}
+
+func extractBody(p *content.Page, c *content.Content) error {
+	c.Body = p.Body
+	return nil
+}
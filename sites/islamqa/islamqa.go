@@ -0,0 +1,68 @@
+package islamqa
+
+import (
+	"io/fs"
+	"net/url"
+	"strings"
+
+	"github.com/hamza72x/islamqa-scrapper/content"
+	"github.com/hamza72x/islamqa-scrapper/rules"
+	"github.com/hamza72x/islamqa-scrapper/sites"
+)
+
+const Name = "islamqa"
+
+// Site is the islamqa.info adapter, it parses the pages by its rules
+type Site struct {
+	// ruleSet are the parsing rules in use and files the files of their fixtures
+	ruleSet *rules.RuleSet
+	files   fs.FS
+
+	// extractors are the extractors of the islamqa.info pages,
+	// of the rules, of the structured data and of the whole body
+	extractors content.Extractors
+}
+
+var _ sites.RuleBased = (*Site)(nil)
+
+func init() {
+	sites.Register(New())
+}
+
+func (*Site) Name() string {
+	return Name
+}
+
+func (*Site) Host() string {
+	return "islamqa.info"
+}
+
+func (*Site) Sitemaps() []string {
+	return append([]string{}, sitemaps...)
+}
+
+// Classify parses the urls of islamqa.info and www.islamqa.info
+// e.g. https://islamqa.info/en/answers/1/...
+func (site *Site) Classify(loc *url.URL) (sites.URL, bool) {
+	host := strings.TrimPrefix(strings.ToLower(loc.Hostname()), "www.")
+	if host != site.Host() {
+		return sites.URL{}, false
+	}
+
+	s := loc.String()
+
+	return sites.URL{
+		Language:   content.Language(s),
+		Kind:       content.Kind(s),
+		QuestionID: content.QuestionID(s),
+	}, true
+}
+
+func (site *Site) Parse(cfg content.Config, p *content.Page, c *content.Content) error {
+	return cfg.Extract(site.extractors, p, c)
+}
+
+// ParseRelated parses the references and the categories by the rules
+func (site *Site) ParseRelated(c *content.Content) ([]content.Reference, []content.Category) {
+	return site.ruleSet.ParseRelated(c)
+}
@@ -0,0 +1,156 @@
+package islamqa
+
+import (
+	"io/fs"
+	"net/url"
+	"testing"
+	"testing/fstest"
+
+	"github.com/hamza72x/islamqa-scrapper/content"
+	"github.com/hamza72x/islamqa-scrapper/rules"
+	"github.com/hamza72x/islamqa-scrapper/sitemap"
+	"github.com/hamza72x/islamqa-scrapper/sites"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		loc  string
+		want sites.URL
+		ok   bool
+	}{
+		{"https://islamqa.info/en/answers/1/fasting", sites.URL{Language: "en", Kind: content.KindFatwa, QuestionID: 1}, true},
+		{"https://www.islamqa.info/ar/articles/100/ramadan", sites.URL{Language: "ar", Kind: content.KindArticle, QuestionID: 100}, true},
+		{"https://ISLAMQA.info/bn/answers/12345", sites.URL{Language: "bn", Kind: content.KindFatwa, QuestionID: 12345}, true},
+		{"https://islamqa.org/en/answers/1", sites.URL{}, false},
+		{"https://example.com/en/answers/1", sites.URL{}, false},
+	}
+
+	for _, tt := range tests {
+		loc, err := url.Parse(tt.loc)
+		require.NoError(t, err)
+
+		got, ok := New().Classify(loc)
+		assert.Equal(t, tt.ok, ok, tt.loc)
+		assert.Equal(t, tt.want, got, tt.loc)
+	}
+}
+
+func TestLink(t *testing.T) {
+	assert.Equal(t, "https://islamqa.info", sites.BaseURL(Name))
+
+	tests := []struct {
+		href string
+		want sites.URL
+		ok   bool
+	}{
+		{"/en/answers/1", sites.URL{Language: "en", Kind: content.KindFatwa, QuestionID: 1}, true},
+		{"https://islamqa.info/ar/articles/2/title", sites.URL{Language: "ar", Kind: content.KindArticle, QuestionID: 2}, true},
+		{"/en/categories/topics/52", sites.URL{}, false},
+		{"https://example.com/en/answers/1", sites.URL{}, false},
+		{"#note", sites.URL{}, false},
+	}
+
+	for _, tt := range tests {
+		s, got, ok := sites.Link(Name, tt.href)
+		assert.Equal(t, tt.ok, ok, tt.href)
+		assert.Equal(t, tt.want, got, tt.href)
+		if ok {
+			assert.Equal(t, Name, s.Name(), tt.href)
+		}
+	}
+}
+
+// TestApply parses every fixture by a full crawl
+func TestApply(t *testing.T) {
+	rs, fsys := New().Rules()
+	require.NotEmpty(t, rs.Fixtures)
+
+	for _, f := range rs.Fixtures {
+		t.Run(f.File, func(t *testing.T) {
+			b, err := fs.ReadFile(fsys, f.File)
+			require.NoError(t, err)
+
+			p, err := content.ParsePage(&sitemap.URL{Loc: f.URL}, string(b))
+			require.NoError(t, err)
+
+			c := &content.Content{}
+			require.NoError(t, sites.Apply(New(), content.Full, p, c))
+
+			assert.Equal(t, f.URL, c.URL)
+			assert.Equal(t, Name, c.Site)
+			assert.Equal(t, f.Kind, c.Kind)
+			assert.Equal(t, f.Language, c.Language)
+			assert.Equal(t, content.CrawlFull, c.Crawl)
+			assert.NotEmpty(t, c.Body)
+
+			if want, ok := f.Expect[content.ExtractTitle]; ok && want != nil && assert.NotNil(t, c.Title) {
+				assert.Equal(t, *want, *c.Title)
+			}
+		})
+	}
+}
+
+// TestRules checks the embedded rules against their fixtures
+func TestRules(t *testing.T) {
+	b, err := files.ReadFile("rules.json")
+	require.NoError(t, err)
+
+	rs, err := rules.Parse(b)
+	require.NoError(t, err)
+	require.NotEmpty(t, rs.Fixtures)
+	assert.NoError(t, rs.Check(files))
+
+	inUse, fsys := New().Rules()
+	assert.NoError(t, inUse.Check(fsys))
+}
+
+func TestParseRelated(t *testing.T) {
+	b, err := files.ReadFile("fixtures/qapage.html")
+	require.NoError(t, err)
+
+	references, categories := New().ParseRelated(&content.Content{Body: string(b)})
+	assert.Equal(t, []content.Reference{{
+		Position: 1, Marker: "1", MarkerAnchor: "fnref1", Anchor: "fn1", Text: "Narrated by Muslim (1162).",
+	}}, references)
+	assert.Equal(t, []content.Category{{TopicID: 63, Name: "Fasting"}}, categories)
+}
+
+// TestUseRules parses the related rows by the rules in use, of the site only
+func TestUseRules(t *testing.T) {
+	site := New()
+	rs, _ := site.Rules()
+
+	b, err := files.ReadFile("fixtures/fatwa.html")
+	require.NoError(t, err)
+	c := &content.Content{Body: string(b)}
+
+	require.NoError(t, site.UseRules(&rules.RuleSet{Site: Name, Rules: rs.Rules}, files))
+	references, categories := site.ParseRelated(c)
+	assert.Empty(t, references)
+	assert.Empty(t, categories)
+
+	// the other sites keep the embedded rules
+	_, categories = New().ParseRelated(c)
+	assert.Equal(t, []content.Category{{TopicID: 52, Name: "যাকাত"}}, categories)
+
+	changed, err := rules.Parse([]byte(`{
+		"site": "islamqa",
+		"rules": [{"field": "title", "selectors": [{"chain": ["h1"]}]}],
+		"categories": {"links": "nav a[href]", "pattern": "/topics/(\\d+)/"}
+	}`))
+	require.NoError(t, err)
+
+	fixtures := fstest.MapFS{}
+	require.NoError(t, site.UseRules(changed, fixtures))
+	_, categories = site.ParseRelated(c)
+	assert.Equal(t, []content.Category{{TopicID: 52, Name: "যাকাত"}}, categories)
+
+	inUse, fsys := site.Rules()
+	assert.Same(t, changed, inUse)
+	assert.Equal(t, fixtures, fsys)
+
+	assert.Error(t, site.UseRules(&rules.RuleSet{Site: "other"}, files))
+}
@@ -1,6 +1,7 @@
-package main
+package islamqa
 
-var siteMaps = []string{
+// sitemaps are crawled by sync, the commented ones are skipped
+var sitemaps = []string{
 	"https://islamqa.info/sitemaps/sitemap-fatawa-ar-1.xml",
 	"https://islamqa.info/sitemaps/sitemap-article-ar-1.xml",
 	// "https://islamqa.info/sitemaps/sitemap-book-ar-1.xml",
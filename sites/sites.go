@@ -0,0 +1,171 @@
+package sites
+
+import (
+	"errors"
+	"io/fs"
+	"net/url"
+
+	"github.com/hamza72x/islamqa-scrapper/content"
+	"github.com/hamza72x/islamqa-scrapper/rules"
+)
+
+// Site is the adapter of a fatwa website, the contents of every
+// site share the storage, the sync queue, the exports and the api
+type Site interface {
+	// Name is stored with the contents of the site, e.g. "islamqa"
+	Name() string
+
+	// Host is the host name of the site, e.g. "islamqa.info",
+	// the site relative links of its pages are of it
+	Host() string
+
+	// Sitemaps are the sitemap urls crawled by sync
+	Sitemaps() []string
+
+	// Classify parses a content url of the site,
+	// ok is false for the urls of the other sites
+	Classify(loc *url.URL) (u URL, ok bool)
+
+	// Parse sets the fields of c parsed from its page by the
+	// extractors of cfg, the url fields are set already
+	Parse(cfg content.Config, p *content.Page, c *content.Content) error
+
+	// ParseRelated parses the references and the categories
+	// of a content from its body
+	ParseRelated(c *content.Content) ([]content.Reference, []content.Category)
+}
+
+// RuleBased is a site parsing its pages by declarative rules,
+// see package rules
+type RuleBased interface {
+	Site
+
+	// Rules are the rules in use and the files of their fixtures
+	Rules() (*rules.RuleSet, fs.FS)
+
+	// UseRules replaces the rules, e.g. by the ones of a config file,
+	// files are the files of their fixtures
+	UseRules(rs *rules.RuleSet, files fs.FS) error
+}
+
+// URL is a classified content url
+type URL struct {
+	// Language is the language code, e.g. "en"
+	Language string
+
+	// Kind is content.KindFatwa or content.KindArticle
+	Kind string
+
+	// QuestionID is shared by the translations of a content
+	QuestionID uint
+}
+
+// registered are the sites in the order of registration
+var registered = []Site{}
+
+// Register adds a site, the site packages register themselves
+// when they're imported
+func Register(s Site) {
+	for i, r := range registered {
+		if r.Name() == s.Name() {
+			registered[i] = s
+			return
+		}
+	}
+	registered = append(registered, s)
+}
+
+// All are the registered sites
+func All() []Site {
+	return append([]Site{}, registered...)
+}
+
+// Get is the registered site of a name
+func Get(name string) (Site, bool) {
+	for _, s := range registered {
+		if s.Name() == name {
+			return s, true
+		}
+	}
+	return nil, false
+}
+
+// BaseURL is the https url of the host of a site by name,
+// e.g. https://islamqa.info, empty for an unknown site
+func BaseURL(name string) string {
+	s, ok := Get(name)
+	if !ok {
+		return ""
+	}
+	return "https://" + s.Host()
+}
+
+// ForURL is the first of sites classifying loc
+func ForURL(sites []Site, loc string) (Site, URL, bool) {
+	u, err := url.Parse(loc)
+	if err != nil {
+		return nil, URL{}, false
+	}
+
+	for _, s := range sites {
+		if classified, ok := s.Classify(u); ok {
+			return s, classified, true
+		}
+	}
+
+	return nil, URL{}, false
+}
+
+// Link classifies a link of a page of a site by name, a site
+// relative link is of the host of the site, ok is false for the
+// links to other pages than the contents of the registered sites
+/*
+	Link("islamqa", "/en/answers/1") // islamqa, {en fatwa 1}
+	Link("islamqa", "https://islamqa.info/ar/articles/2/...") // islamqa, {ar article 2}
+*/
+func Link(site string, href string) (Site, URL, bool) {
+	ref, err := url.Parse(href)
+	if err != nil {
+		return nil, URL{}, false
+	}
+
+	if !ref.IsAbs() {
+		base, err := url.Parse(BaseURL(site))
+		if err != nil || len(base.Host) == 0 {
+			return nil, URL{}, false
+		}
+		ref = base.ResolveReference(ref)
+	}
+
+	s, u, ok := ForURL(registered, ref.String())
+	if !ok || len(u.Kind) == 0 || u.QuestionID == 0 {
+		return nil, URL{}, false
+	}
+
+	return s, u, true
+}
+
+// Apply sets the url fields of c, then the fields parsed by the site
+// from the page by the extractors of cfg, c is a new content
+// or the stored content of the url
+func Apply(s Site, cfg content.Config, p *content.Page, c *content.Content) error {
+	u, err := url.Parse(p.URL.Loc)
+	if err != nil {
+		return err
+	}
+
+	classified, ok := s.Classify(u)
+	if !ok {
+		return errors.New("not a content url of " + s.Name() + ": " + p.URL.Loc)
+	}
+
+	c.URL = p.URL.Loc
+	c.Site = s.Name()
+	c.Language = classified.Language
+	c.Kind = classified.Kind
+	c.QuestionID = classified.QuestionID
+	c.LastModified = p.URL.LastMod
+	c.Crawl = cfg.Name
+
+	return s.Parse(cfg, p, c)
+}
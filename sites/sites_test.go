@@ -0,0 +1,34 @@
+package sites_test
+
+import (
+	"testing"
+
+	"github.com/hamza72x/islamqa-scrapper/sites"
+	_ "github.com/hamza72x/islamqa-scrapper/sites/islamqa"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRules checks the rules of every rule based site against
+// their fixtures, as `rules check` does
+func TestRules(t *testing.T) {
+	checked := 0
+
+	for _, site := range sites.All() {
+		rb, ok := site.(sites.RuleBased)
+		if !ok {
+			continue
+		}
+
+		t.Run(site.Name(), func(t *testing.T) {
+			rs, fsys := rb.Rules()
+			require.NotEmpty(t, rs.Fixtures)
+			assert.Equal(t, site.Name(), rs.Site)
+			assert.NoError(t, rs.Check(fsys))
+		})
+		checked++
+	}
+
+	assert.NotZero(t, checked)
+}
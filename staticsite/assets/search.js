@@ -0,0 +1,60 @@
+// client-side search over <language>/search.json, the text of the
+// index is normalised as the normalize package does, roughly
+(function () {
+  var root = document.currentScript.getAttribute("data-root");
+  var status = document.getElementById("status");
+  var results = document.getElementById("results");
+
+  function normalize(s) {
+    return s
+      .normalize("NFD")
+      .replace(/[\u0300-\u036f\u064b-\u065f\u0670\u06d6-\u06ed\u0640\u200c-\u200f\u061c]/g, "")
+      .replace(/ٱ/g, "ا")
+      .replace(/[ىی]/g, "ي")
+      .replace(/[ةۃہھ]/g, "ه")
+      .replace(/ک/g, "ك")
+      .normalize("NFC")
+      .toLowerCase();
+  }
+
+  var q = new URLSearchParams(location.search).get("q") || "";
+  var terms = normalize(q).split(/\s+/).filter(Boolean);
+  document.querySelector("input[name=q]").value = q;
+
+  if (terms.length === 0) {
+    return;
+  }
+
+  fetch("search.json")
+    .then(function (r) { return r.json(); })
+    .then(function (entries) {
+      var found = entries
+        .map(function (e) {
+          var title = normalize(e.title);
+          var score = 0;
+          for (var i = 0; i < terms.length; i++) {
+            if (e.text.indexOf(terms[i]) < 0) {
+              return null;
+            }
+            score += title.indexOf(terms[i]) >= 0 ? 5 : 1;
+          }
+          return { entry: e, score: score };
+        })
+        .filter(Boolean)
+        .sort(function (a, b) { return b.score - a.score; });
+
+      status.textContent = found.length;
+
+      found.forEach(function (f) {
+        var a = document.createElement("a");
+        a.href = root + f.entry.path;
+        a.textContent = f.entry.title;
+        var li = document.createElement("li");
+        li.appendChild(a);
+        results.appendChild(li);
+      });
+    })
+    .catch(function (err) {
+      status.textContent = err;
+    });
+})();
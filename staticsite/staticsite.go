@@ -0,0 +1,401 @@
+package staticsite
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"html"
+	"html/template"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hamza72x/islamqa-scrapper/content"
+	"github.com/hamza72x/islamqa-scrapper/export"
+	"github.com/hamza72x/islamqa-scrapper/normalize"
+	"github.com/hamza72x/islamqa-scrapper/sites"
+
+	"gorm.io/gorm"
+)
+
+//go:embed templates/*.html assets/*
+var files embed.FS
+
+// searchTextLength is the length in runes of the text of an answer
+// kept in the search index
+const searchTextLength = 1000
+
+// entry is a rendered content, the fatwa bodies are not kept
+type entry struct {
+	Site       string
+	QuestionID uint
+	Language   string
+	Kind       string
+	Title      string
+	URL        string
+
+	// Path is relative to the site root, e.g. en/islamqa/fatwa/1.html
+	Path       string
+	Categories []export.Category
+}
+
+type category struct {
+	TopicID uint
+	Name    string
+	Path    string
+	Entries []*entry
+}
+
+// language is a language of the site and its pages
+type language struct {
+	Code       string
+	Direction  string
+	Entries    []*entry
+	Categories []*category
+}
+
+// page is the data of every template
+type page struct {
+	// Root is the relative path from the page to the site root, e.g. ../../
+	Root      string
+	Title     string
+	Language  string
+	Direction string
+
+	Languages    []*language
+	Site         *language
+	Category     *category
+	Entry        *entry
+	Translations []*entry
+
+	Question string
+	Summary  string
+	Body     template.HTML
+
+	// Text is shown when there's no answer html
+	Text string
+}
+
+// searchEntry is an entry of <language>/search.json
+type searchEntry struct {
+	Title string `json:"title"`
+	Path  string `json:"path"`
+
+	// Text is the normalised title, question and answer beginning
+	Text string `json:"text"`
+}
+
+// linkRegex matches the links of an answer, the ones to the contents
+// of the sites are local, see sites.Link
+var linkRegex = regexp.MustCompile(`href="([^"]*)"`)
+
+// Build renders every content of language, every language when empty,
+// into dir as a static site: an index of languages, an index and a search
+// page per language, a page per category and per fatwa
+// returns the number of fatwa pages
+func Build(db *gorm.DB, dir string, lang string) (int, error) {
+	q := export.Query{
+		Source:   export.SourceContents,
+		Language: lang,
+	}
+
+	// the indexes are built first, the fatwa pages link to their translations
+	languages := map[string]*language{}
+	categories := map[string]*category{}
+	translations := map[string][]*entry{}
+	paths := map[string]string{}
+
+	err := export.Each(db, q, func(r *export.Record) error {
+		e := &entry{
+			Site:       segment(r.Site),
+			QuestionID: r.QuestionID,
+			Language:   segment(r.Language),
+			Kind:       segment(r.Kind),
+			Title:      r.Title,
+			URL:        r.URL,
+			Categories: r.Categories,
+		}
+		if len(e.Title) == 0 {
+			e.Title = e.URL
+		}
+		e.Path = entryPath(e, r.ID)
+
+		l, ok := languages[e.Language]
+		if !ok {
+			l = &language{Code: e.Language, Direction: content.Direction(e.Language)}
+			languages[e.Language] = l
+		}
+		l.Entries = append(l.Entries, e)
+
+		// the topic ids are of a site
+		for _, c := range r.Categories {
+			cat, ok := categories[categoryPath(e, c.TopicID)]
+			if !ok {
+				cat = &category{
+					TopicID: c.TopicID,
+					Name:    c.Name,
+					Path:    categoryPath(e, c.TopicID),
+				}
+				categories[cat.Path] = cat
+				l.Categories = append(l.Categories, cat)
+			}
+			cat.Entries = append(cat.Entries, e)
+		}
+
+		if e.QuestionID > 0 {
+			key := translationKey(e.Site, e.Kind, e.QuestionID)
+			translations[key] = append(translations[key], e)
+			paths[e.Language+"/"+key] = e.Path
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	codes := []*language{}
+	for _, l := range languages {
+		sort.Slice(l.Categories, func(i, j int) bool {
+			return l.Categories[i].Name < l.Categories[j].Name
+		})
+		codes = append(codes, l)
+	}
+	sort.Slice(codes, func(i, j int) bool {
+		return codes[i].Code < codes[j].Code
+	})
+
+	if err := copyAssets(dir); err != nil {
+		return 0, err
+	}
+
+	if err := render(dir, "index.html", "index.html", &page{
+		Title:     "IslamQA",
+		Direction: content.DirectionLTR,
+		Languages: codes,
+	}); err != nil {
+		return 0, err
+	}
+
+	for _, l := range codes {
+		base := page{
+			Root:      "../",
+			Language:  l.Code,
+			Direction: l.Direction,
+			Languages: codes,
+			Site:      l,
+		}
+
+		p := base
+		p.Title = "IslamQA (" + l.Code + ")"
+		if err := render(dir, path.Join(l.Code, "index.html"), "language.html", &p); err != nil {
+			return 0, err
+		}
+
+		p = base
+		p.Title = "IslamQA (" + l.Code + ")"
+		if err := render(dir, path.Join(l.Code, "search.html"), "search.html", &p); err != nil {
+			return 0, err
+		}
+
+		for _, c := range l.Categories {
+			p := base
+			p.Root = "../../../"
+			p.Title = c.Name
+			p.Category = c
+			if err := render(dir, c.Path, "category.html", &p); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	// fatwa pages and search indexes
+	index := map[string][]searchEntry{}
+	count := 0
+
+	err = export.Each(db, q, func(r *export.Record) error {
+		e := &entry{
+			Site:       segment(r.Site),
+			QuestionID: r.QuestionID,
+			Language:   segment(r.Language),
+			Kind:       segment(r.Kind),
+		}
+		e.Path = entryPath(e, r.ID)
+
+		l := languages[e.Language]
+
+		title := r.Title
+		if len(title) == 0 {
+			title = r.URL
+		}
+
+		p := &page{
+			Root:      "../../../",
+			Title:     title,
+			Language:  l.Code,
+			Direction: l.Direction,
+			Languages: codes,
+			Site:      l,
+			Entry: &entry{
+				Site:       e.Site,
+				QuestionID: r.QuestionID,
+				Language:   e.Language,
+				Kind:       e.Kind,
+				Title:      title,
+				URL:        r.URL,
+				Path:       e.Path,
+				Categories: r.Categories,
+			},
+			Question: r.Question,
+			Summary:  r.Summary,
+			Body:     template.HTML(localLinks(r.Site, r.HTML, paths, "../../../")),
+			Text:     r.Text,
+		}
+
+		for _, t := range translations[translationKey(e.Site, e.Kind, e.QuestionID)] {
+			if t.Language != e.Language {
+				p.Translations = append(p.Translations, t)
+			}
+		}
+
+		if err := render(dir, e.Path, "fatwa.html", p); err != nil {
+			return err
+		}
+
+		text := []rune(normalize.Text(title + " " + r.Question + " " + r.Text))
+		if len(text) > searchTextLength {
+			text = text[:searchTextLength]
+		}
+
+		index[l.Code] = append(index[l.Code], searchEntry{
+			Title: title,
+			Path:  e.Path,
+			Text:  string(text),
+		})
+
+		count++
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for code, entries := range index {
+		b, err := json.Marshal(entries)
+		if err != nil {
+			return 0, err
+		}
+		if err := write(dir, path.Join(code, "search.json"), b); err != nil {
+			return 0, err
+		}
+	}
+
+	return count, nil
+}
+
+// entryPath is <language>/<site>/<kind>/<question id>.html, the row
+// id is used when the url has no question id
+func entryPath(e *entry, id uint) string {
+	name := strconv.FormatUint(uint64(e.QuestionID), 10)
+	if e.QuestionID == 0 {
+		name = "c" + strconv.FormatUint(uint64(id), 10)
+	}
+	return path.Join(e.Language, e.Site, e.Kind, name+".html")
+}
+
+// categoryPath is <language>/<site>/categories/<topic id>.html
+func categoryPath(e *entry, topicID uint) string {
+	return path.Join(e.Language, e.Site, "categories", strconv.FormatUint(uint64(topicID), 10)+".html")
+}
+
+// translationKey is shared by the translations of a content of a site
+func translationKey(site string, kind string, questionID uint) string {
+	return site + "/" + kind + "/" + strconv.FormatUint(uint64(questionID), 10)
+}
+
+// localLinks points the links to the answers and articles of the sites
+// to their pages when they're in the static site, the other site
+// relative links to the host of the site of the content
+func localLinks(site string, body string, paths map[string]string, root string) string {
+	base := sites.BaseURL(site)
+
+	return linkRegex.ReplaceAllStringFunc(body, func(href string) string {
+		m := linkRegex.FindStringSubmatch(href)
+
+		if s, u, ok := sites.Link(site, html.UnescapeString(m[1])); ok {
+			key := segment(u.Language) + "/" + translationKey(s.Name(), segment(u.Kind), u.QuestionID)
+			if p, ok := paths[key]; ok {
+				return `href="` + root + p + `"`
+			}
+		}
+
+		if len(base) > 0 && strings.HasPrefix(m[1], "/") && !strings.HasPrefix(m[1], "//") {
+			return `href="` + base + m[1] + `"`
+		}
+
+		return href
+	})
+}
+
+// segment is "unknown" for a missing language or kind
+func segment(s string) string {
+	if len(s) == 0 {
+		return "unknown"
+	}
+	return s
+}
+
+// templates are parsed once, each with the layout
+var templates = map[string]*template.Template{}
+
+func render(dir string, name string, tmpl string, p *page) error {
+	t, ok := templates[tmpl]
+	if !ok {
+		var err error
+		if t, err = template.ParseFS(files, "templates/layout.html", "templates/"+tmpl); err != nil {
+			return err
+		}
+		templates[tmpl] = t
+	}
+
+	var b bytes.Buffer
+	if err := t.ExecuteTemplate(&b, "layout", p); err != nil {
+		return err
+	}
+
+	return write(dir, name, b.Bytes())
+}
+
+func copyAssets(dir string) error {
+	entries, err := files.ReadDir("assets")
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		b, err := files.ReadFile("assets/" + e.Name())
+		if err != nil {
+			return err
+		}
+		if err := write(dir, path.Join("assets", e.Name()), b); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func write(dir string, name string, b []byte) error {
+	file := filepath.Join(dir, filepath.FromSlash(name))
+
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(file, b, 0644)
+}
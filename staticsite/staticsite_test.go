@@ -0,0 +1,33 @@
+package staticsite
+
+import (
+	"testing"
+
+	_ "github.com/hamza72x/islamqa-scrapper/sites/islamqa"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalLinks(t *testing.T) {
+	paths := map[string]string{
+		"en/islamqa/fatwa/1": "en/islamqa/fatwa/1.html",
+	}
+
+	tests := []struct {
+		site string
+		body string
+		want string
+	}{
+		{"islamqa", `<a href="/en/answers/1/fasting">`, `<a href="../../../en/islamqa/fatwa/1.html">`},
+		{"islamqa", `<a href="https://islamqa.info/en/answers/1">`, `<a href="../../../en/islamqa/fatwa/1.html">`},
+		{"islamqa", `<a href="/en/answers/2">`, `<a href="https://islamqa.info/en/answers/2">`},
+		{"islamqa", `<a href="/en/categories/topics/63">`, `<a href="https://islamqa.info/en/categories/topics/63">`},
+		{"islamqa", `<a href="//example.com/a">`, `<a href="//example.com/a">`},
+		{"islamqa", `<a href="#note">`, `<a href="#note">`},
+		{"unknown", `<a href="/en/answers/2">`, `<a href="/en/answers/2">`},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, localLinks(tt.site, tt.body, paths, "../../../"), tt.body)
+	}
+}
@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"gorm.io/gorm"
+)
+
+// InsertIgnore inserts the rows into the columns of a table, the rows
+// conflicting with a unique index are skipped
+// PostgreSQL copies the rows with COPY into a temporary table first,
+// SQLite inserts them in batches
+// returns the number of inserted rows
+func InsertIgnore(db *gorm.DB, table string, columns []string, rows [][]interface{}) (int64, error) {
+	if len(rows) == 0 {
+		return 0, nil
+	}
+
+	if IsPostgres(db) {
+		return copyIgnore(db, table, columns, rows)
+	}
+
+	// sqlite allows 999 variables per statement
+	batch := 999 / len(columns)
+	placeholder := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ") + ")"
+
+	inserted := int64(0)
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for start := 0; start < len(rows); start += batch {
+			end := start + batch
+			if end > len(rows) {
+				end = len(rows)
+			}
+
+			placeholders := []string{}
+			args := []interface{}{}
+			for _, row := range rows[start:end] {
+				placeholders = append(placeholders, placeholder)
+				args = append(args, row...)
+			}
+
+			result := tx.Exec(fmt.Sprintf(
+				"INSERT OR IGNORE INTO %s (%s) VALUES %s",
+				table, strings.Join(columns, ", "), strings.Join(placeholders, ", "),
+			), args...)
+			if result.Error != nil {
+				return result.Error
+			}
+
+			inserted += result.RowsAffected
+		}
+
+		return nil
+	})
+
+	return inserted, err
+}
+
+func copyIgnore(db *gorm.DB, table string, columns []string, rows [][]interface{}) (int64, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return 0, err
+	}
+
+	ctx := context.Background()
+
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	inserted := int64(0)
+
+	err = conn.Raw(func(driverConn interface{}) error {
+		pg := driverConn.(*stdlib.Conn).Conn()
+
+		tx, err := pg.Begin(ctx)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback(ctx)
+
+		tmp := "copy_" + table
+		list := strings.Join(columns, ", ")
+
+		if _, err := tx.Exec(ctx, fmt.Sprintf(
+			"CREATE TEMPORARY TABLE %s ON COMMIT DROP AS SELECT %s FROM %s WITH NO DATA",
+			tmp, list, table,
+		)); err != nil {
+			return err
+		}
+
+		if _, err := tx.CopyFrom(ctx, pgx.Identifier{tmp}, columns, pgx.CopyFromRows(rows)); err != nil {
+			return err
+		}
+
+		tag, err := tx.Exec(ctx, fmt.Sprintf(
+			"INSERT INTO %s (%s) SELECT %s FROM %s ON CONFLICT DO NOTHING",
+			table, list, list, tmp,
+		))
+		if err != nil {
+			return err
+		}
+
+		inserted = tag.RowsAffected()
+
+		return tx.Commit(ctx)
+	})
+
+	return inserted, err
+}
@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// JSON is a column of a structured value, JSONB on PostgreSQL
+// and JSON text on SQLite
+type JSON[T any] struct {
+	Data T
+}
+
+func NewJSON[T any](data T) JSON[T] {
+	return JSON[T]{Data: data}
+}
+
+func (j JSON[T]) GormDataType() string {
+	return "json"
+}
+
+func (JSON[T]) GormDBDataType(db *gorm.DB, field *schema.Field) string {
+	if IsPostgres(db) {
+		return "JSONB"
+	}
+	return "TEXT"
+}
+
+func (j JSON[T]) Value() (driver.Value, error) {
+	b, err := json.Marshal(j.Data)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (j *JSON[T]) Scan(src interface{}) error {
+	var b []byte
+
+	switch v := src.(type) {
+	case nil:
+		var zero T
+		j.Data = zero
+		return nil
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return errors.New("unsupported json column value")
+	}
+
+	return json.Unmarshal(b, &j.Data)
+}
+
+func (j JSON[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(j.Data)
+}
+
+func (j *JSON[T]) UnmarshalJSON(b []byte) error {
+	return json.Unmarshal(b, &j.Data)
+}
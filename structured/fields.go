@@ -0,0 +1,301 @@
+package structured
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// field names of Fields, see Field
+const (
+	FieldTitle       = "title"
+	FieldQuestion    = "question"
+	FieldAnswer      = "answer"
+	FieldDescription = "description"
+	FieldAuthor      = "author"
+	FieldPublisher   = "publisher"
+	FieldPublished   = "published"
+	FieldModified    = "modified"
+	FieldViews       = "views"
+)
+
+// Fields are the values of the structured data of a page,
+// empty when missing
+type Fields struct {
+	Title       string
+	Question    string
+	Answer      string
+	Description string
+	Author      string
+	Publisher   string
+
+	// Published and Modified are as written in the page
+	Published string
+	Modified  string
+
+	// Views is the count of a ViewAction interaction statistic
+	Views string
+}
+
+// Field is a value by name, e.g. FieldTitle
+func (f Fields) Field(name string) (string, bool) {
+	switch name {
+	case FieldTitle:
+		return f.Title, true
+	case FieldQuestion:
+		return f.Question, true
+	case FieldAnswer:
+		return f.Answer, true
+	case FieldDescription:
+		return f.Description, true
+	case FieldAuthor:
+		return f.Author, true
+	case FieldPublisher:
+		return f.Publisher, true
+	case FieldPublished:
+		return f.Published, true
+	case FieldModified:
+		return f.Modified, true
+	case FieldViews:
+		return f.Views, true
+	}
+
+	return "", false
+}
+
+// schema are the schema.org properties of the fields by type, the types are in order of
+// preference, a question is preferred over the page having it
+var schema = []struct {
+	types  []string
+	fields map[string][]string
+
+	// when selects the nodes of the types, every one when nil
+	when func(node map[string]interface{}) bool
+}{
+	{
+		types: []string{"Question"},
+		fields: map[string][]string{
+			FieldTitle:       {"name", "headline"},
+			FieldQuestion:    {"text"},
+			FieldAnswer:      {"acceptedAnswer.text", "suggestedAnswer.text"},
+			FieldDescription: {"description"},
+			FieldAuthor:      {"author.name", "author"},
+			FieldPublished:   {"datePublished", "dateCreated"},
+			FieldModified:    {"dateModified"},
+		},
+	},
+	{
+		types: []string{"Answer"},
+		fields: map[string][]string{
+			FieldAnswer: {"text"},
+		},
+	},
+	{
+		types: []string{"Article", "NewsArticle", "BlogPosting", "ScholarlyArticle"},
+		fields: map[string][]string{
+			FieldTitle:       {"headline", "name"},
+			FieldAnswer:      {"articleBody"},
+			FieldDescription: {"description"},
+			FieldAuthor:      {"author.name", "author"},
+			FieldPublisher:   {"publisher.name", "publisher"},
+			FieldPublished:   {"datePublished", "dateCreated"},
+			FieldModified:    {"dateModified"},
+		},
+	},
+	{
+		types: []string{"QAPage", "WebPage"},
+		fields: map[string][]string{
+			FieldTitle:       {"name", "headline"},
+			FieldDescription: {"description"},
+			FieldAuthor:      {"author.name", "author"},
+			FieldPublisher:   {"publisher.name", "publisher"},
+			FieldPublished:   {"datePublished", "dateCreated"},
+			FieldModified:    {"dateModified"},
+		},
+	},
+	{
+		types: []string{"WebSite"},
+		fields: map[string][]string{
+			FieldPublisher: {"name"},
+		},
+	},
+	{
+		// {"@type": "InteractionCounter", "interactionType": "https://schema.org/ViewAction", "userInteractionCount": 1}
+		types: []string{"InteractionCounter"},
+		fields: map[string][]string{
+			FieldViews: {"userInteractionCount"},
+		},
+		when: func(node map[string]interface{}) bool {
+			action, ok := first(node["interactionType"]).(map[string]interface{})
+			if !ok {
+				action = map[string]interface{}{"@type": first(node["interactionType"])}
+			}
+			return isType(action, []string{"ViewAction", "WatchAction"})
+		},
+	},
+}
+
+// opengraph tags of the fields, in order of preference
+var tags = map[string][]string{
+	FieldTitle:       {"og:title", "twitter:title"},
+	FieldDescription: {"og:description", "twitter:description"},
+	FieldAuthor:      {"article:author", "twitter:creator"},
+	FieldPublisher:   {"article:publisher", "og:site_name"},
+	FieldPublished:   {"article:published_time"},
+	FieldModified:    {"article:modified_time", "og:updated_time"},
+}
+
+// Fields are the values of the structured data, the JSON-LD is preferred
+// over the microdata, and both over the OpenGraph and Twitter tags
+func (d Data) Fields() Fields {
+	values := map[string]string{}
+
+	for _, nodes := range [][]map[string]interface{}{d.JSONLD, d.Microdata} {
+		for _, p := range schema {
+			walk(nodes, func(node map[string]interface{}) {
+				if !isType(node, p.types) || (p.when != nil && !p.when(node)) {
+					return
+				}
+				for field, paths := range p.fields {
+					if len(values[field]) > 0 {
+						continue
+					}
+					for _, path := range paths {
+						if value := lookup(node, path); len(value) > 0 {
+							values[field] = value
+							break
+						}
+					}
+				}
+			})
+		}
+	}
+
+	for field, names := range tags {
+		if len(values[field]) > 0 {
+			continue
+		}
+		for _, name := range names {
+			if value := d.OpenGraph[name]; len(value) > 0 {
+				values[field] = value
+				break
+			}
+		}
+	}
+
+	return Fields{
+		Title:       values[FieldTitle],
+		Question:    values[FieldQuestion],
+		Answer:      values[FieldAnswer],
+		Description: values[FieldDescription],
+		Author:      values[FieldAuthor],
+		Publisher:   values[FieldPublisher],
+		Published:   values[FieldPublished],
+		Modified:    values[FieldModified],
+		Views:       values[FieldViews],
+	}
+}
+
+// walk visits the nodes and their nested nodes, depth first
+func walk(nodes []map[string]interface{}, fn func(node map[string]interface{})) {
+	var visit func(v interface{})
+	visit = func(v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			fn(v)
+
+			// in order of the keys, the first of the nodes is the same one
+			keys := make([]string, 0, len(v))
+			for key := range v {
+				if key != "@context" {
+					keys = append(keys, key)
+				}
+			}
+			sort.Strings(keys)
+
+			for _, key := range keys {
+				visit(v[key])
+			}
+		case []interface{}:
+			for _, e := range v {
+				visit(e)
+			}
+		}
+	}
+
+	for _, node := range nodes {
+		visit(node)
+	}
+}
+
+// isType is whether the @type of a node is one of types,
+// "https://schema.org/Question" is a Question
+func isType(node map[string]interface{}, types []string) bool {
+	var nodeTypes []interface{}
+
+	switch t := node["@type"].(type) {
+	case string:
+		nodeTypes = []interface{}{t}
+	case []interface{}:
+		nodeTypes = t
+	case []string:
+		for _, s := range t {
+			nodeTypes = append(nodeTypes, s)
+		}
+	}
+
+	for _, t := range nodeTypes {
+		s, ok := t.(string)
+		if !ok {
+			continue
+		}
+		if i := strings.LastIndexAny(s, "/#"); i >= 0 {
+			s = s[i+1:]
+		}
+		for _, want := range types {
+			if s == want {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// lookup is the string at a dotted path of properties, the first
+// element of an array is taken, e.g. "acceptedAnswer.text"
+func lookup(node map[string]interface{}, path string) string {
+	var v interface{} = node
+
+	for _, key := range strings.Split(path, ".") {
+		m, ok := first(v).(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		v = m[key]
+	}
+
+	switch v := first(v).(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case map[string]interface{}:
+		// {"@value": "..."}
+		if s, ok := v["@value"].(string); ok {
+			return strings.TrimSpace(s)
+		}
+	}
+
+	return ""
+}
+
+func first(v interface{}) interface{} {
+	if values, ok := v.([]interface{}); ok {
+		if len(values) == 0 {
+			return nil
+		}
+		return values[0]
+	}
+	return v
+}
@@ -0,0 +1,133 @@
+package structured
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// microdata items
+/*
+	<div itemscope itemtype="https://schema.org/Question">
+		<h1 itemprop="name">...</h1>
+		<div itemprop="acceptedAnswer" itemscope itemtype="https://schema.org/Answer">
+			<div itemprop="text">...</div>
+		</div>
+	</div>
+*/
+func parseMicrodata(doc *goquery.Document) []map[string]interface{} {
+	items := []map[string]interface{}{}
+
+	// the top level items aren't a property of another item
+	doc.Find("[itemscope]").Each(func(_ int, s *goquery.Selection) {
+		if _, ok := s.Attr("itemprop"); ok {
+			return
+		}
+		items = append(items, item(s))
+	})
+
+	return items
+}
+
+func item(s *goquery.Selection) map[string]interface{} {
+	node := map[string]interface{}{}
+
+	if types := strings.Fields(s.AttrOr("itemtype", "")); len(types) > 0 {
+		node["@type"] = types
+	}
+	if id, ok := s.Attr("itemid"); ok {
+		node["@id"] = id
+	}
+
+	properties(s, node)
+
+	return node
+}
+
+// properties adds the properties of the item scope s to node,
+// the properties of the nested items are theirs
+func properties(s *goquery.Selection, node map[string]interface{}) {
+	s.Children().Each(func(_ int, child *goquery.Selection) {
+		names := strings.Fields(child.AttrOr("itemprop", ""))
+		_, scope := child.Attr("itemscope")
+
+		if len(names) > 0 {
+			var value interface{}
+			if scope {
+				value = item(child)
+			} else {
+				value = propertyValue(child)
+			}
+
+			for _, name := range names {
+				add(node, name, value)
+			}
+		}
+
+		if !scope {
+			properties(child, node)
+		}
+	})
+}
+
+// add sets a property, the repeated ones are arrays
+func add(node map[string]interface{}, name string, value interface{}) {
+	existing, ok := node[name]
+	if !ok {
+		node[name] = value
+		return
+	}
+
+	if values, ok := existing.([]interface{}); ok {
+		node[name] = append(values, value)
+		return
+	}
+
+	node[name] = []interface{}{existing, value}
+}
+
+// propertyValue is the value of an element by its tag, as in the
+// microdata spec, its text otherwise
+func propertyValue(s *goquery.Selection) string {
+	attr := ""
+
+	switch goquery.NodeName(s) {
+	case "meta":
+		attr = "content"
+	case "audio", "embed", "iframe", "img", "source", "track", "video":
+		attr = "src"
+	case "a", "area", "link":
+		attr = "href"
+	case "object":
+		attr = "data"
+	case "data", "meter":
+		attr = "value"
+	case "time":
+		attr = "datetime"
+	}
+
+	if len(attr) > 0 {
+		if value, ok := s.Attr(attr); ok {
+			return strings.TrimSpace(value)
+		}
+	}
+
+	// an answer body is kept as html
+	if s.Nodes[0].FirstChild != nil && hasElements(s.Nodes[0]) {
+		if h, err := s.Html(); err == nil {
+			return strings.TrimSpace(h)
+		}
+	}
+
+	return strings.TrimSpace(s.Text())
+}
+
+func hasElements(n *html.Node) bool {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode {
+			return true
+		}
+	}
+	return false
+}
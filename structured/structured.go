@@ -0,0 +1,113 @@
+package structured
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Data is the structured data of a page, the schema.org nodes of the
+// JSON-LD blocks and of the microdata, and the OpenGraph and Twitter tags
+type Data struct {
+	// JSONLD are the objects of the JSON-LD blocks, a block of an array
+	// or of a @graph is an object per element
+	JSONLD []map[string]interface{} `json:"json_ld,omitempty"`
+
+	// Microdata are the top level items, in the JSON-LD form:
+	// {"@type": "Question", "name": "...", "author": {"@type": "Person", ...}}
+	Microdata []map[string]interface{} `json:"microdata,omitempty"`
+
+	// OpenGraph are the og:, article: and twitter: meta tags by property,
+	// the first tag of a property is kept
+	OpenGraph map[string]string `json:"open_graph,omitempty"`
+}
+
+// Parse reads the structured data of a page, the invalid JSON-LD
+// blocks are skipped
+func Parse(doc *goquery.Document) Data {
+	return Data{
+		JSONLD:    parseJSONLD(doc),
+		Microdata: parseMicrodata(doc),
+		OpenGraph: parseOpenGraph(doc),
+	}
+}
+
+// Empty is whether the page has no structured data
+func (d Data) Empty() bool {
+	return len(d.JSONLD) == 0 && len(d.Microdata) == 0 && len(d.OpenGraph) == 0
+}
+
+// jsonld blocks
+/*
+	<script type="application/ld+json">
+		{"@context": "https://schema.org", "@type": "QAPage", "mainEntity": {"@type": "Question", ...}}
+	</script>
+*/
+func parseJSONLD(doc *goquery.Document) []map[string]interface{} {
+	objects := []map[string]interface{}{}
+
+	doc.Find("script[type='application/ld+json']").Each(func(_ int, s *goquery.Selection) {
+		var v interface{}
+		if err := json.Unmarshal([]byte(strings.TrimSpace(s.Text())), &v); err != nil {
+			return
+		}
+
+		objects = append(objects, flatten(v)...)
+	})
+
+	return objects
+}
+
+func flatten(v interface{}) []map[string]interface{} {
+	switch v := v.(type) {
+	case []interface{}:
+		objects := []map[string]interface{}{}
+		for _, e := range v {
+			objects = append(objects, flatten(e)...)
+		}
+		return objects
+	case map[string]interface{}:
+		if graph, ok := v["@graph"]; ok {
+			return flatten(graph)
+		}
+		return []map[string]interface{}{v}
+	}
+
+	return nil
+}
+
+// opengraph and twitter tags
+/*
+	<meta property="og:title" content="..." />
+	<meta property="article:published_time" content="2019-05-01T00:00:00+03:00" />
+	<meta name="twitter:title" content="..." />
+*/
+func parseOpenGraph(doc *goquery.Document) map[string]string {
+	tags := map[string]string{}
+
+	doc.Find("meta[property], meta[name]").Each(func(_ int, s *goquery.Selection) {
+		property, ok := s.Attr("property")
+		if !ok {
+			property, _ = s.Attr("name")
+		}
+		property = strings.ToLower(strings.TrimSpace(property))
+
+		if !strings.HasPrefix(property, "og:") &&
+			!strings.HasPrefix(property, "article:") &&
+			!strings.HasPrefix(property, "twitter:") {
+			return
+		}
+
+		value, ok := s.Attr("content")
+		if !ok {
+			return
+		}
+
+		if _, seen := tags[property]; !seen {
+			tags[property] = strings.TrimSpace(value)
+		}
+	})
+
+	return tags
+}
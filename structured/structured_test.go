@@ -0,0 +1,126 @@
+package structured
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parse(t *testing.T, page string) Data {
+	t.Helper()
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(page))
+	require.NoError(t, err)
+
+	return Parse(doc)
+}
+
+func TestFields(t *testing.T) {
+	tests := []struct {
+		name string
+		page string
+		want Fields
+	}{
+		{
+			name: "graph",
+			page: `<script type="application/ld+json">{
+				"@context": "https://schema.org",
+				"@graph": [
+					{"@type": "WebSite", "name": "Islam Question & Answer"},
+					{"@type": "QAPage", "name": "Page title", "datePublished": "2000-03-21",
+						"mainEntity": {"@type": "Question", "name": "Fasting on Arafah", "text": "What is its virtue?",
+							"author": {"@type": "Person", "name": "A questioner"},
+							"acceptedAnswer": {"@type": "Answer", "text": "<p>It expiates two years.</p>"}}},
+					{"@type": "InteractionCounter", "interactionType": {"@type": "ViewAction"}, "userInteractionCount": 1234}
+				]
+			}</script>`,
+			want: Fields{
+				Title: "Fasting on Arafah", Question: "What is its virtue?", Answer: "<p>It expiates two years.</p>",
+				Author: "A questioner", Publisher: "Islam Question & Answer", Published: "2000-03-21", Views: "1234",
+			},
+		},
+		{
+			name: "blocks",
+			page: `<script type="application/ld+json">{"@type": "BreadcrumbList", "itemListElement": []}</script>
+				<script type="application/ld+json">{not json</script>
+				<script type="application/ld+json">[
+					{"@type": "https://schema.org/Article", "headline": "An article", "articleBody": "Body",
+						"author": [{"@type": "Person", "name": "First"}, {"@type": "Person", "name": "Second"}],
+						"publisher": {"@type": "Organization", "name": "Publisher"},
+						"dateModified": {"@value": "2023-06-27"}}
+				]</script>`,
+			want: Fields{
+				Title: "An article", Answer: "Body", Author: "First", Publisher: "Publisher", Modified: "2023-06-27",
+			},
+		},
+		{
+			name: "invalid json-ld",
+			page: `<head>
+				<script type="application/ld+json">{"@type": "Question", "name": "Truncated"</script>
+				<meta property="og:title" content=" OpenGraph title ">
+				<meta name="twitter:description" content="Twitter description">
+				<meta property="article:published_time" content="2019-05-01T00:00:00+03:00">
+				<meta property="og:site_name" content="Site">
+			</head>`,
+			want: Fields{
+				Title: "OpenGraph title", Description: "Twitter description", Publisher: "Site", Published: "2019-05-01T00:00:00+03:00",
+			},
+		},
+		{
+			name: "microdata only",
+			page: `<div itemscope itemtype="https://schema.org/Question">
+				<h1 itemprop="name"> Microdata question </h1>
+				<div><p itemprop="text">Is it allowed?</p></div>
+				<span itemprop="author" itemscope itemtype="https://schema.org/Person"><span itemprop="name">Author</span></span>
+				<time itemprop="dateCreated" datetime="2001-01-01">1 January 2001</time>
+				<div itemprop="acceptedAnswer" itemscope itemtype="https://schema.org/Answer">
+					<div itemprop="text"><p>Yes.</p></div>
+				</div>
+			</div>`,
+			want: Fields{
+				Title: "Microdata question", Question: "Is it allowed?", Answer: "<p>Yes.</p>", Author: "Author", Published: "2001-01-01",
+			},
+		},
+		{
+			name: "json-ld over microdata over opengraph",
+			page: `<meta property="og:title" content="OpenGraph">
+				<meta property="og:description" content="OpenGraph description">
+				<div itemscope itemtype="https://schema.org/Question"><h1 itemprop="name">Microdata</h1><p itemprop="text">Microdata text</p></div>
+				<script type="application/ld+json">{"@type": "Question", "name": "JSON-LD"}</script>`,
+			want: Fields{Title: "JSON-LD", Question: "Microdata text", Description: "OpenGraph description"},
+		},
+		{
+			name: "views of a view action only",
+			page: `<script type="application/ld+json">{"@type": "Article", "headline": "Title", "interactionStatistic": [
+				{"@type": "InteractionCounter", "interactionType": "https://schema.org/LikeAction", "userInteractionCount": 1},
+				{"@type": "InteractionCounter", "interactionType": "https://schema.org/ViewAction", "userInteractionCount": 99}
+			]}</script>`,
+			want: Fields{Title: "Title", Views: "99"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, parse(t, tt.page).Fields())
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	d := parse(t, `<script type="application/ld+json">{"@graph": [{"@type": "WebSite"}, {"@type": "WebPage"}]}</script>
+		<script type="application/ld+json">not json</script>
+		<div itemscope itemtype="https://schema.org/Question"><span itemprop="name">Q</span></div>
+		<meta property="og:title" content="First"><meta property="og:title" content="Second">
+		<meta name="description" content="Not a tag">`)
+
+	assert.Len(t, d.JSONLD, 2)
+	assert.Equal(t, []map[string]interface{}{{"@type": []string{"https://schema.org/Question"}, "name": "Q"}}, d.Microdata)
+	assert.Equal(t, map[string]string{"og:title": "First"}, d.OpenGraph)
+	assert.False(t, d.Empty())
+
+	assert.True(t, parse(t, `<p>No structured data</p>`).Empty())
+}